@@ -1,41 +1,116 @@
 package cron
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/metrics"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/service"
 	"github.com/nighostchris/everytrack-backend/internal/tools"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
 type CronJob struct {
-	Logger *zap.Logger
-	Db     *pgxpool.Pool
-	Env    *config.Config
+	Logger         *zap.Logger
+	Db             *pgxpool.Pool
+	Env            *config.Config
+	Ledger         *service.LedgerService
+	CreditAccounts repository.CreditAccountRepository
+	FuturePayments repository.FuturePaymentRepository
+	// Closed by Stop, jobs check it between rounds and between records they process
+	stopping chan struct{}
+	running  sync.WaitGroup
 }
 
 func Init(db *pgxpool.Pool, env *config.Config, logger *zap.Logger) *CronJob {
-	return &CronJob{Db: db, Env: env, Logger: logger}
+	repositories := repository.NewPgxRepositories(db)
+	return &CronJob{
+		Db:             db,
+		Env:            env,
+		Logger:         logger,
+		Ledger:         service.NewLedgerService(repositories),
+		CreditAccounts: repositories.CreditAccounts,
+		FuturePayments: repositories.FuturePayments,
+		stopping:       make(chan struct{}),
+	}
 }
 
-// Fetch exchange rates from Github Currency API every day
-func (cj *CronJob) SubscribeExchangeRates() {
-	tool := tools.GithubCurrencyApi{Db: cj.Db, Logger: cj.Logger}
+// Run job in background every interval until the cron jobs are stopped, every round is traced and recorded in metrics
+func (cj *CronJob) schedule(name string, interval time.Duration, job func(ctx context.Context) error) {
+	cj.running.Add(1)
 	go func() {
+		defer cj.running.Done()
 		for {
-			tool.FetchLatestExchangeRates()
-			time.Sleep(24 * time.Hour)
+			cj.run(name, job)
+
+			select {
+			case <-cj.stopping:
+				return
+			case <-time.After(interval):
+			}
 		}
 	}()
 }
 
-func (cj *CronJob) SubscribeTwelveDataFinancialData() {
-	tool := tools.TwelveDataFinancialDataApi{Db: cj.Db, Logger: cj.Logger, Env: cj.Env}
+func (cj *CronJob) run(name string, job func(ctx context.Context) error) {
+	ctx, span := tracing.Tracer().Start(context.Background(), fmt.Sprintf("cron %s", name))
+	defer span.End()
+
+	startedAt := time.Now()
+	jobError := job(ctx)
+	metrics.ObserveCronJob(name, startedAt, jobError)
+	if jobError != nil {
+		span.RecordError(jobError)
+		span.SetStatus(codes.Error, jobError.Error())
+	}
+}
+
+// Check if the cron jobs are being stopped, so that a running job can return early between records
+func (cj *CronJob) isStopping() bool {
+	select {
+	case <-cj.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop scheduling jobs and wait for the running ones to finish, or give up once ctx is done
+func (cj *CronJob) Stop(ctx context.Context) error {
+	close(cj.stopping)
+
+	finished := make(chan struct{})
 	go func() {
-		for {
-			tool.FetchLatestUSStockPrice()
-			time.Sleep(30 * time.Minute)
-		}
+		cj.running.Wait()
+		close(finished)
 	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Fetch exchange rates from Github Currency API every day
+func (cj *CronJob) SubscribeExchangeRates() {
+	tool := tools.GithubCurrencyApi{Db: cj.Db, Logger: cj.Logger}
+	cj.schedule("fetch_exchange_rates", 24*time.Hour, func(ctx context.Context) error {
+		return tool.FetchLatestExchangeRates(ctx)
+	})
+}
+
+func (cj *CronJob) SubscribeTwelveDataFinancialData() {
+	tool := tools.TwelveDataFinancialDataApi{Db: cj.Db, Logger: cj.Logger, Env: cj.Env}
+	cj.schedule("fetch_us_stock_prices", 30*time.Minute, func(ctx context.Context) error {
+		return tool.FetchLatestUSStockPrice(ctx)
+	})
 }
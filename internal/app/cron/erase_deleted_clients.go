@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/mailer"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+)
+
+// Permanently erase clients whose deletion grace period is over, together with every record they own
+func (cj *CronJob) EraseDeletedClients() {
+	clientMailer := mailer.New(cj.Env, cj.Logger)
+
+	cj.schedule("erase_deleted_clients", time.Hour, func(ctx context.Context) error {
+		logger := cj.Logger.With(tracing.LogFields(ctx)...)
+		logger.Info("starts")
+
+		clientDeletions, getClientDeletionsError := database.GetAllDueClientDeletions(ctx, cj.Db)
+		if getClientDeletionsError != nil {
+			logger.Error(fmt.Sprintf("failed to get due client deletions from database. %s", getClientDeletionsError.Error()))
+			return getClientDeletionsError
+		}
+
+		failedDeletions := 0
+
+		for _, clientDeletion := range clientDeletions {
+			if cj.isStopping() {
+				break
+			}
+			// Email is gone once client is erased, so it has to be read beforehand for the confirmation mail
+			client, getClientError := database.GetClientById(ctx, cj.Db, clientDeletion.ClientId)
+			if getClientError != nil {
+				logger.Error(fmt.Sprintf("failed to get client %s from database. %s", clientDeletion.ClientId, getClientError.Error()))
+				failedDeletions++
+				continue
+			}
+
+			isErased, eraseError := database.EraseClient(ctx, cj.Db, clientDeletion.ClientId)
+			if eraseError != nil {
+				logger.Error(fmt.Sprintf("failed to erase client %s from database. %s", clientDeletion.ClientId, eraseError.Error()))
+				failedDeletions++
+				continue
+			}
+			if !isErased {
+				logger.Info(fmt.Sprintf("deletion of client %s has been cancelled", clientDeletion.ClientId))
+				continue
+			}
+			logger.Info(fmt.Sprintf("erased client %s", clientDeletion.ClientId))
+
+			sendMailError := clientMailer.Send(mailer.Mail{
+				To:      client.Email,
+				Subject: "Your Everytrack account has been deleted",
+				Body:    "Your Everytrack account and all of its data have been permanently deleted as you requested.\r\n\r\nThank you for using Everytrack.",
+			})
+			if sendMailError != nil {
+				logger.Error(fmt.Sprintf("failed to send account deleted mail. %s", sendMailError.Error()))
+			}
+		}
+
+		if failedDeletions > 0 {
+			return fmt.Errorf("failed to erase %d deleted clients", failedDeletions)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+)
+
+// Close statements of credit accounts and schedule repayment of the statement balance from the repayment account on due date
+func (cj *CronJob) MonitorCreditStatements() {
+	cj.schedule("monitor_credit_statements", time.Hour, func(ctx context.Context) error {
+		logger := cj.Logger.With(tracing.LogFields(ctx)...)
+		logger.Info("starts")
+
+		// Get all credit accounts of all users in database
+		creditAccounts, getCreditAccountsError := cj.CreditAccounts.GetAllCreditAccounts(ctx)
+		if getCreditAccountsError != nil {
+			logger.Error(
+				fmt.Sprintf("failed to get all credit accounts from database. %s", getCreditAccountsError.Error()),
+			)
+			return getCreditAccountsError
+		}
+
+		failedAccounts := 0
+
+		for _, creditAccount := range creditAccounts {
+			if cj.isStopping() {
+				break
+			}
+
+			// Record the statement of last closed period unless created already, and schedule the repayment if anything is owed
+			statement, closeStatementError := cj.Ledger.CloseCreditStatement(ctx, creditAccount, time.Now().UTC())
+			if closeStatementError != nil {
+				logger.Error(
+					fmt.Sprintf("failed to close statement for credit account %s. %s", creditAccount.Id, closeStatementError.Error()),
+				)
+				failedAccounts++
+				continue
+			}
+			if statement == nil {
+				continue
+			}
+			logger.Info(fmt.Sprintf("closed statement of balance %s for credit account %s", statement.Balance.String(), creditAccount.Id))
+			if statement.Balance.IsPositive() && !statement.WithRepayment {
+				logger.Info(fmt.Sprintf("credit account %s has no repayment account, statement will not be repaid automatically", creditAccount.Id))
+			}
+		}
+
+		logger.Info("finished")
+
+		if failedAccounts > 0 {
+			return fmt.Errorf("failed to close statements of %d credit accounts", failedAccounts)
+		}
+		return nil
+	})
+}
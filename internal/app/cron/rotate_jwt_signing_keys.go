@@ -0,0 +1,47 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+)
+
+// Rotate the asymmetric JWT signing key periodically and prune keys which can no longer have signed any unexpired token
+func (cj *CronJob) RotateJwtSigningKeys(keyStore *utils.KeyStore) {
+	if !keyStore.IsAsymmetric() {
+		return
+	}
+
+	rotation := time.Hour * time.Duration(cj.Env.JwtKeyRotationInHour)
+	// Tokens signed by a key right before it is rotated stay valid until the longest token expiry has passed
+	retention := rotation + time.Hour*time.Duration(cj.Env.RefreshTokenExpiryInHour)
+
+	cj.schedule("rotate_jwt_signing_keys", time.Hour, func(ctx context.Context) error {
+		logger := cj.Logger.With(tracing.LogFields(ctx)...)
+		logger.Info("starts")
+
+		// Pick up keys rotated by other instances sharing the same keys directory
+		reloadError := keyStore.Reload()
+		if reloadError != nil {
+			logger.Error(fmt.Sprintf("failed to reload jwt keys. %s", reloadError.Error()))
+		}
+
+		var rotateError error
+		if keyStore.GetSigningKeyAge() >= rotation {
+			if rotateError = keyStore.Rotate(); rotateError != nil {
+				logger.Error(fmt.Sprintf("failed to rotate jwt signing key. %s", rotateError.Error()))
+			}
+		}
+
+		pruneError := keyStore.Prune(retention)
+		if pruneError != nil {
+			logger.Error(fmt.Sprintf("failed to prune expired jwt keys. %s", pruneError.Error()))
+		}
+
+		return errors.Join(reloadError, rotateError, pruneError)
+	})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/mailer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const clientDataExportPath = "/v1/settings/export"
+
+type RequestAccountDeletionRequestBody struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code"`
+	// Client has to confirm the data export has been offered before the deletion can be scheduled
+	ExportAcknowledged bool `json:"exportAcknowledged" validate:"required"`
+}
+
+func (ah *AuthHandler) GetAccountDeletion(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	clientId := c.Get("uid").(string)
+	clientDeletion, getClientDeletionError := database.GetClientDeletion(ctx, ah.Db, clientId)
+	if getClientDeletionError != nil && !errors.Is(getClientDeletionError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client deletion from database. %s", getClientDeletionError.Error()))
+		return apperror.Internal()
+	}
+	if getClientDeletionError != nil {
+		return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"scheduled": false, "scheduledAt": nil, "exportUrl": clientDataExportPath}})
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		LooseJson{"success": true, "data": LooseJson{"scheduled": true, "scheduledAt": clientDeletion.ScheduledAt.Unix(), "exportUrl": clientDataExportPath}},
+	)
+}
+
+// Schedule deletion of the account after re-authenticating client, it can be cancelled until the grace period is over
+func (ah *AuthHandler) RequestAccountDeletion(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(RequestAccountDeletionRequestBody)
+	logger.Info("starts")
+
+	// Personal access tokens are meant for automation and must never be able to delete the account
+	if c.Get("apiTokenId") != nil {
+		logger.Error("account deletion requested with api token")
+		return apperror.PermissionDenied("Account deletion requires a login session.")
+	}
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	clientId := c.Get("uid").(string)
+	client, getClientError := database.GetClientById(ctx, ah.Db, clientId)
+	if getClientError != nil {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got client from database")
+
+	// Re-authentication shares the login throttling so that a stolen access token cannot be used to guess the password
+	retryAfter, checkThrottleError := ah.checkLoginThrottle(c, client.Email)
+	if checkThrottleError != nil {
+		logger.Error(fmt.Sprintf("failed to check login throttling. %s", checkThrottleError.Error()))
+		return apperror.Internal()
+	}
+	if retryAfter > 0 {
+		logger.Error(fmt.Sprintf("re-authentication throttled for another %s", retryAfter.String()))
+		return ah.respondLoginThrottled(c, retryAfter)
+	}
+
+	if verifyPasswordError := bcrypt.CompareHashAndPassword([]byte(client.Password), []byte(data.Password)); verifyPasswordError != nil {
+		logger.Error(fmt.Sprintf("password verification failed. %s", verifyPasswordError.Error()))
+		ah.recordLoginAttempt(c, client.Email, &client.Id, false)
+		return apperror.Unauthenticated("Invalid password.")
+	}
+	logger.Debug("verified password")
+
+	// Clients with 2FA enabled have to present a second factor as well
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, clientId)
+	if getClientTotpError != nil && !errors.Is(getClientTotpError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return apperror.Internal()
+	}
+	if getClientTotpError == nil && clientTotp.ConfirmedAt.Valid {
+		if len(data.Code) == 0 {
+			return apperror.InvalidField("code", "format", "has an invalid value")
+		}
+		isCodeValid, verifyError := ah.verifySecondFactor(ctx, clientTotp, data.Code)
+		if verifyError != nil {
+			logger.Error(fmt.Sprintf("failed to verify second factor. %s", verifyError.Error()))
+			return apperror.Internal()
+		}
+		if !isCodeValid {
+			logger.Error("invalid second factor code")
+			ah.recordLoginAttempt(c, client.Email, &client.Id, false)
+			return apperror.Unauthenticated("Invalid code.")
+		}
+		logger.Debug("verified second factor")
+	}
+
+	scheduledAt := time.Now().Add(time.Hour * 24 * time.Duration(ah.Env.AccountDeletionGracePeriodInDay))
+	isScheduled, scheduleError := database.ScheduleClientDeletion(ctx, ah.Db, clientId, scheduledAt)
+	if scheduleError != nil {
+		logger.Error(fmt.Sprintf("failed to schedule client deletion in database. %s", scheduleError.Error()))
+		return apperror.Internal()
+	}
+	if !isScheduled {
+		logger.Error("client deletion has been scheduled already")
+		return apperror.Conflict("Account deletion already scheduled.")
+	}
+	logger.Info(fmt.Sprintf("scheduled deletion of client %s at %s", clientId, scheduledAt.Format(time.RFC3339)))
+
+	// Failing to notify client should not undo the scheduled deletion, which is visible in settings anyway
+	sendMailError := ah.Mailer.Send(mailer.Mail{
+		To:      client.Email,
+		Subject: "Your Everytrack account is scheduled for deletion",
+		Body: fmt.Sprintf(
+			"Your Everytrack account and all of its data will be permanently deleted on %s.\r\n\r\nYou can still download a copy of your data or cancel the deletion from your settings before then:\r\n%s/settings\r\n\r\nIf it was not you, log in and cancel the deletion, then change your password immediately.",
+			scheduledAt.UTC().Format("2 January 2006 15:04 MST"),
+			ah.Env.AppUrl,
+		),
+	})
+	if sendMailError != nil {
+		logger.Error(fmt.Sprintf("failed to send account deletion mail. %s", sendMailError.Error()))
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"scheduledAt": scheduledAt.Unix()}})
+}
+
+func (ah *AuthHandler) CancelAccountDeletion(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	clientId := c.Get("uid").(string)
+	isCancelled, cancelError := database.CancelClientDeletion(ctx, ah.Db, clientId)
+	if cancelError != nil {
+		logger.Error(fmt.Sprintf("failed to cancel client deletion in database. %s", cancelError.Error()))
+		return apperror.Internal()
+	}
+	if !isCancelled {
+		logger.Error("client has no scheduled deletion")
+		return apperror.NotFound("No account deletion scheduled.")
+	}
+	logger.Info(fmt.Sprintf("cancelled deletion of client %s", clientId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
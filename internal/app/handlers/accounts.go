@@ -1,24 +1,37 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/iancoleman/strcase"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/service"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
 )
 
+// Longest range of balance history that can be requested at once
+const balanceHistoryMaxRange = 3 * 366 * 24 * time.Hour
+
 type AccountsHandler struct {
-	Db     *pgxpool.Pool
-	Logger *zap.Logger
+	Logger        *zap.Logger
+	Ledger        *service.LedgerService
+	Accounts      repository.AccountRepository
+	Clients       repository.ClientRepository
+	ExchangeRates repository.ExchangeRateRepository
+	Transactions  repository.TransactionRepository
+}
+
+type BalanceHistoryRecord struct {
+	Date    int64  `json:"date"`
+	Balance string `json:"balance"`
 }
 
 type CreateNewAccountRequestBody struct {
@@ -40,438 +53,333 @@ type UpdateAccountRequestBody struct {
 }
 
 func (ah *AccountsHandler) GetAllAccountsByType(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ah.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	providerType := c.QueryParam("type")
 	if len(providerType) == 0 {
-		ah.Logger.Error("undefined provider type", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined provider type."},
-		)
+		logger.Error("undefined provider type")
+		return apperror.InvalidArgument("Undefined provider type.")
 	}
 	if !slices.Contains(ProviderTypes, providerType) {
-		ah.Logger.Error(fmt.Sprintf("invalid provider type %s", providerType), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid provider type."},
-		)
+		logger.Error(fmt.Sprintf("invalid provider type %s", providerType))
+		return apperror.InvalidArgument("Invalid provider type.")
 	}
-	ah.Logger.Info(fmt.Sprintf("going to get all account summary by type %s", providerType), requestId)
+	logger.Info(fmt.Sprintf("going to get all account summary by type %s", providerType))
 
 	// Get all accounts by provider type from database
-	accountSummary, getAccountSummaryError := database.GetAllAccountSummaryByType(ah.Db, providerType, clientId)
+	accountSummary, getAccountSummaryError := ah.Accounts.GetAllAccountSummaryByType(ctx, providerType, workspaceId)
 	if getAccountSummaryError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to get all account summary from database. %s", getAccountSummaryError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to get all account summary from database. %s", getAccountSummaryError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug(fmt.Sprintf("got accounts from database - %#v", accountSummary), requestId)
+	logger.Debug(fmt.Sprintf("got accounts from database - %#v", accountSummary))
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": accountSummary})
 }
 
 func (ah *AccountsHandler) CreateNewAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
 	data := new(CreateNewAccountRequestBody)
 	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ah.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		ah.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
 	}
-	ah.Logger.Debug("validated request parameters", requestId)
+	logger.Debug("validated request parameters")
 
 	// Check if account name in use already
-	accountNameInUse, checkExistingAccountError := database.CheckExistingAccount(
-		ah.Db,
+	accountNameInUse, checkExistingAccountError := ah.Accounts.CheckExistingAccount(
+		ctx,
 		database.CheckExistingAccountParams{
 			Name:            data.Name,
-			ClientId:        clientId,
+			WorkspaceId:     workspaceId,
 			AssetProviderId: data.AssetProviderId,
 		},
 	)
 	if checkExistingAccountError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to check existing account in database. %s", checkExistingAccountError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to check existing account in database. %s", checkExistingAccountError.Error()))
+		return apperror.Internal()
 	}
 	if accountNameInUse {
-		return c.JSON(
-			http.StatusConflict,
-			LooseJson{"success": false, "error": "Account name already in use."},
-		)
+		return apperror.Conflict("Account name already in use.")
 	}
 
 	// Create a new account in database
-	_, createError := database.CreateNewAccount(
-		ah.Db,
+	_, createError := ah.Accounts.CreateNewAccount(
+		ctx,
 		database.CreateNewAccountParams{
 			ClientId:        clientId,
+			WorkspaceId:     workspaceId,
 			Name:            data.Name,
 			CurrencyId:      data.CurrencyId,
 			AssetProviderId: data.AssetProviderId,
 		},
 	)
 	if createError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to create new account in database. %s", createError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to create new account in database. %s", createError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("created a new account in database", requestId)
+	logger.Debug("created a new account in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ah *AccountsHandler) TransferBetweenAccounts(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
 	data := new(TransferBetweenAccountsRequestBody)
 	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ah.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		ah.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
-	}
-	ah.Logger.Debug("validated request parameters", requestId)
-
-	// Get source account name and balance
-	sourceAccountSummary, getSourceAccountSummaryError := database.GetAccountSummary(ah.Db, data.SourceAccountId)
-	if getSourceAccountSummaryError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to get source account summary from database. %s", getSourceAccountSummaryError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-	ah.Logger.Debug("got source account summary from database", requestId)
-
-	// Get target account name and balance
-	targetAccountSummary, getTargetAccountSummaryError := database.GetAccountSummary(ah.Db, data.TargetAccountId)
-	if getTargetAccountSummaryError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to get target account summary from database. %s", getTargetAccountSummaryError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-	ah.Logger.Debug("got target account summary from database", requestId)
-
-	// Converting source account balance to decimal
-	sourceAccountBalanceInDecimal, parseSourceAccountBalanceError := decimal.NewFromString(sourceAccountSummary.Balance)
-	if parseSourceAccountBalanceError != nil {
-		ah.Logger.Error(fmt.Sprintf("failed to parse source account balance into decimal. %s", parseSourceAccountBalanceError.Error()), requestId)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-
-	// Converting target account balance to decimal
-	targetAccountBalanceInDecimal, parseTargetAccountBalanceError := decimal.NewFromString(targetAccountSummary.Balance)
-	if parseTargetAccountBalanceError != nil {
-		ah.Logger.Error(fmt.Sprintf("failed to parse target account balance into decimal. %s", parseTargetAccountBalanceError.Error()), requestId)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-
-	// Converting amount to decimal
-	amountInDecimal, parseAmountError := decimal.NewFromString(data.Amount)
-	if parseAmountError != nil {
-		ah.Logger.Error(fmt.Sprintf("failed to parse amount into decimal. %s", parseAmountError.Error()), requestId)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-
-	// Calculate the final account balance after receiving / spending the transfer amount
-	newSourceAccountBalance := sourceAccountBalanceInDecimal.Sub(amountInDecimal)
-	newTargetAccountBalance := targetAccountBalanceInDecimal.Add(amountInDecimal)
-	ah.Logger.Debug(
-		fmt.Sprintf("source account balance for %s will go from %s to %s", data.SourceAccountId, sourceAccountSummary.Balance, newSourceAccountBalance.Truncate(2).String()),
-		requestId,
-	)
-	ah.Logger.Debug(
-		fmt.Sprintf("target account balance for %s will go from %s to %s", data.TargetAccountId, targetAccountSummary.Balance, newTargetAccountBalance.Truncate(2).String()),
-		requestId,
-	)
-
-	// Update source account balance in database
-	_, updateSourceAccountError := database.UpdateAccount(
-		ah.Db,
-		database.UpdateAccountParams{
-			Balance:       newSourceAccountBalance.Truncate(2).String(),
-			CurrencyId:    sourceAccountSummary.CurrencyId,
-			AccountTypeId: sourceAccountSummary.AccountTypeId,
-		},
-	)
-	if updateSourceAccountError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to update source account balance in database. %s", updateSourceAccountError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-	ah.Logger.Debug("updated source account balance in database", requestId)
-
-	// Update target account balance in database
-	_, updateTargetAccountError := database.UpdateAccount(
-		ah.Db,
-		database.UpdateAccountParams{
-			Balance:       newTargetAccountBalance.Truncate(2).String(),
-			CurrencyId:    targetAccountSummary.CurrencyId,
-			AccountTypeId: targetAccountSummary.AccountTypeId,
-		},
-	)
-	if updateTargetAccountError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to update target account balance in database. %s", updateTargetAccountError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-	ah.Logger.Debug("updated target account balance in database", requestId)
-
-	// Create expense transaction for source account
-	_, createSourceAccountTransactionError := database.CreateNewTransaction(ah.Db, database.CreateNewTransactionParams{
-		Income:     false,
-		Name:       fmt.Sprintf("Transfer to %s", targetAccountSummary.Name),
-		Amount:     data.Amount,
-		ClientId:   clientId,
-		Category:   "bank-transfer",
-		AccountId:  data.SourceAccountId,
-		CurrencyId: sourceAccountSummary.CurrencyId,
-		ExecutedAt: time.Now().Truncate(24 * time.Hour),
-	})
-	ah.Logger.Debug("created a new transaction record for source account in database", requestId)
-
-	if createSourceAccountTransactionError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to create new transaction record for source account in database. %s", createSourceAccountTransactionError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-
-	// Create incoming transaction for target account
-	_, createTargetAccountTransactionError := database.CreateNewTransaction(ah.Db, database.CreateNewTransactionParams{
-		Income:     true,
-		Name:       fmt.Sprintf("Received from %s", sourceAccountSummary.Name),
-		Amount:     data.Amount,
-		ClientId:   clientId,
-		Category:   "bank-transfer",
-		AccountId:  data.TargetAccountId,
-		CurrencyId: targetAccountSummary.CurrencyId,
-		ExecutedAt: time.Now().Truncate(24 * time.Hour),
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Move the amount between accounts, recording a transaction on each side
+	transferError := ah.Ledger.Transfer(ctx, service.TransferParams{
+		Amount:          data.Amount,
+		ClientId:        clientId,
+		WorkspaceId:     workspaceId,
+		SourceAccountId: data.SourceAccountId,
+		TargetAccountId: data.TargetAccountId,
 	})
-	ah.Logger.Debug("created a new transaction record for target account in database", requestId)
-
-	if createTargetAccountTransactionError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to create new transaction record for target account in database. %s", createTargetAccountTransactionError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+	if transferError != nil {
+		return transferError
 	}
+	logger.Debug(fmt.Sprintf("transferred %s from account %s to %s", data.Amount, data.SourceAccountId, data.TargetAccountId))
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ah *AccountsHandler) UpdateAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
 	data := new(UpdateAccountRequestBody)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ah.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		ah.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Check if workspace owns the account
+	isOwned, checkOwnershipError := ah.Accounts.CheckAccountTypeWorkspaceOwnership(ctx, workspaceId, data.AccountTypeId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of account in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("account %s not found in workspace %s", data.AccountTypeId, workspaceId))
+		return apperror.NotFound("Account not found.")
 	}
-	ah.Logger.Debug("validated request parameters", requestId)
 
 	// Update account in database
-	_, updateError := database.UpdateAccount(
-		ah.Db,
+	_, updateError := ah.Accounts.UpdateAccount(
+		ctx,
 		database.UpdateAccountParams{
 			Balance:       data.Balance,
 			CurrencyId:    data.CurrencyId,
 			AccountTypeId: data.AccountTypeId,
+			WorkspaceId:   workspaceId,
 		},
 	)
 	if updateError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to update account in database. %s", updateError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to update account in database. %s", updateError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("updated account in database", requestId)
+	logger.Debug("updated account in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ah *AccountsHandler) DeleteAccount(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ah.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	accountId := c.QueryParam("id")
 	if len(accountId) == 0 {
-		ah.Logger.Error("undefined account id", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined account id."},
-		)
+		logger.Error("undefined account id")
+		return apperror.InvalidArgument("Undefined account id.")
 	}
 	providerType := c.QueryParam("type")
 	if len(providerType) == 0 {
-		ah.Logger.Error("undefined provider type", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined provider type."},
-		)
+		logger.Error("undefined provider type")
+		return apperror.InvalidArgument("Undefined provider type.")
 	}
 	if !slices.Contains(ProviderTypes, providerType) {
-		ah.Logger.Error(fmt.Sprintf("invalid provider type %s", providerType), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid provider type."},
-		)
+		logger.Error(fmt.Sprintf("invalid provider type %s", providerType))
+		return apperror.InvalidArgument("Invalid provider type.")
 	}
-	ah.Logger.Info(fmt.Sprintf("going to check if client owns the account with id %s", accountId), requestId)
+	logger.Info(fmt.Sprintf("going to check if client owns the account with id %s", accountId))
 
-	// Get all client owned accounts in database
-	ownedAccounts, getOwnedAccountsError := database.GetAllAccountSummaryByType(ah.Db, providerType, clientId)
-	if getOwnedAccountsError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to get all owned accounts from database. %s", getOwnedAccountsError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
-	}
-	if len(ownedAccounts) == 0 {
-		ah.Logger.Error(
-			fmt.Sprintf("client does not own the account %s and not authorized to delete", accountId),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusUnauthorized,
-			LooseJson{"success": false, "error": "Unauthorized to delete account."},
-		)
-	}
-	ah.Logger.Info(fmt.Sprintf("going to delete account with id %s", accountId), requestId)
+	// Check if workspace owns the account
+	isOwned, checkOwnershipError := ah.Accounts.CheckWorkspaceOwnership(ctx, workspaceId, accountId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of account in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("account %s not found in workspace %s", accountId, workspaceId))
+		return apperror.NotFound("Account not found.")
+	}
+	logger.Info(fmt.Sprintf("going to delete account with id %s", accountId))
 
 	// Delete account in database
-	_, deleteError := database.DeleteAccount(ah.Db, accountId)
+	_, deleteError := ah.Accounts.DeleteAccount(ctx, accountId)
 	if deleteError != nil {
-		ah.Logger.Error(
-			fmt.Sprintf("failed to delete account in database. %s", deleteError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to delete account in database. %s", deleteError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("deleted account in database", requestId)
+	logger.Debug("deleted account in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
+
+func (ah *AccountsHandler) GetAccountBalanceHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	accountId := c.Param("id")
+	if len(accountId) == 0 {
+		logger.Error("undefined account id")
+		return apperror.InvalidArgument("Undefined account id.")
+	}
+
+	// Default to the balance history of last 30 days in daily interval
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if rawFrom := c.QueryParam("from"); len(rawFrom) > 0 {
+		parsedFrom, parseFromError := strconv.ParseInt(rawFrom, 10, 64)
+		if parseFromError != nil {
+			return apperror.InvalidArgument("Invalid query parameter from.")
+		}
+		from = time.Unix(parsedFrom, 0)
+	}
+	if rawTo := c.QueryParam("to"); len(rawTo) > 0 {
+		parsedTo, parseToError := strconv.ParseInt(rawTo, 10, 64)
+		if parseToError != nil {
+			return apperror.InvalidArgument("Invalid query parameter to.")
+		}
+		to = time.Unix(parsedTo, 0)
+	}
+	if to.After(time.Now()) {
+		to = time.Now()
+	}
+	if from.After(to) || to.Sub(from) > balanceHistoryMaxRange {
+		logger.Error(fmt.Sprintf("invalid balance history range from %s to %s", from, to))
+		return apperror.InvalidArgument("Invalid balance history range.")
+	}
+	interval := c.QueryParam("interval")
+	if len(interval) == 0 {
+		interval = "day"
+	}
+	if !slices.Contains(utils.BalanceHistoryIntervals, interval) {
+		logger.Error(fmt.Sprintf("invalid interval %s", interval))
+		return apperror.InvalidArgument("Invalid interval.")
+	}
+	convert := false
+	if rawConvert := c.QueryParam("convert"); len(rawConvert) > 0 {
+		parsedConvert, parseConvertError := strconv.ParseBool(rawConvert)
+		if parseConvertError != nil {
+			return apperror.InvalidArgument("Invalid query parameter convert.")
+		}
+		convert = parsedConvert
+	}
+	logger.Debug("validated request parameters")
+
+	// Check if client owns the account
+	ownedAccounts, getOwnedAccountsError := ah.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspaceId)
+	if getOwnedAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all owned accounts from database. %s", getOwnedAccountsError.Error()))
+		return apperror.Internal()
+	}
+	accountIndex := slices.IndexFunc(ownedAccounts, func(account database.AccountSummary) bool { return account.Id == accountId })
+	if accountIndex < 0 {
+		logger.Error(fmt.Sprintf("client does not own an account with id %s", accountId))
+		return apperror.NotFound("Account not found.")
+	}
+	account := ownedAccounts[accountIndex]
+
+	// Reconstruct daily balances from the transaction history, including reconciliation adjustments
+	currentBalance, parseBalanceError := decimal.NewFromString(account.Balance)
+	if parseBalanceError != nil {
+		logger.Error(fmt.Sprintf("failed to parse balance into decimal. %s", parseBalanceError.Error()))
+		return apperror.Internal()
+	}
+	transactions, getTransactionsError := ah.Transactions.GetAllTransactionsByAccountIdAfter(ctx, accountId, from.UTC().Truncate(24*time.Hour))
+	if getTransactionsError != nil {
+		logger.Error(fmt.Sprintf("failed to get transactions of account from database. %s", getTransactionsError.Error()))
+		return apperror.Internal()
+	}
+	dailyBalances, reconstructError := utils.ReconstructDailyBalances(currentBalance, transactions, from, to)
+	if reconstructError != nil {
+		logger.Error(fmt.Sprintf("failed to reconstruct daily balances. %s", reconstructError.Error()))
+		return apperror.Internal()
+	}
+	balances := utils.AggregateBalances(dailyBalances, interval)
+	logger.Debug(fmt.Sprintf("reconstructed %d balance points in %s interval", len(balances), interval))
+
+	// Convert balances into client base currency with latest exchange rates
+	currencyId := account.CurrencyId
+	if convert {
+		client, getClientError := ah.Clients.GetClientById(ctx, clientId)
+		if getClientError != nil {
+			logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+			return apperror.Internal()
+		}
+		exchangeRates, getExchangeRatesError := ah.ExchangeRates.GetAllExchangeRates(ctx)
+		if getExchangeRatesError != nil {
+			logger.Error(fmt.Sprintf("failed to get exchange rates from database. %s", getExchangeRatesError.Error()))
+			return apperror.Internal()
+		}
+		for index, balance := range balances {
+			convertedBalance, convertError := utils.ConvertCurrency(balance.Balance, account.CurrencyId, client.CurrencyId, exchangeRates)
+			if convertError != nil {
+				logger.Error(fmt.Sprintf("failed to convert balance into client currency. %s", convertError.Error()))
+				return apperror.Internal()
+			}
+			balances[index].Balance = convertedBalance
+		}
+		currencyId = client.CurrencyId
+	}
+
+	// Construct the response object
+	balanceRecords := []BalanceHistoryRecord{}
+	for _, balance := range balances {
+		balanceRecords = append(balanceRecords, BalanceHistoryRecord{Date: balance.Date.Unix(), Balance: balance.Balance.Truncate(2).String()})
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		LooseJson{"success": true, "data": LooseJson{"currencyId": currencyId, "interval": interval, "balances": balanceRecords}},
+	)
+}
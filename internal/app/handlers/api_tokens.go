@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"go.uber.org/zap"
+)
+
+type ApiTokensHandler struct {
+	Db     *pgxpool.Pool
+	Logger *zap.Logger
+}
+
+type ApiTokenRecord struct {
+	Id         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	LastUsedAt *int64   `json:"lastUsedAt"`
+	ExpiresAt  *int64   `json:"expiresAt"`
+	CreatedAt  int64    `json:"createdAt"`
+}
+
+type CreateNewApiTokenRequestBody struct {
+	Name      string   `json:"name" validate:"required,max=50"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt int64    `json:"expiresAt"`
+}
+
+func (ath *ApiTokensHandler) GetAllApiTokens(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ath.Logger)
+	clientId := c.Get("uid").(string)
+	logger.Info("starts")
+
+	apiTokens, getApiTokensError := database.GetAllActiveApiTokensByClientId(ctx, ath.Db, clientId)
+	if getApiTokensError != nil {
+		logger.Error(fmt.Sprintf("failed to get all active api tokens from database. %s", getApiTokensError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got active api tokens from database")
+
+	apiTokenRecords := []ApiTokenRecord{}
+	for _, apiToken := range apiTokens {
+		apiTokenRecord := ApiTokenRecord{
+			Id:        apiToken.Id,
+			Name:      apiToken.Name,
+			Scopes:    apiToken.Scopes,
+			CreatedAt: apiToken.CreatedAt.Unix(),
+		}
+		if apiToken.LastUsedAt.Valid {
+			lastUsedAt := apiToken.LastUsedAt.Time.Unix()
+			apiTokenRecord.LastUsedAt = &lastUsedAt
+		}
+		if apiToken.ExpiresAt.Valid {
+			expiresAt := apiToken.ExpiresAt.Time.Unix()
+			apiTokenRecord.ExpiresAt = &expiresAt
+		}
+		apiTokenRecords = append(apiTokenRecords, apiTokenRecord)
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": apiTokenRecords})
+}
+
+func (ath *ApiTokensHandler) CreateNewApiToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ath.Logger)
+	data := new(CreateNewApiTokenRequestBody)
+	clientId := c.Get("uid").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+	for _, scope := range data.Scopes {
+		if !utils.IsValidApiTokenScope(scope) {
+			logger.Error(fmt.Sprintf("invalid api token scope %s", scope))
+			return apperror.InvalidArgument(fmt.Sprintf("Invalid scope %s", scope))
+		}
+	}
+	var expiresAt *time.Time
+	if data.ExpiresAt != 0 {
+		if data.ExpiresAt <= time.Now().Unix() {
+			logger.Error("api token expiry is in the past")
+			return apperror.InvalidField("expiresAt", "format", "has an invalid value")
+		}
+		expiry := time.Unix(data.ExpiresAt, 0)
+		expiresAt = &expiry
+	}
+	logger.Debug("validated request parameters")
+
+	token, generateTokenError := utils.GenerateApiToken()
+	if generateTokenError != nil {
+		logger.Error(fmt.Sprintf("failed to generate api token. %s", generateTokenError.Error()))
+		return apperror.Internal()
+	}
+
+	// Only the hash is persisted, the token itself is shown to client once in this response
+	tokenId, createError := database.CreateNewApiToken(ctx, ath.Db, database.CreateNewApiTokenParams{
+		ClientId:  clientId,
+		Name:      data.Name,
+		TokenHash: utils.HashToken(token),
+		Scopes:    data.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if createError != nil {
+		logger.Error(fmt.Sprintf("failed to create new api token in database. %s", createError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("created new api token in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"id": tokenId, "token": token}})
+}
+
+func (ath *ApiTokensHandler) RevokeApiToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ath.Logger)
+	clientId := c.Get("uid").(string)
+	logger.Info("starts")
+
+	tokenId := c.QueryParam("id")
+	if len(tokenId) == 0 {
+		logger.Error("undefined api token id")
+		return apperror.InvalidArgument("Undefined api token id.")
+	}
+
+	revoked, revokeError := database.RevokeApiToken(ctx, ath.Db, tokenId, clientId)
+	if revokeError != nil {
+		logger.Error(fmt.Sprintf("failed to revoke api token in database. %s", revokeError.Error()))
+		return apperror.Internal()
+	}
+	if !revoked {
+		logger.Error(fmt.Sprintf("client does not own an active api token with id %s", tokenId))
+		return apperror.NotFound("Api token not found.")
+	}
+	logger.Debug("revoked api token in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
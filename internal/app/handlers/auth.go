@@ -1,25 +1,33 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"time"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/iancoleman/strcase"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/config"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/mailer"
+	"github.com/nighostchris/everytrack-backend/internal/oauth"
 	"github.com/nighostchris/everytrack-backend/internal/utils"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	Db         *pgxpool.Pool
-	Logger     *zap.Logger
-	TokenUtils *utils.TokenUtils
+	Db             *pgxpool.Pool
+	Env            *config.Config
+	Logger         *zap.Logger
+	Mailer         mailer.Mailer
+	TokenUtils     *utils.TokenUtils
+	OauthProviders map[string]oauth.Provider
 }
 
 type SignupRequestBody struct {
@@ -33,43 +41,177 @@ type LoginRequestBody struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type VerifyRequestBody struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequestBody struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequestBody struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// Extract token from bearer authorization header value, empty if there is none
+func extractBearerToken(authHeader string) string {
+	regexExpression := "\\s|Bearer"
+	regex := regexp.MustCompile(regexExpression)
+	return regex.ReplaceAllString(authHeader, "")
+}
+
+func (ah *AuthHandler) newSessionParams(c echo.Context, clientId string, familyId *string, refreshToken string) database.CreateNewSessionParams {
+	return database.CreateNewSessionParams{
+		ClientId:  clientId,
+		FamilyId:  familyId,
+		TokenHash: utils.HashToken(refreshToken),
+		UserAgent: c.Request().UserAgent(),
+		IpAddress: c.RealIP(),
+		ExpiresAt: ah.TokenUtils.GetRefreshTokenExpiry(),
+	}
+}
+
+// Generate the access and refresh token pair for client, set access token into cookie and persist refresh token as a new session
+func (ah *AuthHandler) startSession(c echo.Context, clientId string) (string, string, error) {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	// Construct access token
+	accessToken, generateAccessTokenError := ah.TokenUtils.GenerateToken(clientId, 0)
+	if generateAccessTokenError != nil {
+		return "", "", generateAccessTokenError
+	}
+	logger.Debug("generated access token")
+
+	// Set access token into cookie
+	c.SetCookie(&http.Cookie{
+		Name:     "token",
+		Value:    accessToken,
+		Expires:  ah.TokenUtils.GetAccessTokenExpiry(),
+		Path:     "/",
+		Secure:   true,                  // Forbid cookie from transmitting over simple HTTP
+		HttpOnly: true,                  // Blocks access of related cookie from client side
+		SameSite: http.SameSiteNoneMode, // SameSite 'none' has to be used together with secure - true
+	})
+	logger.Debug("finished setting access token to response cookie")
+
+	// Construct refresh token
+	refreshToken, generateRefreshTokenError := ah.TokenUtils.GenerateToken(clientId, 1)
+	if generateRefreshTokenError != nil {
+		return "", "", generateRefreshTokenError
+	}
+	logger.Debug("generated refresh token")
+
+	// Persist refresh token as a new session
+	_, createSessionError := database.CreateNewSession(ctx, ah.Db, ah.newSessionParams(c, clientId, nil, refreshToken))
+	if createSessionError != nil {
+		return "", "", createSessionError
+	}
+	logger.Debug("created new session")
+
+	return accessToken, refreshToken, nil
+}
+
+// Issue a one-time token of the given purpose to client and deliver the link containing it by mail
+func (ah *AuthHandler) sendClientTokenMail(ctx context.Context, clientId string, email string, purpose string) error {
+	token, generateTokenError := ah.TokenUtils.GenerateOneTimeToken()
+	if generateTokenError != nil {
+		return generateTokenError
+	}
+
+	var mail mailer.Mail
+	var expiresAt time.Time
+	if purpose == "password-reset" {
+		expiresAt = time.Now().Add(time.Minute * time.Duration(ah.Env.PasswordResetTokenExpiryInMinute))
+		mail = mailer.Mail{
+			To:      email,
+			Subject: "Reset your Everytrack password",
+			Body: fmt.Sprintf(
+				"Someone requested a password reset for your Everytrack account.\r\n\r\nReset your password with the link below within %d minutes:\r\n%s/reset-password?token=%s\r\n\r\nIf it was not you, please ignore this mail.",
+				ah.Env.PasswordResetTokenExpiryInMinute,
+				ah.Env.AppUrl,
+				token,
+			),
+		}
+	} else {
+		expiresAt = time.Now().Add(time.Hour * time.Duration(ah.Env.VerificationTokenExpiryInHour))
+		mail = mailer.Mail{
+			To:      email,
+			Subject: "Verify your Everytrack email address",
+			Body: fmt.Sprintf(
+				"Welcome to Everytrack.\r\n\r\nVerify your email address with the link below within %d hours:\r\n%s/verify?token=%s",
+				ah.Env.VerificationTokenExpiryInHour,
+				ah.Env.AppUrl,
+				token,
+			),
+		}
+	}
+
+	_, createTokenError := database.CreateNewClientToken(ctx, ah.Db, database.CreateNewClientTokenParams{
+		ClientId:  clientId,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if createTokenError != nil {
+		return createTokenError
+	}
+
+	return ah.Mailer.Send(mail)
+}
+
 func (ah *AuthHandler) Signup(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
 	data := new(SignupRequestBody)
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(http.StatusBadRequest, LooseJson{"success": false, "error": "Missing required fields."})
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(http.StatusBadRequest, LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s.", strcase.ToLowerCamel(ve[0].Field()))})
-		}
-		return c.JSON(http.StatusBadRequest, LooseJson{"success": false, "error": validateError.Error()})
+		return apperror.Validation(validateError)
 	}
 
-	// Check if client exists already in database
-	_, getClientError := database.GetClientByEmail(ah.Db, data.Email)
+	// Hash the password before looking up the email, so that registered emails do not respond faster by skipping bcrypt
+	passwordHash, generatePasswordHashError := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
 
+	if generatePasswordHashError != nil {
+		return apperror.Internal()
+	}
+
+	// Respond exactly the same when email is registered already so that signup cannot be used to find out registered emails,
+	// the owner of the email gets notified instead
+	existingClient, getClientError := database.GetClientByEmail(ctx, ah.Db, data.Email)
 	if getClientError == nil {
-		return c.JSON(http.StatusNotFound, LooseJson{"success": false, "error": "Username already in use."})
+		sendMailError := ah.Mailer.Send(mailer.Mail{
+			To:      existingClient.Email,
+			Subject: "Signup attempt with your Everytrack email address",
+			Body: fmt.Sprintf(
+				"Someone tried to sign up for Everytrack with your email address, which already has an account.\r\n\r\nIf it was you and you forgot your password, you can reset it at:\r\n%s/forgot-password",
+				ah.Env.AppUrl,
+			),
+		})
+		if sendMailError != nil {
+			logger.Error(fmt.Sprintf("failed to send signup attempt notice mail. %s", sendMailError.Error()))
+		}
+		return c.JSON(http.StatusOK, LooseJson{"success": true})
+	}
+	if !errors.Is(getClientError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Internal()
 	}
 
 	// Get default currency from database
-	defaultCurrencyId, getDefaultCurrencyIdError := database.GetDefaultCurrency(ah.Db)
+	defaultCurrencyId, getDefaultCurrencyIdError := database.GetDefaultCurrency(ctx, ah.Db)
 	if getDefaultCurrencyIdError != nil {
-		ah.Logger.Error(fmt.Sprintf("failed to get default currency id. %s", getDefaultCurrencyIdError.Error()))
-		return c.JSON(http.StatusNotFound, LooseJson{"success": false, "error": "Internal server error."})
-	}
-
-	passwordHash, generatePasswordHashError := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
-
-	if generatePasswordHashError != nil {
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": "Internal server error."})
+		logger.Error(fmt.Sprintf("failed to get default currency id. %s", getDefaultCurrencyIdError.Error()))
+		return apperror.Internal()
 	}
 
 	newClientId, createNewClientError := database.CreateNewClient(
+		ctx,
 		ah.Db,
 		database.CreateNewClientParams{
 			Email:      data.Email,
@@ -80,104 +222,122 @@ func (ah *AuthHandler) Signup(c echo.Context) error {
 	)
 
 	if createNewClientError != nil {
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": "Internal server error."})
+		return apperror.Internal()
 	}
 
-	// Construct access token
-	accessToken, generateAccessTokenError := ah.TokenUtils.GenerateToken(newClientId, 0)
-	if generateAccessTokenError != nil {
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": generateAccessTokenError.Error()})
+	// Failing to deliver the verification mail should not fail the signup as client can request it again
+	if sendMailError := ah.sendClientTokenMail(ctx, newClientId, data.Email, "email-verification"); sendMailError != nil {
+		logger.Error(fmt.Sprintf("failed to send verification mail. %s", sendMailError.Error()))
+	} else {
+		logger.Debug("sent verification mail")
 	}
 
-	// Set access token into cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    accessToken,
-		Expires:  ah.TokenUtils.GetAccessTokenExpiry(),
-		Path:     "/",
-		Secure:   true,                  // Forbid cookie from transmitting over simple HTTP
-		HttpOnly: true,                  // Blocks access of related cookie from client side
-		SameSite: http.SameSiteNoneMode, // SameSite 'none' has to be used together with secure - true
-	})
-
-	// Construct refresh token
-	refreshToken, generateRefreshTokenError := ah.TokenUtils.GenerateToken(newClientId, 1)
-	if generateRefreshTokenError != nil {
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": generateRefreshTokenError.Error()})
-	}
-	ah.Logger.Debug("generated refresh token")
-
-	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"token": accessToken, "refresh": refreshToken}})
+	// Client logs in afterwards as a registered email does not get the token pair either
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ah *AuthHandler) Login(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
 	data := new(LoginRequestBody)
-	ah.Logger.Info("starts")
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(http.StatusBadRequest, LooseJson{"success": false, "error": "Missing required fields"})
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(http.StatusBadRequest, LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))})
-		}
-		ah.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()))
-		return c.JSON(http.StatusBadRequest, LooseJson{"success": false, "error": "Invalid field"})
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Throttle repeated failures against the same email or from the same IP address
+	retryAfter, checkThrottleError := ah.checkLoginThrottle(c, data.Email)
+	if checkThrottleError != nil {
+		logger.Error(fmt.Sprintf("failed to check login throttling. %s", checkThrottleError.Error()))
+		return apperror.Internal()
+	}
+	if retryAfter > 0 {
+		logger.Error(fmt.Sprintf("login throttled for another %s", retryAfter.String()))
+		return ah.respondLoginThrottled(c, retryAfter)
 	}
-	ah.Logger.Debug("validated request parameters")
+	logger.Debug("checked login throttling")
 
 	// Try to get client from database by input email
-	client, getClientError := database.GetClientByEmail(ah.Db, data.Email)
+	client, getClientError := database.GetClientByEmail(ctx, ah.Db, data.Email)
 	if getClientError != nil {
-		ah.Logger.Error(fmt.Sprintf("failed to get client from database by email - %s. %s", data.Email, getClientError.Error()))
-		return c.JSON(http.StatusNotFound, LooseJson{"success": false, "error": "Invalid user"})
+		if !errors.Is(getClientError, pgx.ErrNoRows) {
+			logger.Error(fmt.Sprintf("failed to get client from database by email - %s. %s", data.Email, getClientError.Error()))
+			return apperror.Internal()
+		}
+		// Spend the same time on password hashing as a registered email would
+		bcrypt.CompareHashAndPassword([]byte(timingEqualiserPasswordHash), []byte(data.Password))
+		logger.Error(fmt.Sprintf("client with email - %s does not exist", data.Email))
+		ah.recordLoginAttempt(c, data.Email, nil, false)
+		return apperror.Unauthenticated("Invalid email or password.")
 	}
-	ah.Logger.Debug(fmt.Sprintf("got client from database with email - %s", client.Email))
+	logger.Debug(fmt.Sprintf("got client from database with email - %s", client.Email))
 
 	// Verify password
 	verifyPasswordError := bcrypt.CompareHashAndPassword([]byte(client.Password), []byte(data.Password))
 	if verifyPasswordError != nil {
-		ah.Logger.Error(fmt.Sprintf("password verification failed. %s", verifyPasswordError.Error()))
-		return c.JSON(http.StatusNotFound, LooseJson{"success": false, "error": "Incorrect password"})
+		logger.Error(fmt.Sprintf("password verification failed. %s", verifyPasswordError.Error()))
+		ah.recordLoginAttempt(c, data.Email, &client.Id, false)
+		return apperror.Unauthenticated("Invalid email or password.")
 	}
-	ah.Logger.Debug("verified password")
-
-	// Construct access token
-	accessToken, generateAccessTokenError := ah.TokenUtils.GenerateToken(client.Id, 0)
-	if generateAccessTokenError != nil {
-		ah.Logger.Error(fmt.Sprintf("access token generation failed. %s", generateAccessTokenError.Error()))
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": "Internal server error"})
+	logger.Debug("verified password")
+
+	// Hand out a short-lived challenge token instead of the token pair if client has enabled 2FA.
+	// The attempt only counts as successful after the second step, otherwise a leaked password could reset the throttling.
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, client.Id)
+	if getClientTotpError != nil && !errors.Is(getClientTotpError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("generated access token")
+	if getClientTotpError == nil && clientTotp.ConfirmedAt.Valid {
+		challengeToken, generateChallengeTokenError := ah.TokenUtils.GenerateToken(client.Id, 2)
+		if generateChallengeTokenError != nil {
+			logger.Error(fmt.Sprintf("challenge token generation failed. %s", generateChallengeTokenError.Error()))
+			return apperror.Internal()
+		}
+		logger.Debug("generated challenge token for second login step")
 
-	// Set access token into cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    accessToken,
-		Expires:  ah.TokenUtils.GetAccessTokenExpiry(),
-		Path:     "/",
-		Secure:   true,                  // Forbid cookie from transmitting over simple HTTP
-		HttpOnly: true,                  // Blocks access of related cookie from client side
-		SameSite: http.SameSiteNoneMode, // SameSite 'none' has to be used together with secure - true
-	})
-	ah.Logger.Debug("finished setting access token to response cookie")
+		return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"twoFactorRequired": true, "challenge": challengeToken}})
+	}
 
-	// Construct refresh token
-	refreshToken, generateRefreshTokenError := ah.TokenUtils.GenerateToken(client.Id, 1)
-	if generateRefreshTokenError != nil {
-		ah.Logger.Error(fmt.Sprintf("refresh token generation failed. %s", generateRefreshTokenError.Error()))
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": "Internal server error"})
+	accessToken, refreshToken, startSessionError := ah.startSession(c, client.Id)
+	if startSessionError != nil {
+		logger.Error(fmt.Sprintf("failed to start session. %s", startSessionError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("generated refresh token")
+	logger.Debug("started new session")
+	ah.recordLoginAttempt(c, data.Email, &client.Id, true)
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"token": accessToken, "refresh": refreshToken}})
 }
 
 func (ah *AuthHandler) Logout(c echo.Context) error {
-	ah.Logger.Info("starts")
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	// Revoke the whole session family of the refresh token if it is presented
+	refreshToken := extractBearerToken(c.Request().Header.Get("Authorization"))
+	if len(refreshToken) > 0 {
+		session, getSessionError := database.GetSessionByTokenHash(ctx, ah.Db, utils.HashToken(refreshToken))
+		if getSessionError == nil {
+			_, revokeSessionError := database.RevokeSessionFamily(ctx, ah.Db, session.FamilyId)
+			if revokeSessionError != nil {
+				logger.Error(fmt.Sprintf("failed to revoke session family in database. %s", revokeSessionError.Error()))
+				return apperror.Internal()
+			}
+			logger.Debug("revoked session family of refresh token")
+		} else if !errors.Is(getSessionError, pgx.ErrNoRows) {
+			logger.Error(fmt.Sprintf("failed to get session from database. %s", getSessionError.Error()))
+			return apperror.Internal()
+		}
+	}
 
 	// Void access token in cookie
 	c.SetCookie(&http.Cookie{
@@ -188,46 +348,72 @@ func (ah *AuthHandler) Logout(c echo.Context) error {
 		HttpOnly: true,
 		SameSite: http.SameSiteNoneMode,
 	})
-	ah.Logger.Debug("finished voiding access token in cookie")
+	logger.Debug("finished voiding access token in cookie")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ah *AuthHandler) Refresh(c echo.Context) error {
-	ah.Logger.Info("starts")
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
 
 	// Try to extract bearer refresh token from Authorization header
 	authHeader := c.Request().Header.Get("Authorization")
 	if len(authHeader) == 0 {
-		ah.Logger.Error("refresh token does not exist in authorization header")
-		return c.JSON(http.StatusUnauthorized, LooseJson{"success": false})
+		logger.Error("refresh token does not exist in authorization header")
+		return apperror.Unauthenticated("Invalid or expired refresh token.")
 	}
-	ah.Logger.Debug("extracted bearer refresh token from authorization header")
+	logger.Debug("extracted bearer refresh token from authorization header")
 
 	// Try to extract refresh token from bearer refresh token
 	regexExpression := "\\s|Bearer"
 	regex := regexp.MustCompile(regexExpression)
 	bearerToken := regex.ReplaceAllString(authHeader, "")
 	if len(bearerToken) == 0 {
-		ah.Logger.Error("refresh token does not exist in authorization header")
-		return c.JSON(http.StatusUnauthorized, LooseJson{"success": false})
+		logger.Error("refresh token does not exist in authorization header")
+		return apperror.Unauthenticated("Invalid or expired refresh token.")
 	}
-	ah.Logger.Debug("extracted refresh token from bearer refresh token")
+	logger.Debug("extracted refresh token from bearer refresh token")
 
 	// Verify refresh token
 	isRefreshTokenValid, uid := ah.TokenUtils.VerifyToken(bearerToken, 1)
 	if !isRefreshTokenValid {
-		ah.Logger.Error("invalid refresh token")
-		return c.JSON(http.StatusUnauthorized, LooseJson{"success": false})
+		logger.Error("invalid refresh token")
+		return apperror.Unauthenticated("Invalid or expired refresh token.")
 	}
 
+	// Verify refresh token against the server side session
+	session, getSessionError := database.GetSessionByTokenHash(ctx, ah.Db, utils.HashToken(bearerToken))
+	if getSessionError != nil {
+		if errors.Is(getSessionError, pgx.ErrNoRows) {
+			logger.Error("refresh token does not belong to any session")
+			return apperror.Unauthenticated("Invalid or expired refresh token.")
+		}
+		logger.Error(fmt.Sprintf("failed to get session from database. %s", getSessionError.Error()))
+		return apperror.Internal()
+	}
+	if session.RevokedAt.Valid {
+		// A rotated refresh token being presented again means it has been stolen, revoke the whole family
+		logger.Error(fmt.Sprintf("detected reuse of refresh token in session family %s", session.FamilyId))
+		if _, revokeSessionError := database.RevokeSessionFamily(ctx, ah.Db, session.FamilyId); revokeSessionError != nil {
+			logger.Error(fmt.Sprintf("failed to revoke session family in database. %s", revokeSessionError.Error()))
+		}
+		return apperror.Unauthenticated("Invalid or expired refresh token.")
+	}
+	if session.ClientId != uid || !session.ExpiresAt.After(time.Now()) {
+		logger.Error("session of refresh token is invalid or expired")
+		return apperror.Unauthenticated("Invalid or expired refresh token.")
+	}
+	logger.Debug("verified session of refresh token")
+
 	// Construct new access token
 	accessToken, generateAccessTokenError := ah.TokenUtils.GenerateToken(uid, 0)
 	if generateAccessTokenError != nil {
-		ah.Logger.Error(fmt.Sprintf("access token generation failed. %s", generateAccessTokenError.Error()))
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": "Internal server error"})
+		logger.Error(fmt.Sprintf("access token generation failed. %s", generateAccessTokenError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("generated new access token")
+	logger.Debug("generated new access token")
 
 	// Set access token into cookie
 	c.SetCookie(&http.Cookie{
@@ -239,19 +425,169 @@ func (ah *AuthHandler) Refresh(c echo.Context) error {
 		HttpOnly: true,                  // Blocks access of related cookie from client side
 		SameSite: http.SameSiteNoneMode, // SameSite 'none' has to be used together with secure - true
 	})
-	ah.Logger.Debug("finished setting access token to response cookie")
+	logger.Debug("finished setting access token to response cookie")
 
 	// Construct new refresh token
 	refreshToken, generateRefreshTokenError := ah.TokenUtils.GenerateToken(uid, 1)
 	if generateRefreshTokenError != nil {
-		ah.Logger.Error(fmt.Sprintf("refresh token generation failed. %s", generateRefreshTokenError.Error()))
-		return c.JSON(http.StatusInternalServerError, LooseJson{"success": false, "error": "Internal server error"})
+		logger.Error(fmt.Sprintf("refresh token generation failed. %s", generateRefreshTokenError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("generated new refresh token")
+
+	// Rotate the session so that the presented refresh token can never be used again
+	_, rotateSessionError := database.RotateSession(ctx, ah.Db, session.Id, ah.newSessionParams(c, uid, &session.FamilyId, refreshToken))
+	if rotateSessionError != nil {
+		if errors.Is(rotateSessionError, database.ErrSessionAlreadyRotated) {
+			logger.Error(fmt.Sprintf("detected concurrent reuse of refresh token in session family %s", session.FamilyId))
+			if _, revokeSessionError := database.RevokeSessionFamily(ctx, ah.Db, session.FamilyId); revokeSessionError != nil {
+				logger.Error(fmt.Sprintf("failed to revoke session family in database. %s", revokeSessionError.Error()))
+			}
+			return apperror.Unauthenticated("Invalid or expired refresh token.")
+		}
+		logger.Error(fmt.Sprintf("failed to rotate session in database. %s", rotateSessionError.Error()))
+		return apperror.Internal()
 	}
-	ah.Logger.Debug("generated new refresh token")
+	logger.Debug("rotated session")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"token": accessToken, "refresh": refreshToken}})
 }
 
 func (ah *AuthHandler) Verify(c echo.Context) error {
-	return c.JSON(http.StatusAccepted, LooseJson{"success": true})
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(VerifyRequestBody)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Reject forged token before touching database
+	if !ah.TokenUtils.VerifyOneTimeToken(data.Token) {
+		logger.Error("invalid signature of verification token")
+		return apperror.InvalidArgument("Invalid or expired token.")
+	}
+
+	// Consume the token and mark client as verified
+	clientId, verifyError := database.VerifyClientEmail(ctx, ah.Db, utils.HashToken(data.Token))
+	if verifyError != nil {
+		if errors.Is(verifyError, pgx.ErrNoRows) {
+			logger.Error("verification token does not exist, expired or used already")
+			return apperror.InvalidArgument("Invalid or expired token.")
+		}
+		logger.Error(fmt.Sprintf("failed to verify client email in database. %s", verifyError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug(fmt.Sprintf("verified email of client %s", clientId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (ah *AuthHandler) ResendVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	clientId := c.Get("uid").(string)
+	client, getClientError := database.GetClientById(ctx, ah.Db, clientId)
+	if getClientError != nil {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Internal()
+	}
+	if client.Verified {
+		logger.Error("client has verified email address already")
+		return apperror.InvalidArgument("Email address verified already.")
+	}
+
+	if sendMailError := ah.sendClientTokenMail(ctx, client.Id, client.Email, "email-verification"); sendMailError != nil {
+		logger.Error(fmt.Sprintf("failed to send verification mail. %s", sendMailError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("sent verification mail")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (ah *AuthHandler) ForgotPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(ForgotPasswordRequestBody)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Always respond with success so that the endpoint cannot be used to find out registered emails
+	client, getClientError := database.GetClientByEmail(ctx, ah.Db, data.Email)
+	if getClientError != nil {
+		if !errors.Is(getClientError, pgx.ErrNoRows) {
+			logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		}
+		return c.JSON(http.StatusOK, LooseJson{"success": true})
+	}
+
+	if sendMailError := ah.sendClientTokenMail(ctx, client.Id, client.Email, "password-reset"); sendMailError != nil {
+		logger.Error(fmt.Sprintf("failed to send password reset mail. %s", sendMailError.Error()))
+	} else {
+		logger.Debug("sent password reset mail")
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (ah *AuthHandler) ResetPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(ResetPasswordRequestBody)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Reject forged token before touching database
+	if !ah.TokenUtils.VerifyOneTimeToken(data.Token) {
+		logger.Error("invalid signature of password reset token")
+		return apperror.InvalidArgument("Invalid or expired token.")
+	}
+
+	passwordHash, generatePasswordHashError := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+	if generatePasswordHashError != nil {
+		logger.Error(fmt.Sprintf("failed to generate password hash. %s", generatePasswordHashError.Error()))
+		return apperror.Internal()
+	}
+
+	// Consume the token, replace password and sign out every existing session
+	clientId, resetError := database.ResetClientPassword(ctx, ah.Db, utils.HashToken(data.Token), string(passwordHash))
+	if resetError != nil {
+		if errors.Is(resetError, pgx.ErrNoRows) {
+			logger.Error("password reset token does not exist, expired or used already")
+			return apperror.InvalidArgument("Invalid or expired token.")
+		}
+		logger.Error(fmt.Sprintf("failed to reset client password in database. %s", resetError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug(fmt.Sprintf("reset password of client %s", clientId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
@@ -1,21 +1,19 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/iancoleman/strcase"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
 	"go.uber.org/zap"
 )
 
 type CashHandler struct {
-	Db     *pgxpool.Pool
 	Logger *zap.Logger
+	Cash   repository.CashRepository
 }
 
 type CashRecord struct {
@@ -36,23 +34,18 @@ type UpdateCashRecordRequestBody struct {
 }
 
 func (ch *CashHandler) GetAllCash(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ch.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ch.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Get all cash records from database
-	cash, getCashError := database.GetAllCash(ch.Db, clientId)
+	cash, getCashError := ch.Cash.GetAllCash(ctx, workspaceId)
 	if getCashError != nil {
-		ch.Logger.Error(
-			fmt.Sprintf("failed to get all cash records from database. %s", getCashError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to get all cash records from database. %s", getCashError.Error()))
+		return apperror.Internal()
 	}
-	ch.Logger.Debug("got cash records from database", requestId)
+	logger.Debug("got cash records from database")
 
 	// Construct the response object
 	cashRecords := []CashRecord{}
@@ -64,143 +57,121 @@ func (ch *CashHandler) GetAllCash(c echo.Context) error {
 		}
 		cashRecords = append(cashRecords, record)
 	}
-	ch.Logger.Debug(fmt.Sprintf("constructed response object - %#v", cashRecords), requestId)
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", cashRecords))
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": cashRecords})
 }
 
 func (ch *CashHandler) CreateNewCashRecord(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ch.Logger)
 	data := new(CreateNewCashRecordRequestBody)
 	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ch.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		ch.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
 	}
-	ch.Logger.Debug("validated request parameters", requestId)
+	logger.Debug("validated request parameters")
 
 	// Create new cash record in database
-	_, createError := database.CreateNewCashRecord(ch.Db, database.CreateNewCashRecordParams{
-		ClientId:   clientId,
-		Amount:     data.Amount,
-		CurrencyId: data.CurrencyId,
+	_, createError := ch.Cash.CreateNewCashRecord(ctx, database.CreateNewCashRecordParams{
+		ClientId:    clientId,
+		WorkspaceId: workspaceId,
+		Amount:      data.Amount,
+		CurrencyId:  data.CurrencyId,
 	})
 	if createError != nil {
-		ch.Logger.Error(
-			fmt.Sprintf("failed to create new cash record in database. %s", createError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to create new cash record in database. %s", createError.Error()))
+		return apperror.Internal()
 	}
-	ch.Logger.Debug("created a new cash record in database", requestId)
+	logger.Debug("created a new cash record in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ch *CashHandler) UpdateCashRecord(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ch.Logger)
 	data := new(UpdateCashRecordRequestBody)
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ch.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		ch.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
+	}
+
+	// Check if workspace owns the cash record
+	isOwned, checkOwnershipError := ch.Cash.CheckWorkspaceOwnership(ctx, workspaceId, data.Id)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of cash record in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("cash record %s not found in workspace %s", data.Id, workspaceId))
+		return apperror.NotFound("Cash record not found.")
 	}
 
 	// Update account in database
-	_, updateError := database.UpdateCashRecord(
-		ch.Db,
+	_, updateError := ch.Cash.UpdateCashRecord(
+		ctx,
 		database.UpdateCashRecordParams{
-			Id:         data.Id,
-			Amount:     data.Amount,
-			ClientId:   clientId,
-			CurrencyId: data.CurrencyId,
+			Id:          data.Id,
+			Amount:      data.Amount,
+			WorkspaceId: workspaceId,
+			CurrencyId:  data.CurrencyId,
 		},
 	)
 	if updateError != nil {
-		ch.Logger.Error(
-			fmt.Sprintf("failed to update cash in database. %s", updateError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to update cash in database. %s", updateError.Error()))
+		return apperror.Internal()
 	}
-	ch.Logger.Debug("updated cash in database", requestId)
+	logger.Debug("updated cash in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (ch *CashHandler) DeleteCash(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ch.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ch.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	cashId := c.QueryParam("id")
 	if len(cashId) == 0 {
-		ch.Logger.Error("undefined cash id", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined cash record id."},
-		)
+		logger.Error("undefined cash id")
+		return apperror.InvalidArgument("Undefined cash record id.")
+	}
+
+	// Check if workspace owns the cash record
+	isOwned, checkOwnershipError := ch.Cash.CheckWorkspaceOwnership(ctx, workspaceId, cashId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of cash record in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("cash record %s not found in workspace %s", cashId, workspaceId))
+		return apperror.NotFound("Cash record not found.")
 	}
 
 	// Delete cash record in database
-	_, deleteError := database.DeleteCashRecord(ch.Db, cashId, clientId)
+	_, deleteError := ch.Cash.DeleteCashRecord(ctx, cashId, workspaceId)
 	if deleteError != nil {
-		ch.Logger.Error(
-			fmt.Sprintf("failed to delete cash record in database. %s", deleteError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to delete cash record in database. %s", deleteError.Error()))
+		return apperror.Internal()
 	}
-	ch.Logger.Debug("deleted cash record in database", requestId)
+	logger.Debug("deleted cash record in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
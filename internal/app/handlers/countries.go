@@ -6,6 +6,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
 	"go.uber.org/zap"
 )
@@ -16,16 +17,18 @@ type CountriesHandler struct {
 }
 
 func (ch *CountriesHandler) GetAllCountries(c echo.Context) error {
-	ch.Logger.Info("starts")
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ch.Logger)
+	logger.Info("starts")
 
 	// Get all countries from database
-	countries, getCountriesError := database.GetAllCountries(ch.Db)
+	countries, getCountriesError := database.GetAllCountries(ctx, ch.Db)
 
 	if getCountriesError != nil {
-		ch.Logger.Error(fmt.Sprintf("failed to get countries from database. %s", getCountriesError.Error()))
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "Internal server error."})
+		logger.Error(fmt.Sprintf("failed to get countries from database. %s", getCountriesError.Error()))
+		return apperror.Internal()
 	}
-	ch.Logger.Debug(fmt.Sprintf("got countries from database - %#v", countries))
+	logger.Debug(fmt.Sprintf("got countries from database - %#v", countries))
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "data": countries})
 }
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+type CreditAccountsHandler struct {
+	Logger         *zap.Logger
+	Accounts       repository.AccountRepository
+	CreditAccounts repository.CreditAccountRepository
+	Transactions   repository.TransactionRepository
+}
+
+type CreditAccountRecord struct {
+	Id                 string  `json:"id"`
+	Name               string  `json:"name"`
+	Balance            string  `json:"balance"`
+	CurrencyId         string  `json:"currencyId"`
+	CreditLimit        string  `json:"creditLimit"`
+	StatementDay       int     `json:"statementDay"`
+	DueDay             int     `json:"dueDay"`
+	Apr                string  `json:"apr"`
+	RepaymentAccountId *string `json:"repaymentAccountId"`
+	Outstanding        string  `json:"outstanding"`
+	AvailableCredit    string  `json:"availableCredit"`
+	Utilisation        string  `json:"utilisation"`
+	EstimatedInterest  string  `json:"estimatedInterest"`
+	NextStatementAt    int64   `json:"nextStatementAt"`
+	NextDueAt          int64   `json:"nextDueAt"`
+}
+
+type CreditStatementRecord struct {
+	Id              *string `json:"id"`
+	Balance         string  `json:"balance"`
+	PeriodStart     int64   `json:"periodStart"`
+	PeriodEnd       int64   `json:"periodEnd"`
+	DueAt           int64   `json:"dueAt"`
+	FuturePaymentId *string `json:"futurePaymentId"`
+}
+
+type UpdateCreditAccountRequestBody struct {
+	AccountId          string  `json:"accountId" validate:"required"`
+	CreditLimit        string  `json:"creditLimit" validate:"required"`
+	StatementDay       int     `json:"statementDay" validate:"required,min=1,max=28"`
+	DueDay             int     `json:"dueDay" validate:"required,min=1,max=28"`
+	Apr                string  `json:"apr" validate:"required"`
+	RepaymentAccountId *string `json:"repaymentAccountId"`
+}
+
+// Outstanding amount of a credit account is the negated balance since spending is deducted from balance
+func calculateCreditAccountRecord(details database.CreditAccountDetails) (CreditAccountRecord, error) {
+	record := CreditAccountRecord{
+		Id:           details.Id,
+		Name:         details.Name,
+		Balance:      details.Balance,
+		CurrencyId:   details.CurrencyId,
+		CreditLimit:  details.CreditLimit,
+		StatementDay: details.StatementDay,
+		DueDay:       details.DueDay,
+		Apr:          details.Apr,
+	}
+	if details.RepaymentAccountId.Valid {
+		repaymentAccountId := details.RepaymentAccountId.String
+		record.RepaymentAccountId = &repaymentAccountId
+	}
+
+	balance, parseBalanceError := decimal.NewFromString(details.Balance)
+	if parseBalanceError != nil {
+		return record, parseBalanceError
+	}
+	creditLimit, parseCreditLimitError := decimal.NewFromString(details.CreditLimit)
+	if parseCreditLimitError != nil {
+		return record, parseCreditLimitError
+	}
+	apr, parseAprError := decimal.NewFromString(details.Apr)
+	if parseAprError != nil {
+		return record, parseAprError
+	}
+
+	outstanding := decimal.Max(balance.Neg(), decimal.Zero)
+	utilisation := decimal.Zero
+	if creditLimit.IsPositive() {
+		utilisation = outstanding.Div(creditLimit).Mul(decimal.NewFromInt(100))
+	}
+	estimatedInterest := outstanding.Mul(apr).Div(decimal.NewFromInt(100)).Div(decimal.NewFromInt(12))
+	nextStatementPeriod := utils.CalculateStatementPeriod(details.StatementDay, time.Now().UTC())
+
+	record.Outstanding = outstanding.Truncate(2).String()
+	record.AvailableCredit = decimal.Max(creditLimit.Sub(outstanding), decimal.Zero).Truncate(2).String()
+	record.Utilisation = utilisation.Round(2).String()
+	record.EstimatedInterest = estimatedInterest.Round(2).String()
+	record.NextStatementAt = nextStatementPeriod.End.Unix()
+	record.NextDueAt = utils.CalculateStatementDueDate(details.DueDay, nextStatementPeriod.End).Unix()
+
+	return record, nil
+}
+
+func (cah *CreditAccountsHandler) GetAllCreditAccounts(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, cah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Get all credit accounts with credit details from database
+	creditAccounts, getCreditAccountsError := cah.CreditAccounts.GetAllCreditAccountsByWorkspaceId(ctx, workspaceId)
+	if getCreditAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all credit accounts from database. %s", getCreditAccountsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got credit accounts from database")
+
+	// Construct the response object with utilisation report
+	creditAccountRecords := []CreditAccountRecord{}
+	for _, creditAccount := range creditAccounts {
+		record, calculateError := calculateCreditAccountRecord(creditAccount)
+		if calculateError != nil {
+			logger.Error(fmt.Sprintf("failed to calculate utilisation for credit account %s. %s", creditAccount.Id, calculateError.Error()))
+			return apperror.Internal()
+		}
+		creditAccountRecords = append(creditAccountRecords, record)
+	}
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", creditAccountRecords))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": creditAccountRecords})
+}
+
+func (cah *CreditAccountsHandler) UpdateCreditAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, cah.Logger)
+	data := new(UpdateCreditAccountRequestBody)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	if creditLimit, parseCreditLimitError := decimal.NewFromString(data.CreditLimit); parseCreditLimitError != nil || creditLimit.IsNegative() {
+		return apperror.InvalidField("creditLimit", "format", "has an invalid value")
+	}
+	if apr, parseAprError := decimal.NewFromString(data.Apr); parseAprError != nil || apr.IsNegative() {
+		return apperror.InvalidField("apr", "format", "has an invalid value")
+	}
+	logger.Debug("validated request parameters")
+
+	// Check if client owns the credit account, and the account the statements are repaid from
+	ownedAccounts, getOwnedAccountsError := cah.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspaceId)
+	if getOwnedAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all owned accounts from database. %s", getOwnedAccountsError.Error()))
+		return apperror.Internal()
+	}
+	ownedCreditAccounts, getOwnedCreditAccountsError := cah.Accounts.GetAllAccountSummaryByType(ctx, "credit", workspaceId)
+	if getOwnedCreditAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all owned credit accounts from database. %s", getOwnedCreditAccountsError.Error()))
+		return apperror.Internal()
+	}
+	creditAccountIndex := slices.IndexFunc(ownedCreditAccounts, func(account database.AccountSummary) bool { return account.Id == data.AccountId })
+	if creditAccountIndex < 0 {
+		logger.Error(fmt.Sprintf("credit account %s not found in workspace %s", data.AccountId, workspaceId))
+		return apperror.NotFound("Account not found.")
+	}
+	if data.RepaymentAccountId != nil {
+		if *data.RepaymentAccountId == data.AccountId {
+			return apperror.InvalidField("repaymentAccountId", "format", "has an invalid value")
+		}
+		repaymentAccountIndex := slices.IndexFunc(ownedAccounts, func(account database.AccountSummary) bool { return account.Id == *data.RepaymentAccountId })
+		if repaymentAccountIndex < 0 {
+			logger.Error(fmt.Sprintf("repayment account %s not found in workspace %s", *data.RepaymentAccountId, workspaceId))
+			return apperror.NotFound("Account not found.")
+		}
+		// Statement balance is repaid as is, so both sides of the transfer must be in the same currency
+		if ownedAccounts[repaymentAccountIndex].CurrencyId != ownedCreditAccounts[creditAccountIndex].CurrencyId {
+			return apperror.InvalidField("repaymentAccountId", "format", "has an invalid value")
+		}
+	}
+
+	// Create or update credit details of the account in database
+	_, upsertError := cah.CreditAccounts.UpsertCreditAccount(
+		ctx,
+		database.UpsertCreditAccountParams{
+			AccountId:          data.AccountId,
+			CreditLimit:        data.CreditLimit,
+			StatementDay:       data.StatementDay,
+			DueDay:             data.DueDay,
+			Apr:                data.Apr,
+			RepaymentAccountId: data.RepaymentAccountId,
+		},
+	)
+	if upsertError != nil {
+		logger.Error(fmt.Sprintf("failed to update credit account in database. %s", upsertError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("updated credit account in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (cah *CreditAccountsHandler) GetAllCreditStatements(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, cah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	accountId := c.QueryParam("id")
+	if len(accountId) == 0 {
+		logger.Error("undefined account id")
+		return apperror.InvalidArgument("Undefined account id.")
+	}
+
+	// Get credit details of the account from database
+	creditAccounts, getCreditAccountsError := cah.CreditAccounts.GetAllCreditAccountsByWorkspaceId(ctx, workspaceId)
+	if getCreditAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all credit accounts from database. %s", getCreditAccountsError.Error()))
+		return apperror.Internal()
+	}
+	var creditAccount *database.CreditAccountDetails
+	for index := range creditAccounts {
+		if creditAccounts[index].Id == accountId {
+			creditAccount = &creditAccounts[index]
+		}
+	}
+	if creditAccount == nil {
+		logger.Error(fmt.Sprintf("client does not own a credit account with id %s", accountId))
+		return apperror.NotFound("Credit account not found.")
+	}
+
+	// Compute the running balance of the statement period that is still open
+	currentPeriod := utils.CalculateStatementPeriod(creditAccount.StatementDay, time.Now().UTC())
+	transactions, getTransactionsError := cah.Transactions.GetAllTransactionsByAccountIdBetween(ctx, accountId, currentPeriod.Start, currentPeriod.End)
+	if getTransactionsError != nil {
+		logger.Error(fmt.Sprintf("failed to get transactions of current statement period from database. %s", getTransactionsError.Error()))
+		return apperror.Internal()
+	}
+	currentBalance, calculateBalanceError := utils.CalculateStatementBalance(transactions)
+	if calculateBalanceError != nil {
+		logger.Error(fmt.Sprintf("failed to calculate current statement balance. %s", calculateBalanceError.Error()))
+		return apperror.Internal()
+	}
+
+	// Get all closed statements from database
+	statements, getStatementsError := cah.CreditAccounts.GetAllCreditStatements(ctx, accountId)
+	if getStatementsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all credit statements from database. %s", getStatementsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got credit statements from database")
+
+	// Construct the response object
+	statementRecords := []CreditStatementRecord{}
+	for _, statement := range statements {
+		statementId := statement.Id
+		record := CreditStatementRecord{
+			Id:          &statementId,
+			Balance:     statement.Balance,
+			PeriodStart: statement.PeriodStart.Unix(),
+			PeriodEnd:   statement.PeriodEnd.Unix(),
+			DueAt:       statement.DueAt.Unix(),
+		}
+		if statement.FuturePaymentId.Valid {
+			futurePaymentId := statement.FuturePaymentId.String
+			record.FuturePaymentId = &futurePaymentId
+		}
+		statementRecords = append(statementRecords, record)
+	}
+	currentStatement := CreditStatementRecord{
+		Balance:     currentBalance.Truncate(2).String(),
+		PeriodStart: currentPeriod.Start.Unix(),
+		PeriodEnd:   currentPeriod.End.Unix(),
+		DueAt:       utils.CalculateStatementDueDate(creditAccount.DueDay, currentPeriod.End).Unix(),
+	}
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", statementRecords))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"current": currentStatement, "statements": statementRecords}})
+}
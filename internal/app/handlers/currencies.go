@@ -6,6 +6,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
 	"go.uber.org/zap"
 )
@@ -16,16 +17,18 @@ type CurrenciesHandler struct {
 }
 
 func (ch *CurrenciesHandler) GetAllCurrencies(c echo.Context) error {
-	ch.Logger.Info("starts")
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ch.Logger)
+	logger.Info("starts")
 
 	// Get all bank details from database
-	currencies, getCurrenciesError := database.GetAllCurrencies(ch.Db)
+	currencies, getCurrenciesError := database.GetAllCurrencies(ctx, ch.Db)
 
 	if getCurrenciesError != nil {
-		ch.Logger.Error(fmt.Sprintf("failed to get currencies from database. %s", getCurrenciesError.Error()))
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "Internal server error."})
+		logger.Error(fmt.Sprintf("failed to get currencies from database. %s", getCurrenciesError.Error()))
+		return apperror.Internal()
 	}
-	ch.Logger.Debug("got currencies from database")
+	logger.Debug("got currencies from database")
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "data": currencies})
 }
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+)
+
+// Bind request body into data and validate it with schema, returning the error to respond with if either fails
+func bindAndValidate(c echo.Context, data interface{}) error {
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+)
+
+type ExportedClient struct {
+	Email      string `json:"email"`
+	Username   string `json:"username"`
+	CurrencyId string `json:"currencyId"`
+	Verified   bool   `json:"verified"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+type ExportedStockHolding struct {
+	Id        string `json:"id"`
+	AccountId string `json:"accountId"`
+	StockId   string `json:"stockId"`
+	Unit      string `json:"unit"`
+	Cost      string `json:"cost"`
+}
+
+type ExportedGoal struct {
+	Id              string   `json:"id"`
+	Name            string   `json:"name"`
+	CurrencyId      string   `json:"currencyId"`
+	TargetAmount    string   `json:"targetAmount"`
+	Deadline        int64    `json:"deadline"`
+	AccountIds      []string `json:"accountIds"`
+	FuturePaymentId *string  `json:"futurePaymentId"`
+}
+
+type ExportedWorkspace struct {
+	Id             string                    `json:"id"`
+	Name           string                    `json:"name"`
+	Role           string                    `json:"role"`
+	Accounts       []database.AccountSummary `json:"accounts"`
+	StockHoldings  []ExportedStockHolding    `json:"stockHoldings"`
+	Cash           []CashRecord              `json:"cash"`
+	Transactions   []TransactionRecord       `json:"transactions"`
+	FuturePayments []FuturePaymentRecord     `json:"futurePayments"`
+	Goals          []ExportedGoal            `json:"goals"`
+}
+
+type ClientDataExport struct {
+	ExportedAt int64               `json:"exportedAt"`
+	Client     ExportedClient      `json:"client"`
+	Workspaces []ExportedWorkspace `json:"workspaces"`
+}
+
+// Collect client profile and the records of every workspace into a single document
+func (sh *SettingsHandler) buildClientDataExport(ctx context.Context, clientId string) (ClientDataExport, error) {
+	export := ClientDataExport{ExportedAt: time.Now().Unix()}
+
+	client, getClientError := sh.Clients.GetClientById(ctx, clientId)
+	if getClientError != nil {
+		return export, getClientError
+	}
+	export.Client = ExportedClient{
+		Email:      client.Email,
+		Username:   client.Username,
+		CurrencyId: client.CurrencyId,
+		Verified:   client.Verified,
+		CreatedAt:  client.CreatedAt.Unix(),
+	}
+
+	workspaces, getWorkspacesError := database.GetAllWorkspacesByClientId(ctx, sh.Db, clientId)
+	if getWorkspacesError != nil {
+		return export, getWorkspacesError
+	}
+	export.Workspaces = []ExportedWorkspace{}
+	for _, workspace := range workspaces {
+		exportedWorkspace, buildWorkspaceExportError := sh.buildWorkspaceDataExport(ctx, workspace)
+		if buildWorkspaceExportError != nil {
+			return export, buildWorkspaceExportError
+		}
+		export.Workspaces = append(export.Workspaces, exportedWorkspace)
+	}
+
+	return export, nil
+}
+
+// Collect every record in a workspace client is a member of, shared workspaces included
+func (sh *SettingsHandler) buildWorkspaceDataExport(ctx context.Context, workspace database.WorkspaceMembership) (ExportedWorkspace, error) {
+	export := ExportedWorkspace{Id: workspace.Id, Name: workspace.Name, Role: workspace.Role}
+
+	accounts, getAccountsError := sh.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspace.Id)
+	if getAccountsError != nil {
+		return export, getAccountsError
+	}
+	export.Accounts = accounts
+
+	stockHoldings, getStockHoldingsError := sh.Stocks.GetAllStockHoldings(ctx, workspace.Id)
+	if getStockHoldingsError != nil {
+		return export, getStockHoldingsError
+	}
+	export.StockHoldings = []ExportedStockHolding{}
+	for _, stockHolding := range stockHoldings {
+		export.StockHoldings = append(export.StockHoldings, ExportedStockHolding{
+			Id:        stockHolding.Id,
+			AccountId: stockHolding.AccountId,
+			StockId:   stockHolding.StockId,
+			Unit:      stockHolding.Unit,
+			Cost:      stockHolding.Cost,
+		})
+	}
+
+	cash, getCashError := database.GetAllCash(ctx, sh.Db, workspace.Id)
+	if getCashError != nil {
+		return export, getCashError
+	}
+	export.Cash = []CashRecord{}
+	for _, record := range cash {
+		export.Cash = append(export.Cash, CashRecord{Id: record.Id, Amount: record.Amount, CurrencyId: record.CurrencyId})
+	}
+
+	transactions, getTransactionsError := sh.Transactions.GetAllTransactions(ctx, workspace.Id)
+	if getTransactionsError != nil {
+		return export, getTransactionsError
+	}
+	export.Transactions = []TransactionRecord{}
+	for _, transaction := range transactions {
+		record := TransactionRecord{
+			Id:         transaction.Id,
+			Name:       transaction.Name,
+			Income:     transaction.Income,
+			Amount:     transaction.Amount,
+			Category:   transaction.Category,
+			CurrencyId: transaction.CurrencyId,
+			Remarks:    transaction.Remarks.String,
+			ExecutedAt: transaction.ExecutedAt.Unix(),
+		}
+		if transaction.AccountId.Valid {
+			accountId := transaction.AccountId.String
+			record.AccountId = &accountId
+		}
+		export.Transactions = append(export.Transactions, record)
+	}
+
+	futurePayments, getFuturePaymentsError := sh.FuturePayments.GetAllFuturePaymentsByWorkspaceId(ctx, workspace.Id)
+	if getFuturePaymentsError != nil {
+		return export, getFuturePaymentsError
+	}
+	export.FuturePayments = []FuturePaymentRecord{}
+	for _, futurePayment := range futurePayments {
+		record := FuturePaymentRecord{
+			Id:          futurePayment.Id,
+			Name:        futurePayment.Name,
+			Amount:      futurePayment.Amount,
+			Income:      futurePayment.Income,
+			Rolling:     futurePayment.Rolling,
+			Remarks:     futurePayment.Remarks.String,
+			Category:    futurePayment.Category,
+			AccountId:   futurePayment.AccountId,
+			CurrencyId:  futurePayment.CurrencyId,
+			ScheduledAt: futurePayment.ScheduledAt.Unix(),
+		}
+		if futurePayment.Frequency.Valid {
+			frequency := futurePayment.Frequency.Int64
+			record.Frequency = &frequency
+		}
+		if futurePayment.SourceAccountId.Valid {
+			sourceAccountId := futurePayment.SourceAccountId.String
+			record.SourceAccountId = &sourceAccountId
+		}
+		if futurePayment.EndsAt.Valid {
+			endsAt := futurePayment.EndsAt.Time.Unix()
+			record.EndsAt = &endsAt
+		}
+		export.FuturePayments = append(export.FuturePayments, record)
+	}
+
+	goals, getGoalsError := database.GetAllGoalsByWorkspaceId(ctx, sh.Db, workspace.Id)
+	if getGoalsError != nil {
+		return export, getGoalsError
+	}
+	goalAccounts, getGoalAccountsError := database.GetAllGoalAccountsByWorkspaceId(ctx, sh.Db, workspace.Id)
+	if getGoalAccountsError != nil {
+		return export, getGoalAccountsError
+	}
+	export.Goals = []ExportedGoal{}
+	for _, goal := range goals {
+		record := ExportedGoal{
+			Id:           goal.Id,
+			Name:         goal.Name,
+			CurrencyId:   goal.CurrencyId,
+			TargetAmount: goal.TargetAmount,
+			Deadline:     goal.Deadline.Unix(),
+			AccountIds:   []string{},
+		}
+		for _, goalAccount := range goalAccounts {
+			if goalAccount.GoalId == goal.Id {
+				record.AccountIds = append(record.AccountIds, goalAccount.AccountId)
+			}
+		}
+		if goal.FuturePaymentId.Valid {
+			futurePaymentId := goal.FuturePaymentId.String
+			record.FuturePaymentId = &futurePaymentId
+		}
+		export.Goals = append(export.Goals, record)
+	}
+
+	return export, nil
+}
+
+func (sh *SettingsHandler) ExportClientData(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
+	logger.Info("starts")
+
+	// Export contains every record of the client, which is far beyond what any api token scope grants
+	if c.Get("apiTokenId") != nil {
+		logger.Error("client data export requested with api token")
+		return apperror.PermissionDenied("Data export requires a login session.")
+	}
+
+	clientId := c.Get("uid").(string)
+	export, buildExportError := sh.buildClientDataExport(ctx, clientId)
+	if buildExportError != nil {
+		logger.Error(fmt.Sprintf("failed to build client data export. %s", buildExportError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("built client data export")
+
+	// Offer the export as a file download rather than inline data
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"everytrack-export-%s.json\"", time.Now().Format("20060102")),
+	)
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": export})
+}
@@ -4,15 +4,15 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
-	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
 	"go.uber.org/zap"
 )
 
 type ExchangeRatesHandler struct {
-	Db     *pgxpool.Pool
-	Logger *zap.Logger
+	Logger        *zap.Logger
+	ExchangeRates repository.ExchangeRateRepository
 }
 
 type ExchangeRateData struct {
@@ -22,16 +22,18 @@ type ExchangeRateData struct {
 }
 
 func (erh *ExchangeRatesHandler) GetAllExchangeRates(c echo.Context) error {
-	erh.Logger.Info("starts")
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, erh.Logger)
+	logger.Info("starts")
 
 	// Get all exchange rates from database
-	exchangeRates, getExchangeRatesError := database.GetAllExchangeRates(erh.Db)
+	exchangeRates, getExchangeRatesError := erh.ExchangeRates.GetAllExchangeRates(ctx)
 
 	if getExchangeRatesError != nil {
-		erh.Logger.Error(fmt.Sprintf("failed to get exchange rates from database. %s", getExchangeRatesError.Error()))
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "Internal server error."})
+		logger.Error(fmt.Sprintf("failed to get exchange rates from database. %s", getExchangeRatesError.Error()))
+		return apperror.Internal()
 	}
-	erh.Logger.Debug("got exchange rates from database")
+	logger.Debug("got exchange rates from database")
 
 	// Construct response object
 	responseData := []ExchangeRateData{}
@@ -1,23 +1,22 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/iancoleman/strcase"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
 	"go.uber.org/zap"
 )
 
 type FuturePaymentsHandler struct {
-	Db     *pgxpool.Pool
-	Logger *zap.Logger
+	Logger         *zap.Logger
+	Accounts       repository.AccountRepository
+	FuturePayments repository.FuturePaymentRepository
 }
 
 type FuturePaymentRecord struct {
@@ -32,6 +31,9 @@ type FuturePaymentRecord struct {
 	AccountId   string `json:"accountId"`
 	CurrencyId  string `json:"currencyId"`
 	ScheduledAt int64  `json:"scheduledAt"`
+	// Only set for payments funding a goal, which transfer from the source account until the goal deadline
+	SourceAccountId *string `json:"sourceAccountId"`
+	EndsAt          *int64  `json:"endsAt"`
 }
 
 type CreateNewFuturePaymentRequestBody struct {
@@ -62,23 +64,18 @@ type UpdateFuturePaymentRequestBody struct {
 }
 
 func (fph *FuturePaymentsHandler) GetAllFuturePayments(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	fph.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, fph.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Get all future payments from database
-	futurePayments, getFuturePaymentsError := database.GetAllFuturePaymentsByClientId(fph.Db, clientId)
+	futurePayments, getFuturePaymentsError := fph.FuturePayments.GetAllFuturePaymentsByWorkspaceId(ctx, workspaceId)
 	if getFuturePaymentsError != nil {
-		fph.Logger.Error(
-			fmt.Sprintf("failed to get all future payment records from database. %s", getFuturePaymentsError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to get all future payment records from database. %s", getFuturePaymentsError.Error()))
+		return apperror.Internal()
 	}
-	fph.Logger.Debug("got future payments from database", requestId)
+	logger.Debug("got future payments from database")
 
 	// Construct the response object
 	futurePaymentRecords := []FuturePaymentRecord{}
@@ -99,76 +96,71 @@ func (fph *FuturePaymentsHandler) GetAllFuturePayments(c echo.Context) error {
 			frequency := futurePayment.Frequency.Int64
 			record.Frequency = &frequency
 		}
+		if futurePayment.SourceAccountId.Valid {
+			sourceAccountId := futurePayment.SourceAccountId.String
+			record.SourceAccountId = &sourceAccountId
+		}
+		if futurePayment.EndsAt.Valid {
+			endsAt := futurePayment.EndsAt.Time.Unix()
+			record.EndsAt = &endsAt
+		}
 		futurePaymentRecords = append(futurePaymentRecords, record)
 	}
-	fph.Logger.Debug(fmt.Sprintf("constructed response object - %#v", futurePaymentRecords), requestId)
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", futurePaymentRecords))
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": futurePaymentRecords})
 }
 
 func (fph *FuturePaymentsHandler) CreateNewFuturePayment(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, fph.Logger)
 	data := new(CreateNewFuturePaymentRequestBody)
 	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	fph.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		fph.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
 	}
 
 	income, parseIncomeError := strconv.ParseBool(data.Income)
 	if parseIncomeError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field income"},
-		)
+		return apperror.InvalidField("income", "format", "has an invalid value")
 	}
 
 	rolling, parseRollingError := strconv.ParseBool(data.Rolling)
 	if parseRollingError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field rolling"},
-		)
+		return apperror.InvalidField("rolling", "format", "has an invalid value")
 	}
 
-	fph.Logger.Debug("validated request parameters", requestId)
+	logger.Debug("validated request parameters")
+
+	// Check if workspace owns the account
+	isOwned, checkOwnershipError := fph.Accounts.CheckWorkspaceOwnership(ctx, workspaceId, data.AccountId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of account in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("account %s not found in workspace %s", data.AccountId, workspaceId))
+		return apperror.NotFound("Account not found.")
+	}
 
 	// Throw error if the payment is on rolling basis but upstream does not send payment frequency as well
 	if rolling && data.Frequency < 1 {
-		fph.Logger.Error("missing frequency when payment is on rolling basis.", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required field frequency"},
-		)
+		logger.Error("missing frequency when payment is on rolling basis.")
+		return apperror.InvalidArgument("Missing required field frequency.")
 	}
 
 	// Throw error if the scheduled payment time is on today or previous days
 	if data.ScheduledAt <= time.Now().Unix() {
-		fph.Logger.Error("the payment schedule time is earlier than current date.", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid payment schedule time"},
-		)
+		logger.Error("the payment schedule time is earlier than current date.")
+		return apperror.InvalidArgument("Invalid payment schedule time.")
 	}
 
 	// Construct database query parameters
@@ -178,6 +170,7 @@ func (fph *FuturePaymentsHandler) CreateNewFuturePayment(c echo.Context) error {
 		Income:      income,
 		Rolling:     rolling,
 		ClientId:    clientId,
+		WorkspaceId: workspaceId,
 		Category:    data.Category,
 		AccountId:   data.AccountId,
 		CurrencyId:  data.CurrencyId,
@@ -190,74 +183,72 @@ func (fph *FuturePaymentsHandler) CreateNewFuturePayment(c echo.Context) error {
 	if data.Frequency > 0 {
 		createNewFuturePaymentDbParams.Frequency = &data.Frequency
 	}
-	fph.Logger.Debug(fmt.Sprintf("constructed parameters for create new future payment database query - %#v", createNewFuturePaymentDbParams))
+	logger.Debug(fmt.Sprintf("constructed parameters for create new future payment database query - %#v", createNewFuturePaymentDbParams))
 
 	// Create new future payment record in database
-	_, createError := database.CreateNewFuturePayment(fph.Db, createNewFuturePaymentDbParams)
+	_, createError := fph.FuturePayments.CreateNewFuturePayment(ctx, createNewFuturePaymentDbParams)
 	if createError != nil {
-		fph.Logger.Error(
-			fmt.Sprintf("failed to create new future payment record in database. %s", createError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to create new future payment record in database. %s", createError.Error()))
+		return apperror.Internal()
 	}
-	fph.Logger.Debug("created a new future payment record in database", requestId)
+	logger.Debug("created a new future payment record in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (fph *FuturePaymentsHandler) UpdateFuturePayment(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, fph.Logger)
 	data := new(UpdateFuturePaymentRequestBody)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	fph.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		fph.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
 	}
 
 	income, parseIncomeError := strconv.ParseBool(data.Income)
 	if parseIncomeError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field income"},
-		)
+		return apperror.InvalidField("income", "format", "has an invalid value")
 	}
 
 	rolling, parseRollingError := strconv.ParseBool(data.Rolling)
 	if parseRollingError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field rolling"},
-		)
+		return apperror.InvalidField("rolling", "format", "has an invalid value")
+	}
+
+	logger.Debug("validated request parameters")
+
+	// Check if workspace owns the future payment
+	isOwned, checkOwnershipError := fph.FuturePayments.CheckWorkspaceOwnership(ctx, workspaceId, data.Id)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of future payment in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("future payment %s not found in workspace %s", data.Id, workspaceId))
+		return apperror.NotFound("Future payment not found.")
 	}
 
-	fph.Logger.Debug("validated request parameters", requestId)
+	// Check if the account belongs to workspace as well
+	isOwned, checkOwnershipError = fph.Accounts.CheckWorkspaceOwnership(ctx, workspaceId, data.AccountId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of account in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("account %s not found in workspace %s", data.AccountId, workspaceId))
+		return apperror.NotFound("Account not found.")
+	}
 
 	// Update account in database
-	_, updateError := database.UpdateFuturePayment(
-		fph.Db,
+	_, updateError := fph.FuturePayments.UpdateFuturePayment(
+		ctx,
 		database.UpdateFuturePaymentParams{
 			Id:          data.Id,
 			Name:        data.Name,
@@ -269,51 +260,49 @@ func (fph *FuturePaymentsHandler) UpdateFuturePayment(c echo.Context) error {
 			Frequency:   &data.Frequency,
 			AccountId:   data.AccountId,
 			CurrencyId:  data.CurrencyId,
+			WorkspaceId: workspaceId,
 			ScheduledAt: time.Unix(data.ScheduledAt, 0),
 		},
 	)
 	if updateError != nil {
-		fph.Logger.Error(
-			fmt.Sprintf("failed to update future payment in database. %s", updateError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to update future payment in database. %s", updateError.Error()))
+		return apperror.Internal()
 	}
-	fph.Logger.Debug("updated future payment in database", requestId)
+	logger.Debug("updated future payment in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (fph *FuturePaymentsHandler) DeleteFuturePayment(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	fph.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, fph.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	futurePaymentId := c.QueryParam("id")
 	if len(futurePaymentId) == 0 {
-		fph.Logger.Error("undefined future payment id", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined future payment id."},
-		)
+		logger.Error("undefined future payment id")
+		return apperror.InvalidArgument("Undefined future payment id.")
+	}
+
+	// Check if workspace owns the future payment
+	isOwned, checkOwnershipError := fph.FuturePayments.CheckWorkspaceOwnership(ctx, workspaceId, futurePaymentId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of future payment in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("future payment %s not found in workspace %s", futurePaymentId, workspaceId))
+		return apperror.NotFound("Future payment not found.")
 	}
 
 	// Delete future payment record in database
-	_, deleteError := database.DeleteFuturePayment(fph.Db, futurePaymentId, clientId)
+	_, deleteError := fph.FuturePayments.DeleteFuturePayment(ctx, futurePaymentId, workspaceId)
 	if deleteError != nil {
-		fph.Logger.Error(
-			fmt.Sprintf("failed to delete future payment in database. %s", deleteError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to delete future payment in database. %s", deleteError.Error()))
+		return apperror.Internal()
 	}
-	fph.Logger.Debug("deleted future payment record in database", requestId)
+	logger.Debug("deleted future payment record in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// Monthly contribution frequency in seconds, matching the frequency unit of future payments
+const goalContributionFrequency int64 = 30 * 86400
+
+type GoalsHandler struct {
+	Logger        *zap.Logger
+	Accounts      repository.AccountRepository
+	ExchangeRates repository.ExchangeRateRepository
+	Goals         repository.GoalRepository
+}
+
+type GoalRecord struct {
+	Id                          string   `json:"id"`
+	Name                        string   `json:"name"`
+	CurrencyId                  string   `json:"currencyId"`
+	TargetAmount                string   `json:"targetAmount"`
+	Deadline                    int64    `json:"deadline"`
+	AccountIds                  []string `json:"accountIds"`
+	CurrentAmount               string   `json:"currentAmount"`
+	RemainingAmount             string   `json:"remainingAmount"`
+	Progress                    string   `json:"progress"`
+	MonthsRemaining             int      `json:"monthsRemaining"`
+	RequiredMonthlyContribution string   `json:"requiredMonthlyContribution"`
+	FuturePaymentId             *string  `json:"futurePaymentId"`
+}
+
+type CreateNewGoalRequestBody struct {
+	Name             string   `json:"name" validate:"required"`
+	CurrencyId       string   `json:"currencyId" validate:"required"`
+	TargetAmount     string   `json:"targetAmount" validate:"required"`
+	Deadline         int64    `json:"deadline" validate:"required"`
+	AccountIds       []string `json:"accountIds" validate:"required,min=1,dive,required"`
+	AutoFund         string   `json:"autoFund"`
+	FundingAccountId string   `json:"fundingAccountId"`
+	SourceAccountId  string   `json:"sourceAccountId"`
+}
+
+type UpdateGoalRequestBody struct {
+	Id               string   `json:"id" validate:"required"`
+	Name             string   `json:"name" validate:"required"`
+	CurrencyId       string   `json:"currencyId" validate:"required"`
+	TargetAmount     string   `json:"targetAmount" validate:"required"`
+	Deadline         int64    `json:"deadline" validate:"required"`
+	AccountIds       []string `json:"accountIds" validate:"required,min=1,dive,required"`
+	AutoFund         string   `json:"autoFund"`
+	FundingAccountId string   `json:"fundingAccountId"`
+	SourceAccountId  string   `json:"sourceAccountId"`
+}
+
+type goalProgress struct {
+	current         decimal.Decimal
+	remaining       decimal.Decimal
+	progress        decimal.Decimal
+	monthsRemaining int
+	contribution    decimal.Decimal
+}
+
+// Track progress of a goal from the balances of its linked accounts converted into the goal currency
+func calculateGoalProgress(
+	targetAmount decimal.Decimal,
+	currencyId string,
+	deadline time.Time,
+	accounts []database.AccountSummary,
+	exchangeRates []database.ExchangeRate,
+) (goalProgress, error) {
+	progress := goalProgress{}
+
+	current, calculateCurrentAmountError := utils.CalculateGoalCurrentAmount(currencyId, accounts, exchangeRates)
+	if calculateCurrentAmountError != nil {
+		return progress, calculateCurrentAmountError
+	}
+	progress.current = current
+
+	progress.remaining = decimal.Max(targetAmount.Sub(progress.current), decimal.Zero)
+	progress.progress = decimal.Zero
+	if targetAmount.IsPositive() {
+		progress.progress = decimal.Min(progress.current.Div(targetAmount).Mul(decimal.NewFromInt(100)), decimal.NewFromInt(100))
+	}
+	progress.monthsRemaining = utils.CalculateMonthsUntil(time.Now(), deadline)
+	progress.contribution = progress.remaining
+	if progress.monthsRemaining > 0 {
+		progress.contribution = progress.remaining.Div(decimal.NewFromInt(int64(progress.monthsRemaining)))
+	}
+	progress.contribution = progress.contribution.RoundUp(2)
+
+	return progress, nil
+}
+
+// Validate the goal fields shared by create and update requests and prepare the funding future payment if requested,
+// which transfers a monthly contribution from the source account into the funding account until the deadline.
+// Returns a non-empty client facing error message when the request is invalid.
+func (gh *GoalsHandler) prepareGoal(
+	ctx context.Context, workspaceId string,
+	name string,
+	currencyId string,
+	rawTargetAmount string,
+	rawDeadline int64,
+	accountIds []string,
+	rawAutoFund string,
+	fundingAccountId string,
+	sourceAccountId string,
+) (decimal.Decimal, *database.GoalFundingParams, string, error) {
+	targetAmount, parseTargetAmountError := decimal.NewFromString(rawTargetAmount)
+	if parseTargetAmountError != nil || !targetAmount.IsPositive() {
+		return targetAmount, nil, "Invalid field targetAmount", nil
+	}
+	deadline := time.Unix(rawDeadline, 0)
+	if !deadline.After(time.Now()) {
+		return targetAmount, nil, "Invalid field deadline", nil
+	}
+	autoFund := false
+	if len(rawAutoFund) > 0 {
+		parsedAutoFund, parseAutoFundError := strconv.ParseBool(rawAutoFund)
+		if parseAutoFundError != nil {
+			return targetAmount, nil, "Invalid field autoFund", nil
+		}
+		autoFund = parsedAutoFund
+	}
+
+	// Check if client owns all the linked accounts
+	ownedAccounts, getOwnedAccountsError := gh.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspaceId)
+	if getOwnedAccountsError != nil {
+		return targetAmount, nil, "", getOwnedAccountsError
+	}
+	linkedAccounts := []database.AccountSummary{}
+	for _, account := range ownedAccounts {
+		if slices.Contains(accountIds, account.Id) {
+			linkedAccounts = append(linkedAccounts, account)
+		}
+	}
+	if len(linkedAccounts) != len(accountIds) {
+		return targetAmount, nil, "Invalid field accountIds", nil
+	}
+	if !autoFund {
+		return targetAmount, nil, "", nil
+	}
+
+	// Fund the goal into the chosen linked account, or the first linked account by default
+	if len(fundingAccountId) == 0 {
+		fundingAccountId = accountIds[0]
+	}
+	fundingAccountIndex := slices.IndexFunc(linkedAccounts, func(account database.AccountSummary) bool { return account.Id == fundingAccountId })
+	if fundingAccountIndex < 0 {
+		return targetAmount, nil, "Invalid field fundingAccountId", nil
+	}
+	fundingAccount := linkedAccounts[fundingAccountIndex]
+
+	// Contributions are transferred from an account outside of the goal in the same currency as the funding account
+	sourceAccountIndex := slices.IndexFunc(ownedAccounts, func(account database.AccountSummary) bool { return account.Id == sourceAccountId })
+	if sourceAccountIndex < 0 || slices.Contains(accountIds, sourceAccountId) {
+		return targetAmount, nil, "Invalid field sourceAccountId", nil
+	}
+	if ownedAccounts[sourceAccountIndex].CurrencyId != fundingAccount.CurrencyId {
+		return targetAmount, nil, "Invalid field sourceAccountId", nil
+	}
+
+	// No contribution is due when the first one would be scheduled after the deadline
+	scheduledAt := time.Now().Truncate(24*time.Hour).AddDate(0, 1, 0)
+	if scheduledAt.After(deadline) {
+		return targetAmount, nil, "", nil
+	}
+
+	exchangeRates, getExchangeRatesError := gh.ExchangeRates.GetAllExchangeRates(ctx)
+	if getExchangeRatesError != nil {
+		return targetAmount, nil, "", getExchangeRatesError
+	}
+	progress, calculateProgressError := calculateGoalProgress(targetAmount, currencyId, deadline, linkedAccounts, exchangeRates)
+	if calculateProgressError != nil {
+		return targetAmount, nil, "", calculateProgressError
+	}
+	if !progress.contribution.IsPositive() {
+		return targetAmount, nil, "", nil
+	}
+	contribution, convertError := utils.ConvertCurrency(progress.contribution, currencyId, fundingAccount.CurrencyId, exchangeRates)
+	if convertError != nil {
+		return targetAmount, nil, "", convertError
+	}
+
+	return targetAmount, &database.GoalFundingParams{
+		Name:            fmt.Sprintf("Contribution to %s", name),
+		Amount:          contribution.RoundUp(2).String(),
+		Frequency:       goalContributionFrequency,
+		AccountId:       fundingAccount.Id,
+		SourceAccountId: sourceAccountId,
+		CurrencyId:      fundingAccount.CurrencyId,
+		ScheduledAt:     scheduledAt,
+		EndsAt:          deadline,
+	}, "", nil
+}
+
+func (gh *GoalsHandler) GetAllGoals(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, gh.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Get all goals and their linked accounts from database
+	goals, getGoalsError := gh.Goals.GetAllGoalsByWorkspaceId(ctx, workspaceId)
+	if getGoalsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all goals from database. %s", getGoalsError.Error()))
+		return apperror.Internal()
+	}
+	goalAccounts, getGoalAccountsError := gh.Goals.GetAllGoalAccountsByWorkspaceId(ctx, workspaceId)
+	if getGoalAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all goal accounts from database. %s", getGoalAccountsError.Error()))
+		return apperror.Internal()
+	}
+	accounts, getAccountsError := gh.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspaceId)
+	if getAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all accounts from database. %s", getAccountsError.Error()))
+		return apperror.Internal()
+	}
+	exchangeRates, getExchangeRatesError := gh.ExchangeRates.GetAllExchangeRates(ctx)
+	if getExchangeRatesError != nil {
+		logger.Error(fmt.Sprintf("failed to get exchange rates from database. %s", getExchangeRatesError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got goals, linked accounts and exchange rates from database")
+
+	// Construct the response object with progress of each goal
+	goalRecords := []GoalRecord{}
+	for _, goal := range goals {
+		accountIds := []string{}
+		for _, goalAccount := range goalAccounts {
+			if goalAccount.GoalId == goal.Id {
+				accountIds = append(accountIds, goalAccount.AccountId)
+			}
+		}
+		linkedAccounts := []database.AccountSummary{}
+		for _, account := range accounts {
+			if slices.Contains(accountIds, account.Id) {
+				linkedAccounts = append(linkedAccounts, account)
+			}
+		}
+
+		targetAmount, parseTargetAmountError := decimal.NewFromString(goal.TargetAmount)
+		if parseTargetAmountError != nil {
+			logger.Error(fmt.Sprintf("failed to parse target amount of goal %s. %s", goal.Id, parseTargetAmountError.Error()))
+			return apperror.Internal()
+		}
+		progress, calculateProgressError := calculateGoalProgress(targetAmount, goal.CurrencyId, goal.Deadline, linkedAccounts, exchangeRates)
+		if calculateProgressError != nil {
+			logger.Error(fmt.Sprintf("failed to calculate progress of goal %s. %s", goal.Id, calculateProgressError.Error()))
+			return apperror.Internal()
+		}
+
+		record := GoalRecord{
+			Id:                          goal.Id,
+			Name:                        goal.Name,
+			CurrencyId:                  goal.CurrencyId,
+			TargetAmount:                goal.TargetAmount,
+			Deadline:                    goal.Deadline.Unix(),
+			AccountIds:                  accountIds,
+			CurrentAmount:               progress.current.Truncate(2).String(),
+			RemainingAmount:             progress.remaining.Truncate(2).String(),
+			Progress:                    progress.progress.Round(2).String(),
+			MonthsRemaining:             progress.monthsRemaining,
+			RequiredMonthlyContribution: progress.contribution.String(),
+		}
+		if goal.FuturePaymentId.Valid {
+			futurePaymentId := goal.FuturePaymentId.String
+			record.FuturePaymentId = &futurePaymentId
+		}
+		goalRecords = append(goalRecords, record)
+	}
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", goalRecords))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": goalRecords})
+}
+
+func (gh *GoalsHandler) CreateNewGoal(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, gh.Logger)
+	data := new(CreateNewGoalRequestBody)
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	targetAmount, funding, invalidMessage, prepareError := gh.prepareGoal(ctx,
+		workspaceId,
+		data.Name,
+		data.CurrencyId,
+		data.TargetAmount,
+		data.Deadline,
+		data.AccountIds,
+		data.AutoFund,
+		data.FundingAccountId,
+		data.SourceAccountId,
+	)
+	if prepareError != nil {
+		logger.Error(fmt.Sprintf("failed to prepare goal. %s", prepareError.Error()))
+		return apperror.Internal()
+	}
+	if len(invalidMessage) > 0 {
+		return apperror.InvalidArgument(invalidMessage)
+	}
+	logger.Debug("validated request parameters")
+
+	// Create new goal in database
+	_, createError := gh.Goals.CreateNewGoal(ctx, database.CreateNewGoalParams{
+		Name:         data.Name,
+		ClientId:     clientId,
+		WorkspaceId:  workspaceId,
+		CurrencyId:   data.CurrencyId,
+		TargetAmount: targetAmount.String(),
+		Deadline:     time.Unix(data.Deadline, 0),
+		AccountIds:   data.AccountIds,
+		Funding:      funding,
+	})
+	if createError != nil {
+		logger.Error(fmt.Sprintf("failed to create new goal in database. %s", createError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("created a new goal in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (gh *GoalsHandler) UpdateGoal(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, gh.Logger)
+	data := new(UpdateGoalRequestBody)
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	targetAmount, funding, invalidMessage, prepareError := gh.prepareGoal(ctx,
+		workspaceId,
+		data.Name,
+		data.CurrencyId,
+		data.TargetAmount,
+		data.Deadline,
+		data.AccountIds,
+		data.AutoFund,
+		data.FundingAccountId,
+		data.SourceAccountId,
+	)
+	if prepareError != nil {
+		logger.Error(fmt.Sprintf("failed to prepare goal. %s", prepareError.Error()))
+		return apperror.Internal()
+	}
+	if len(invalidMessage) > 0 {
+		return apperror.InvalidArgument(invalidMessage)
+	}
+	logger.Debug("validated request parameters")
+
+	// Check if workspace owns the goal
+	isOwned, checkOwnershipError := gh.Goals.CheckWorkspaceOwnership(ctx, workspaceId, data.Id)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of goal in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("goal %s not found in workspace %s", data.Id, workspaceId))
+		return apperror.NotFound("Goal not found.")
+	}
+
+	// Update goal in database
+	_, updateError := gh.Goals.UpdateGoal(ctx, database.UpdateGoalParams{
+		Id:           data.Id,
+		Name:         data.Name,
+		ClientId:     clientId,
+		WorkspaceId:  workspaceId,
+		CurrencyId:   data.CurrencyId,
+		TargetAmount: targetAmount.String(),
+		Deadline:     time.Unix(data.Deadline, 0),
+		AccountIds:   data.AccountIds,
+		Funding:      funding,
+	})
+	if updateError != nil {
+		logger.Error(fmt.Sprintf("failed to update goal in database. %s", updateError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("updated goal in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (gh *GoalsHandler) DeleteGoal(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, gh.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	goalId := c.QueryParam("id")
+	if len(goalId) == 0 {
+		logger.Error("undefined goal id")
+		return apperror.InvalidArgument("Undefined goal id.")
+	}
+
+	// Check if workspace owns the goal
+	isOwned, checkOwnershipError := gh.Goals.CheckWorkspaceOwnership(ctx, workspaceId, goalId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of goal in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("goal %s not found in workspace %s", goalId, workspaceId))
+		return apperror.NotFound("Goal not found.")
+	}
+
+	// Delete goal together with its funding future payment in database
+	_, deleteError := gh.Goals.DeleteGoal(ctx, goalId, workspaceId)
+	if deleteError != nil {
+		logger.Error(fmt.Sprintf("failed to delete goal in database. %s", deleteError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("deleted goal in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
@@ -1,44 +1,125 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/logger"
+	"github.com/nighostchris/everytrack-backend/internal/mailer"
+	"github.com/nighostchris/everytrack-backend/internal/oauth"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/service"
 	"github.com/nighostchris/everytrack-backend/internal/utils"
 	"go.uber.org/zap"
 )
 
 type Handlers struct {
-	Auth           *AuthHandler
-	Cash           *CashHandler
-	Stocks         *StocksHandler
-	Accounts       *AccountsHandler
-	Settings       *SettingsHandler
-	Providers      *ProvidersHandler
-	Countries      *CountriesHandler
-	Currencies     *CurrenciesHandler
-	Transactions   *TransactionsHandler
-	ExchangeRates  *ExchangeRatesHandler
-	FuturePayments *FuturePaymentsHandler
+	Auth            *AuthHandler
+	Cash            *CashHandler
+	Goals           *GoalsHandler
+	Stocks          *StocksHandler
+	Accounts        *AccountsHandler
+	Sessions        *SessionsHandler
+	Settings        *SettingsHandler
+	Providers       *ProvidersHandler
+	ApiTokens       *ApiTokensHandler
+	Countries       *CountriesHandler
+	Currencies      *CurrenciesHandler
+	LoanAccounts    *LoanAccountsHandler
+	CreditAccounts  *CreditAccountsHandler
+	Transactions    *TransactionsHandler
+	Reconciliations *ReconciliationsHandler
+	ExchangeRates   *ExchangeRatesHandler
+	FuturePayments  *FuturePaymentsHandler
+	Workspaces      *WorkspacesHandler
+	Jwks            *JwksHandler
+	Health          *HealthHandler
+	Metrics         *MetricsHandler
+	Verified        *VerifiedClientMiddleware
+	Workspace       *WorkspaceMiddleware
 }
 
 type LooseJson map[string]interface{}
 
-func Init(db *pgxpool.Pool, env *config.Config, logger *zap.Logger) *Handlers {
+// Logger of the request carrying its request id and trace, attached to request context by log middleware
+func requestLogger(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	return logger.FromContext(ctx, fallback)
+}
+
+func Init(db *pgxpool.Pool, env *config.Config, logger *zap.Logger, tokenUtils *utils.TokenUtils) *Handlers {
+	repositories := repository.NewPgxRepositories(db)
+	ledger := service.NewLedgerService(repositories)
+
 	return &Handlers{
-		Cash:           &CashHandler{Db: db, Logger: logger},
-		Stocks:         &StocksHandler{Db: db, Logger: logger},
-		Settings:       &SettingsHandler{Db: db, Logger: logger},
-		Accounts:       &AccountsHandler{Db: db, Logger: logger},
+		Cash:           &CashHandler{Logger: logger, Cash: repositories.Cash},
+		Stocks:         &StocksHandler{Logger: logger, Accounts: repositories.Accounts, Stocks: repositories.Stocks},
+		Sessions:       &SessionsHandler{Db: db, Logger: logger},
 		Providers:      &ProvidersHandler{Db: db, Logger: logger},
+		ApiTokens:      &ApiTokensHandler{Db: db, Logger: logger},
 		Countries:      &CountriesHandler{Db: db, Logger: logger},
 		Currencies:     &CurrenciesHandler{Db: db, Logger: logger},
-		Transactions:   &TransactionsHandler{Db: db, Logger: logger},
-		ExchangeRates:  &ExchangeRatesHandler{Db: db, Logger: logger},
-		FuturePayments: &FuturePaymentsHandler{Db: db, Logger: logger},
-		Auth:           &AuthHandler{Db: db, Logger: logger, TokenUtils: &utils.TokenUtils{Env: env, Logger: logger}},
+		LoanAccounts:   &LoanAccountsHandler{Logger: logger, Ledger: ledger, LoanAccounts: repositories.LoanAccounts},
+		Transactions:   &TransactionsHandler{Logger: logger, Ledger: ledger, Transactions: repositories.Transactions},
+		ExchangeRates:  &ExchangeRatesHandler{Logger: logger, ExchangeRates: repositories.ExchangeRates},
+		FuturePayments: &FuturePaymentsHandler{Logger: logger, Accounts: repositories.Accounts, FuturePayments: repositories.FuturePayments},
+		Jwks:           &JwksHandler{Logger: logger, TokenUtils: tokenUtils},
+		Health:         &HealthHandler{Db: db, Env: env, Logger: logger},
+		Metrics:        &MetricsHandler{Env: env, Logger: logger},
+		Verified:       &VerifiedClientMiddleware{Db: db, Logger: logger},
+		Workspace:      &WorkspaceMiddleware{Db: db, Logger: logger},
+		Auth: &AuthHandler{
+			Db:             db,
+			Env:            env,
+			Logger:         logger,
+			Mailer:         mailer.New(env, logger),
+			TokenUtils:     tokenUtils,
+			OauthProviders: oauth.New(env, logger),
+		},
+		Accounts: &AccountsHandler{
+			Logger:        logger,
+			Ledger:        ledger,
+			Accounts:      repositories.Accounts,
+			Clients:       repositories.Clients,
+			ExchangeRates: repositories.ExchangeRates,
+			Transactions:  repositories.Transactions,
+		},
+		CreditAccounts: &CreditAccountsHandler{
+			Logger:         logger,
+			Accounts:       repositories.Accounts,
+			CreditAccounts: repositories.CreditAccounts,
+			Transactions:   repositories.Transactions,
+		},
+		Goals: &GoalsHandler{
+			Logger:        logger,
+			Accounts:      repositories.Accounts,
+			ExchangeRates: repositories.ExchangeRates,
+			Goals:         repositories.Goals,
+		},
+		Reconciliations: &ReconciliationsHandler{
+			Logger:          logger,
+			Ledger:          ledger,
+			Accounts:        repositories.Accounts,
+			Reconciliations: repositories.Reconciliations,
+		},
+		Settings: &SettingsHandler{
+			Db:             db,
+			Logger:         logger,
+			Accounts:       repositories.Accounts,
+			Clients:        repositories.Clients,
+			FuturePayments: repositories.FuturePayments,
+			Stocks:         repositories.Stocks,
+			Transactions:   repositories.Transactions,
+		},
+		Workspaces: &WorkspacesHandler{
+			Db:         db,
+			Env:        env,
+			Logger:     logger,
+			Mailer:     mailer.New(env, logger),
+			TokenUtils: tokenUtils,
+		},
 	}
 }
 
@@ -50,29 +131,68 @@ func (h *Handlers) BindRoutes(e *echo.Echo) {
 		return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
 	})
 
+	// ============================================================
+	// /healthz and /readyz - Liveness and readiness probes
+	// ============================================================
+	e.GET("/healthz", h.Health.GetLiveness)
+	e.GET("/readyz", h.Health.GetReadiness)
+
+	// ============================================================
+	// /metrics - Prometheus metrics
+	// ============================================================
+	e.GET("/metrics", h.Metrics.GetMetrics)
+
+	// ============================================================
+	// /.well-known/jwks.json - Public keys for verifying our tokens
+	// ============================================================
+	e.GET("/.well-known/jwks.json", h.Jwks.GetJwks)
+
 	v1 := e.Group("/v1")
 	// ============================================================
 	// /v1/accounts endpoints
 	// ============================================================
-	accounts := v1.Group("/accounts")
+	accounts := v1.Group("/accounts", h.Workspace.New)
 	accounts.PUT("", h.Accounts.UpdateAccount)
 	accounts.DELETE("", h.Accounts.DeleteAccount)
 	accounts.POST("", h.Accounts.CreateNewAccount)
-	accounts.POST("/transfer", h.Accounts.TransferBetweenAccounts)
+	accounts.POST("/transfer", h.Accounts.TransferBetweenAccounts, h.Verified.New)
 	accounts.GET("", h.Accounts.GetAllAccountsByType)
+	accounts.GET("/:id/history", h.Accounts.GetAccountBalanceHistory)
+	accounts.GET("/credit", h.CreditAccounts.GetAllCreditAccounts)
+	accounts.PUT("/credit", h.CreditAccounts.UpdateCreditAccount)
+	accounts.GET("/credit/statements", h.CreditAccounts.GetAllCreditStatements)
+	accounts.POST("/reconcile", h.Reconciliations.ReconcileAccount, h.Verified.New)
+	accounts.GET("/reconciliations", h.Reconciliations.GetAllReconciliations)
+	accounts.GET("/loan", h.LoanAccounts.GetAllLoanAccounts)
+	accounts.PUT("/loan", h.LoanAccounts.UpdateLoanAccount)
+	accounts.GET("/loan/schedule", h.LoanAccounts.GetLoanSchedule)
+	accounts.GET("/loan/repayments", h.LoanAccounts.GetAllLoanRepayments)
+	accounts.POST("/loan/repayments", h.LoanAccounts.CreateNewLoanRepayment)
 	// ============================================================
 	// /v1/auth endpoints
 	// ============================================================
 	auth := v1.Group("/auth")
 	auth.POST("/login", h.Auth.Login)
+	auth.POST("/login/2fa", h.Auth.LoginWithTwoFactor)
 	auth.POST("/signup", h.Auth.Signup)
 	auth.POST("/verify", h.Auth.Verify)
+	auth.POST("/verify/resend", h.Auth.ResendVerification)
+	auth.POST("/password/forgot", h.Auth.ForgotPassword)
+	auth.POST("/password/reset", h.Auth.ResetPassword)
+	auth.GET("/oauth/:provider", h.Auth.StartOauthLogin)
+	auth.GET("/oauth/:provider/callback", h.Auth.OauthCallback)
+	auth.GET("/attempts", h.Auth.GetAllFailedLoginAttempts)
+	auth.GET("/2fa", h.Auth.GetTwoFactorStatus)
+	auth.POST("/2fa/enrol", h.Auth.EnrolTwoFactor)
+	auth.POST("/2fa/confirm", h.Auth.ConfirmTwoFactor)
+	auth.POST("/2fa/disable", h.Auth.DisableTwoFactor)
+	auth.POST("/2fa/recovery-codes", h.Auth.RegenerateRecoveryCodes)
 	auth.POST("/logout", h.Auth.Logout)
 	auth.POST("/refresh", h.Auth.Refresh)
 	// ============================================================
 	// /v1/cash endpoints
 	// ============================================================
-	cash := v1.Group("/cash")
+	cash := v1.Group("/cash", h.Workspace.New)
 	cash.GET("", h.Cash.GetAllCash)
 	cash.DELETE("", h.Cash.DeleteCash)
 	cash.PUT("", h.Cash.UpdateCashRecord)
@@ -88,9 +208,24 @@ func (h *Handlers) BindRoutes(e *echo.Echo) {
 	currencies := v1.Group("/currencies")
 	currencies.GET("", h.Currencies.GetAllCurrencies)
 	// ============================================================
+	// /v1/goals endpoints
+	// ============================================================
+	goals := v1.Group("/goals", h.Workspace.New)
+	goals.GET("", h.Goals.GetAllGoals)
+	goals.PUT("", h.Goals.UpdateGoal)
+	goals.DELETE("", h.Goals.DeleteGoal)
+	goals.POST("", h.Goals.CreateNewGoal)
+	// ============================================================
+	// /v1/tokens endpoints
+	// ============================================================
+	tokens := v1.Group("/tokens")
+	tokens.GET("", h.ApiTokens.GetAllApiTokens)
+	tokens.DELETE("", h.ApiTokens.RevokeApiToken)
+	tokens.POST("", h.ApiTokens.CreateNewApiToken, h.Verified.New)
+	// ============================================================
 	// /v1/transactions endpoints
 	// ============================================================
-	transactions := v1.Group("/transactions")
+	transactions := v1.Group("/transactions", h.Workspace.New)
 	transactions.GET("", h.Transactions.GetAllTransactions)
 	transactions.DELETE("", h.Transactions.DeleteTransaction)
 	transactions.POST("", h.Transactions.CreateNewTransaction)
@@ -102,7 +237,7 @@ func (h *Handlers) BindRoutes(e *echo.Echo) {
 	// ============================================================
 	// /v1/fpayments endpoints
 	// ============================================================
-	futurePayments := v1.Group("/fpayments")
+	futurePayments := v1.Group("/fpayments", h.Workspace.New)
 	futurePayments.PUT("", h.FuturePayments.UpdateFuturePayment)
 	futurePayments.GET("", h.FuturePayments.GetAllFuturePayments)
 	futurePayments.DELETE("", h.FuturePayments.DeleteFuturePayment)
@@ -113,18 +248,43 @@ func (h *Handlers) BindRoutes(e *echo.Echo) {
 	providers := v1.Group("/providers")
 	providers.GET("", h.Providers.GetAllProvidersByType)
 	// ============================================================
+	// /v1/sessions endpoints
+	// ============================================================
+	sessions := v1.Group("/sessions")
+	sessions.GET("", h.Sessions.GetAllSessions)
+	sessions.DELETE("", h.Sessions.RevokeSession)
+	// ============================================================
 	// /v1/settings endpoints
 	// ============================================================
 	settings := v1.Group("/settings")
-	settings.PUT("", h.Settings.UpdateSettings)
+	settings.PUT("", h.Settings.UpdateSettings, h.Verified.New)
 	settings.GET("", h.Settings.GetAllClientSettings)
+	settings.GET("/export", h.Settings.ExportClientData)
+	settings.GET("/deletion", h.Auth.GetAccountDeletion)
+	settings.POST("/deletion", h.Auth.RequestAccountDeletion)
+	settings.DELETE("/deletion", h.Auth.CancelAccountDeletion)
 	// ============================================================
 	// /v1/stocks endpoints
 	// ============================================================
-	stocks := v1.Group("/stocks")
+	stocks := v1.Group("/stocks", h.Workspace.New)
 	stocks.GET("", h.Stocks.GetAllStocks)
 	stocks.PUT("/holdings", h.Stocks.UpdateStockHolding)
 	stocks.GET("/holdings", h.Stocks.GetAllStockHoldings)
 	stocks.DELETE("/holdings", h.Stocks.DeleteStockHolding)
 	stocks.POST("/holdings", h.Stocks.CreateNewStockHolding)
+	// ============================================================
+	// /v1/workspaces endpoints
+	// ============================================================
+	workspaces := v1.Group("/workspaces")
+	workspaces.GET("", h.Workspaces.GetAllWorkspaces)
+	workspaces.POST("", h.Workspaces.CreateNewWorkspace)
+	workspaces.PUT("", h.Workspaces.UpdateWorkspace, h.Workspace.New, h.Workspace.RequireOwner)
+	workspaces.POST("/leave", h.Workspaces.LeaveWorkspace)
+	workspaces.GET("/members", h.Workspaces.GetAllWorkspaceMembers, h.Workspace.New)
+	workspaces.PUT("/members", h.Workspaces.UpdateWorkspaceMember, h.Workspace.New, h.Workspace.RequireOwner)
+	workspaces.DELETE("/members", h.Workspaces.DeleteWorkspaceMember, h.Workspace.New, h.Workspace.RequireOwner)
+	workspaces.GET("/invitations", h.Workspaces.GetAllWorkspaceInvitations, h.Workspace.New, h.Workspace.RequireOwner)
+	workspaces.POST("/invitations", h.Workspaces.CreateNewWorkspaceInvitation, h.Workspace.New, h.Workspace.RequireOwner, h.Verified.New)
+	workspaces.DELETE("/invitations", h.Workspaces.RevokeWorkspaceInvitation, h.Workspace.New, h.Workspace.RequireOwner)
+	workspaces.POST("/invitations/accept", h.Workspaces.AcceptWorkspaceInvitation, h.Verified.New)
 }
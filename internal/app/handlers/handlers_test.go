@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/service"
+	"go.uber.org/zap"
+)
+
+const testCurrencyId = "0b8e7d2c-1f4a-4c3e-9a6b-5d2f8e1c7a90"
+
+type testValidator struct {
+	validator *validator.Validate
+}
+
+func (tv *testValidator) Validate(i interface{}) error {
+	return tv.validator.Struct(i)
+}
+
+// Handlers wired the same way as Init, but backed by a single in-memory store instead of postgres
+type testHandlers struct {
+	repositories    *repository.Repositories
+	accounts        *AccountsHandler
+	cash            *CashHandler
+	creditAccounts  *CreditAccountsHandler
+	goals           *GoalsHandler
+	loanAccounts    *LoanAccountsHandler
+	reconciliations *ReconciliationsHandler
+	stocks          *StocksHandler
+	transactions    *TransactionsHandler
+	futurePayments  *FuturePaymentsHandler
+}
+
+func newTestHandlers() *testHandlers {
+	logger := zap.NewNop()
+	repositories := repository.NewMemoryRepositories(repository.NewMemoryStore())
+	ledger := service.NewLedgerService(repositories)
+
+	return &testHandlers{
+		repositories: repositories,
+		accounts: &AccountsHandler{
+			Logger:        logger,
+			Ledger:        ledger,
+			Accounts:      repositories.Accounts,
+			Clients:       repositories.Clients,
+			ExchangeRates: repositories.ExchangeRates,
+			Transactions:  repositories.Transactions,
+		},
+		cash: &CashHandler{Logger: logger, Cash: repositories.Cash},
+		creditAccounts: &CreditAccountsHandler{
+			Logger:         logger,
+			Accounts:       repositories.Accounts,
+			CreditAccounts: repositories.CreditAccounts,
+			Transactions:   repositories.Transactions,
+		},
+		goals: &GoalsHandler{
+			Logger:        logger,
+			Accounts:      repositories.Accounts,
+			ExchangeRates: repositories.ExchangeRates,
+			Goals:         repositories.Goals,
+		},
+		loanAccounts: &LoanAccountsHandler{Logger: logger, Ledger: ledger, LoanAccounts: repositories.LoanAccounts},
+		reconciliations: &ReconciliationsHandler{
+			Logger:          logger,
+			Ledger:          ledger,
+			Accounts:        repositories.Accounts,
+			Reconciliations: repositories.Reconciliations,
+		},
+		stocks:         &StocksHandler{Logger: logger, Accounts: repositories.Accounts, Stocks: repositories.Stocks},
+		transactions:   &TransactionsHandler{Logger: logger, Ledger: ledger, Transactions: repositories.Transactions},
+		futurePayments: &FuturePaymentsHandler{Logger: logger, Accounts: repositories.Accounts, FuturePayments: repositories.FuturePayments},
+	}
+}
+
+// Records of a workspace seeded through the repositories, one of every kind the handlers under test act on
+type testWorkspace struct {
+	clientId        string
+	workspaceId     string
+	savings         database.AccountSummary
+	cash            database.AccountSummary
+	brokerage       database.AccountSummary
+	loan            database.AccountSummary
+	credit          database.AccountSummary
+	cashId          string
+	stockHoldingId  string
+	transactionId   string
+	futurePaymentId string
+	goalId          string
+}
+
+func (th *testHandlers) seedWorkspace(t *testing.T, clientId string, workspaceId string) testWorkspace {
+	t.Helper()
+	ctx := context.Background()
+	repositories := th.repositories
+	store := repositories.Accounts.(*repository.MemoryAccountRepository).Store
+
+	workspace := testWorkspace{clientId: clientId, workspaceId: workspaceId}
+	accounts := map[string]*database.AccountSummary{
+		"savings":   &workspace.savings,
+		"cash":      &workspace.cash,
+		"brokerage": &workspace.brokerage,
+		"loan":      &workspace.loan,
+		"credit":    &workspace.credit,
+	}
+	for providerType, account := range accounts {
+		providerId := store.AddAssetProvider(database.AssetProvider{Name: providerType, Type: providerType})
+		_, createError := repositories.Accounts.CreateNewAccount(ctx, database.CreateNewAccountParams{
+			Name:            providerType,
+			ClientId:        clientId,
+			WorkspaceId:     workspaceId,
+			CurrencyId:      testCurrencyId,
+			AssetProviderId: providerId,
+		})
+		if createError != nil {
+			t.Fatalf("failed to create %s account. %s", providerType, createError.Error())
+		}
+		summaries, getSummariesError := repositories.Accounts.GetAllAccountSummaryByType(ctx, providerType, workspaceId)
+		if getSummariesError != nil || len(summaries) != 1 {
+			t.Fatalf("failed to get %s account. %v", providerType, getSummariesError)
+		}
+		*account = summaries[0]
+	}
+	if _, updateError := repositories.Accounts.UpdateAccountBalance(ctx, "100", workspace.savings.Id); updateError != nil {
+		t.Fatalf("failed to set savings account balance. %s", updateError.Error())
+	}
+
+	if _, upsertError := repositories.Ledger.UpsertLoanAccount(ctx, database.UpsertLoanAccountParams{
+		AccountId:    workspace.loan.Id,
+		Principal:    "1000",
+		AnnualRate:   "5",
+		TermInMonths: 12,
+		StartDate:    time.Now().AddDate(0, -1, 0),
+	}, nil); upsertError != nil {
+		t.Fatalf("failed to create loan account. %s", upsertError.Error())
+	}
+	if _, upsertError := repositories.CreditAccounts.UpsertCreditAccount(ctx, database.UpsertCreditAccountParams{
+		AccountId:    workspace.credit.Id,
+		CreditLimit:  "500",
+		StatementDay: 1,
+		DueDay:       20,
+		Apr:          "20",
+	}); upsertError != nil {
+		t.Fatalf("failed to create credit account. %s", upsertError.Error())
+	}
+
+	if _, createError := repositories.Cash.CreateNewCashRecord(ctx, database.CreateNewCashRecordParams{
+		Amount:      "30",
+		ClientId:    clientId,
+		WorkspaceId: workspaceId,
+		CurrencyId:  testCurrencyId,
+	}); createError != nil {
+		t.Fatalf("failed to create cash record. %s", createError.Error())
+	}
+	cash, _ := repositories.Cash.GetAllCash(ctx, workspaceId)
+	workspace.cashId = cash[0].Id
+
+	stockId := store.AddStock(database.Stock{CurrencyId: testCurrencyId, Name: "Stock", Ticker: "STK", CurrentPrice: "10"})
+	if _, createError := repositories.Stocks.CreateNewStockHolding(ctx, database.CreateNewStockHoldingParams{
+		Unit:      "1",
+		Cost:      "10",
+		StockId:   stockId,
+		AccountId: workspace.brokerage.Id,
+	}); createError != nil {
+		t.Fatalf("failed to create stock holding. %s", createError.Error())
+	}
+	holdings, _ := repositories.Stocks.GetAllStockHoldings(ctx, workspaceId)
+	workspace.stockHoldingId = holdings[0].Id
+
+	if _, recordError := repositories.Ledger.RecordTransaction(ctx, database.CreateNewTransactionParams{
+		Name:        "Salary",
+		Income:      true,
+		Amount:      "50",
+		Category:    "income",
+		ClientId:    clientId,
+		WorkspaceId: workspaceId,
+		AccountId:   workspace.cash.Id,
+		CurrencyId:  testCurrencyId,
+		ExecutedAt:  time.Now(),
+	}); recordError != nil {
+		t.Fatalf("failed to record transaction. %s", recordError.Error())
+	}
+	transactions, _ := repositories.Transactions.GetAllTransactions(ctx, workspaceId)
+	workspace.transactionId = transactions[0].Id
+
+	if _, createError := repositories.FuturePayments.CreateNewFuturePayment(ctx, database.CreateNewFuturePaymentParams{
+		Name:        "Rent",
+		Amount:      "20",
+		Category:    "housing",
+		ClientId:    clientId,
+		WorkspaceId: workspaceId,
+		AccountId:   workspace.cash.Id,
+		CurrencyId:  testCurrencyId,
+		ScheduledAt: time.Now().AddDate(0, 1, 0),
+	}); createError != nil {
+		t.Fatalf("failed to create future payment. %s", createError.Error())
+	}
+	futurePayments, _ := repositories.FuturePayments.GetAllFuturePaymentsByWorkspaceId(ctx, workspaceId)
+	workspace.futurePaymentId = futurePayments[0].Id
+
+	if _, createError := repositories.Goals.CreateNewGoal(ctx, database.CreateNewGoalParams{
+		Name:         "Holiday",
+		ClientId:     clientId,
+		WorkspaceId:  workspaceId,
+		CurrencyId:   testCurrencyId,
+		TargetAmount: "1000",
+		Deadline:     time.Now().AddDate(1, 0, 0),
+		AccountIds:   []string{workspace.savings.Id},
+	}); createError != nil {
+		t.Fatalf("failed to create goal. %s", createError.Error())
+	}
+	goals, _ := repositories.Goals.GetAllGoalsByWorkspaceId(ctx, workspaceId)
+	workspace.goalId = goals[0].Id
+
+	return workspace
+}
+
+// Run a handler as the client of a workspace, the same as after authentication and workspace middlewares
+func serve(handler echo.HandlerFunc, workspace testWorkspace, method string, target string, body string) (*httptest.ResponseRecorder, error) {
+	e := echo.New()
+	e.Validator = &testValidator{validator: validator.New()}
+
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+	c := e.NewContext(request, recorder)
+	c.Set("uid", workspace.clientId)
+	c.Set("workspaceId", workspace.workspaceId)
+
+	return recorder, handler(c)
+}
+
+func assertStatus(t *testing.T, recorder *httptest.ResponseRecorder, err error, status int) {
+	t.Helper()
+	if err == nil {
+		if recorder.Code != status {
+			t.Fatalf("expected status %d, got %d with body %s", status, recorder.Code, recorder.Body.String())
+		}
+		return
+	}
+
+	var appError *apperror.Error
+	if !errors.As(err, &appError) {
+		t.Fatalf("expected status %d, got unexpected error %s", status, err.Error())
+	}
+	if appError.Status() != status {
+		t.Fatalf("expected status %d, got %d with error %s", status, appError.Status(), appError.Error())
+	}
+}
+
+func getAccount(t *testing.T, repositories *repository.Repositories, accountId string) database.AccountSummary {
+	t.Helper()
+	account, getAccountError := repositories.Accounts.GetAccountSummary(context.Background(), accountId)
+	if getAccountError != nil {
+		t.Fatalf("failed to get account %s. %s", accountId, getAccountError.Error())
+	}
+	return account
+}
+
+func TestTransferBetweenAccountsMovesBalance(t *testing.T) {
+	th := newTestHandlers()
+	workspace := th.seedWorkspace(t, "client", "workspace")
+
+	recorder, err := serve(th.accounts.TransferBetweenAccounts, workspace, http.MethodPost, "/v1/accounts/transfer",
+		`{"amount":"30.5","sourceAccountId":"`+workspace.savings.Id+`","targetAccountId":"`+workspace.cash.Id+`"}`)
+	assertStatus(t, recorder, err, http.StatusOK)
+
+	if balance := getAccount(t, th.repositories, workspace.savings.Id).Balance; balance != "69.5" {
+		t.Errorf("expected source balance 69.5, got %s", balance)
+	}
+	if balance := getAccount(t, th.repositories, workspace.cash.Id).Balance; balance != "80.5" {
+		t.Errorf("expected target balance 80.5, got %s", balance)
+	}
+	transactions, _ := th.repositories.Transactions.GetAllTransactions(context.Background(), workspace.workspaceId)
+	if len(transactions) != 3 {
+		t.Errorf("expected a transaction recorded on each side of transfer, got %d transactions", len(transactions))
+	}
+}
+
+func TestDeleteTransactionRevertsBalance(t *testing.T) {
+	th := newTestHandlers()
+	workspace := th.seedWorkspace(t, "client", "workspace")
+
+	recorder, err := serve(th.transactions.DeleteTransaction, workspace, http.MethodDelete, "/v1/transactions?id="+workspace.transactionId, "")
+	assertStatus(t, recorder, err, http.StatusOK)
+
+	if balance := getAccount(t, th.repositories, workspace.cash.Id).Balance; balance != "0" {
+		t.Errorf("expected balance 0 after reverting transaction, got %s", balance)
+	}
+}
+
+func TestCreateNewGoalFundsFromSourceAccount(t *testing.T) {
+	th := newTestHandlers()
+	workspace := th.seedWorkspace(t, "client", "workspace")
+	deadline := time.Now().AddDate(0, 6, 0).Unix()
+
+	// Source account has to be outside of the goal, otherwise contributions would not change its progress
+	requestBody := `{"name":"Car","currencyId":"` + testCurrencyId + `","targetAmount":"600","deadline":` + strconv.FormatInt(deadline, 10) +
+		`,"accountIds":["` + workspace.cash.Id + `"],"autoFund":"true","sourceAccountId":"` + workspace.cash.Id + `"}`
+	recorder, err := serve(th.goals.CreateNewGoal, workspace, http.MethodPost, "/v1/goals", requestBody)
+	assertStatus(t, recorder, err, http.StatusBadRequest)
+
+	requestBody = strings.Replace(requestBody, `"sourceAccountId":"`+workspace.cash.Id, `"sourceAccountId":"`+workspace.savings.Id, 1)
+	recorder, err = serve(th.goals.CreateNewGoal, workspace, http.MethodPost, "/v1/goals", requestBody)
+	assertStatus(t, recorder, err, http.StatusOK)
+
+	futurePayments, _ := th.repositories.FuturePayments.GetAllFuturePaymentsByWorkspaceId(context.Background(), workspace.workspaceId)
+	var funding *database.FuturePayment
+	for index := range futurePayments {
+		if futurePayments[index].SourceAccountId.Valid {
+			funding = &futurePayments[index]
+		}
+	}
+	if funding == nil {
+		t.Fatal("expected a future payment funding the goal")
+	}
+	if funding.AccountId != workspace.cash.Id || funding.SourceAccountId.String != workspace.savings.Id {
+		t.Errorf("expected funding from savings into cash account, got from %s into %s", funding.SourceAccountId.String, funding.AccountId)
+	}
+	if !funding.EndsAt.Valid || funding.EndsAt.Time.Unix() != deadline {
+		t.Errorf("expected funding to end at goal deadline %d, got %v", deadline, funding.EndsAt)
+	}
+}
+
+func TestDeleteGoalRemovesFunding(t *testing.T) {
+	th := newTestHandlers()
+	workspace := th.seedWorkspace(t, "client", "workspace")
+	ctx := context.Background()
+
+	if _, updateError := th.repositories.Goals.UpdateGoal(ctx, database.UpdateGoalParams{
+		Id:           workspace.goalId,
+		Name:         "Holiday",
+		ClientId:     workspace.clientId,
+		WorkspaceId:  workspace.workspaceId,
+		CurrencyId:   testCurrencyId,
+		TargetAmount: "1000",
+		Deadline:     time.Now().AddDate(1, 0, 0),
+		AccountIds:   []string{workspace.cash.Id},
+		Funding: &database.GoalFundingParams{
+			Name:            "Contribution to Holiday",
+			Amount:          "80",
+			Frequency:       goalContributionFrequency,
+			AccountId:       workspace.cash.Id,
+			SourceAccountId: workspace.savings.Id,
+			CurrencyId:      testCurrencyId,
+			ScheduledAt:     time.Now().AddDate(0, 1, 0),
+			EndsAt:          time.Now().AddDate(1, 0, 0),
+		},
+	}); updateError != nil {
+		t.Fatalf("failed to fund goal. %s", updateError.Error())
+	}
+
+	recorder, err := serve(th.goals.DeleteGoal, workspace, http.MethodDelete, "/v1/goals?id="+workspace.goalId, "")
+	assertStatus(t, recorder, err, http.StatusOK)
+
+	goals, _ := th.repositories.Goals.GetAllGoalsByWorkspaceId(ctx, workspace.workspaceId)
+	if len(goals) != 0 {
+		t.Errorf("expected goal to be deleted, got %d goals", len(goals))
+	}
+	futurePayments, _ := th.repositories.FuturePayments.GetAllFuturePaymentsByWorkspaceId(ctx, workspace.workspaceId)
+	if len(futurePayments) != 1 || futurePayments[0].Id != workspace.futurePaymentId {
+		t.Errorf("expected only the funding future payment to be deleted, got %d future payments", len(futurePayments))
+	}
+}
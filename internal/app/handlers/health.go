@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	Db     *pgxpool.Pool
+	Env    *config.Config
+	Logger *zap.Logger
+}
+
+// Liveness only tells the process is able to serve requests, dependencies are left to readiness
+func (hh *HealthHandler) GetLiveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+// Readiness tells whether the instance should receive traffic, i.e. database is reachable, migrations are applied and exchange rates are fresh
+func (hh *HealthHandler) GetReadiness(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, hh.Logger)
+	checks := LooseJson{"database": "ok", "migrations": "ok", "exchangeRates": "ok"}
+	isReady := true
+
+	if pingError := database.Ping(ctx, hh.Db); pingError != nil {
+		logger.Error(fmt.Sprintf("failed to ping database. %s", pingError.Error()))
+		// Nothing else can be checked without database
+		return c.JSON(
+			http.StatusServiceUnavailable,
+			LooseJson{"success": false, "data": LooseJson{"database": "unavailable", "migrations": "unknown", "exchangeRates": "unknown"}},
+		)
+	}
+
+	pendingMigrations, getPendingMigrationsError := database.GetAllPendingMigrations(ctx, hh.Db)
+	if getPendingMigrationsError != nil {
+		logger.Error(fmt.Sprintf("failed to get pending migrations from database. %s", getPendingMigrationsError.Error()))
+		checks["migrations"] = "unknown"
+		isReady = false
+	} else if len(pendingMigrations) > 0 {
+		logger.Error(fmt.Sprintf("database has %d pending migrations", len(pendingMigrations)))
+		checks["migrations"] = "pending"
+		isReady = false
+	}
+
+	lastUpdatedAt, getLastUpdatedAtError := database.GetLatestExchangeRateUpdate(ctx, hh.Db)
+	if getLastUpdatedAtError != nil {
+		logger.Error(fmt.Sprintf("failed to get latest exchange rate update from database. %s", getLastUpdatedAtError.Error()))
+		checks["exchangeRates"] = "unknown"
+		isReady = false
+	} else if hh.Env.ReadinessExchangeRateMaxAgeInHour > 0 {
+		maxAge := time.Duration(hh.Env.ReadinessExchangeRateMaxAgeInHour) * time.Hour
+		if !lastUpdatedAt.Valid || time.Since(lastUpdatedAt.Time) > maxAge {
+			logger.Error("exchange rates are stale")
+			checks["exchangeRates"] = "stale"
+			isReady = false
+		}
+	}
+
+	if !isReady {
+		return c.JSON(http.StatusServiceUnavailable, LooseJson{"success": false, "data": checks})
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": checks})
+}
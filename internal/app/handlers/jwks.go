@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"go.uber.org/zap"
+)
+
+type JwksHandler struct {
+	Logger     *zap.Logger
+	TokenUtils *utils.TokenUtils
+}
+
+// Publish public keys in JWKS format so that other services can verify tokens issued by us
+func (jh *JwksHandler) GetJwks(c echo.Context) error {
+	logger := requestLogger(c.Request().Context(), jh.Logger)
+	logger.Info("starts")
+
+	// Allow caching for a short while only so that rotated keys are picked up soon
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+
+	return c.JSON(http.StatusOK, LooseJson{"keys": jh.TokenUtils.KeyStore.GetJwks()})
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/service"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+type LoanAccountsHandler struct {
+	Logger       *zap.Logger
+	Ledger       *service.LedgerService
+	LoanAccounts repository.LoanAccountRepository
+}
+
+type LoanAccountRecord struct {
+	Id               string `json:"id"`
+	Name             string `json:"name"`
+	Balance          string `json:"balance"`
+	CurrencyId       string `json:"currencyId"`
+	Principal        string `json:"principal"`
+	AnnualRate       string `json:"annualRate"`
+	TermInMonths     int    `json:"termInMonths"`
+	StartDate        int64  `json:"startDate"`
+	Outstanding      string `json:"outstanding"`
+	MonthlyRepayment string `json:"monthlyRepayment"`
+	RemainingMonths  int    `json:"remainingMonths"`
+	NextRepaymentAt  int64  `json:"nextRepaymentAt"`
+}
+
+type AmortisationEntryRecord struct {
+	DueAt     int64  `json:"dueAt"`
+	Payment   string `json:"payment"`
+	Interest  string `json:"interest"`
+	Principal string `json:"principal"`
+	Balance   string `json:"balance"`
+}
+
+type LoanRepaymentRecord struct {
+	Id         string `json:"id"`
+	Amount     string `json:"amount"`
+	Interest   string `json:"interest"`
+	Principal  string `json:"principal"`
+	Extra      bool   `json:"extra"`
+	ExecutedAt int64  `json:"executedAt"`
+}
+
+type UpdateLoanAccountRequestBody struct {
+	AccountId    string `json:"accountId" validate:"required"`
+	Principal    string `json:"principal" validate:"required"`
+	AnnualRate   string `json:"annualRate" validate:"required"`
+	TermInMonths int    `json:"termInMonths" validate:"required,min=1"`
+	StartDate    int64  `json:"startDate" validate:"required"`
+}
+
+type CreateNewLoanRepaymentRequestBody struct {
+	AccountId  string `json:"accountId" validate:"required"`
+	Amount     string `json:"amount" validate:"required"`
+	Extra      string `json:"extra" validate:"required"`
+	ExecutedAt int64  `json:"executedAt" validate:"required"`
+}
+
+// Strategies on how the schedule is recomputed after extra repayments
+var LoanScheduleStrategies = []string{"reduce-term", "reduce-payment"}
+
+type loanAccountState struct {
+	outstanding     decimal.Decimal
+	annualRate      decimal.Decimal
+	originalPayment decimal.Decimal
+	remainingMonths int
+	nextRepaymentAt time.Time
+}
+
+// Outstanding amount of a loan account is the negated balance since the drawdown is deducted from balance
+func calculateLoanAccountState(details database.LoanAccountDetails, at time.Time) (loanAccountState, error) {
+	state := loanAccountState{}
+
+	balance, parseBalanceError := decimal.NewFromString(details.Balance)
+	if parseBalanceError != nil {
+		return state, parseBalanceError
+	}
+	principal, parsePrincipalError := decimal.NewFromString(details.Principal)
+	if parsePrincipalError != nil {
+		return state, parsePrincipalError
+	}
+	annualRate, parseAnnualRateError := decimal.NewFromString(details.AnnualRate)
+	if parseAnnualRateError != nil {
+		return state, parseAnnualRateError
+	}
+
+	elapsedRepayments := utils.CountElapsedRepayments(details.StartDate, at)
+	state.outstanding = decimal.Max(balance.Neg(), decimal.Zero)
+	state.annualRate = annualRate
+	state.originalPayment = utils.CalculateMonthlyRepayment(principal, annualRate, details.TermInMonths)
+	state.remainingMonths = details.TermInMonths - elapsedRepayments
+	if state.remainingMonths < 1 && state.outstanding.IsPositive() {
+		state.remainingMonths = 1
+	}
+	state.nextRepaymentAt = details.StartDate.AddDate(0, elapsedRepayments+1, 0)
+
+	return state, nil
+}
+
+func (lah *LoanAccountsHandler) findOwnedLoanAccount(ctx context.Context, workspaceId string, accountId string) (*database.LoanAccountDetails, error) {
+	loanAccounts, getLoanAccountsError := lah.LoanAccounts.GetAllLoanAccountsByWorkspaceId(ctx, workspaceId)
+	if getLoanAccountsError != nil {
+		return nil, getLoanAccountsError
+	}
+	for index := range loanAccounts {
+		if loanAccounts[index].Id == accountId {
+			return &loanAccounts[index], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (lah *LoanAccountsHandler) GetAllLoanAccounts(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, lah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Get all loan accounts with loan details from database
+	loanAccounts, getLoanAccountsError := lah.LoanAccounts.GetAllLoanAccountsByWorkspaceId(ctx, workspaceId)
+	if getLoanAccountsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all loan accounts from database. %s", getLoanAccountsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got loan accounts from database")
+
+	// Construct the response object
+	loanAccountRecords := []LoanAccountRecord{}
+	for _, loanAccount := range loanAccounts {
+		state, calculateStateError := calculateLoanAccountState(loanAccount, time.Now())
+		if calculateStateError != nil {
+			logger.Error(fmt.Sprintf("failed to calculate state of loan account %s. %s", loanAccount.Id, calculateStateError.Error()))
+			return apperror.Internal()
+		}
+		loanAccountRecords = append(loanAccountRecords, LoanAccountRecord{
+			Id:               loanAccount.Id,
+			Name:             loanAccount.Name,
+			Balance:          loanAccount.Balance,
+			CurrencyId:       loanAccount.CurrencyId,
+			Principal:        loanAccount.Principal,
+			AnnualRate:       loanAccount.AnnualRate,
+			TermInMonths:     loanAccount.TermInMonths,
+			StartDate:        loanAccount.StartDate.Unix(),
+			Outstanding:      state.outstanding.Truncate(2).String(),
+			MonthlyRepayment: state.originalPayment.String(),
+			RemainingMonths:  state.remainingMonths,
+			NextRepaymentAt:  state.nextRepaymentAt.Unix(),
+		})
+	}
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", loanAccountRecords))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": loanAccountRecords})
+}
+
+func (lah *LoanAccountsHandler) UpdateLoanAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, lah.Logger)
+	data := new(UpdateLoanAccountRequestBody)
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	// Create or update loan details of the account, booking the drawdown through the ledger for a new loan
+	updateError := lah.Ledger.UpdateLoanAccount(ctx, service.UpdateLoanAccountParams{
+		ClientId:     clientId,
+		WorkspaceId:  workspaceId,
+		AccountId:    data.AccountId,
+		Principal:    data.Principal,
+		AnnualRate:   data.AnnualRate,
+		TermInMonths: data.TermInMonths,
+		StartDate:    time.Unix(data.StartDate, 0).UTC(),
+	})
+	if updateError != nil {
+		return updateError
+	}
+	logger.Debug("updated loan account in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (lah *LoanAccountsHandler) GetLoanSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, lah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	accountId := c.QueryParam("id")
+	if len(accountId) == 0 {
+		logger.Error("undefined account id")
+		return apperror.InvalidArgument("Undefined account id.")
+	}
+	strategy := c.QueryParam("strategy")
+	if len(strategy) == 0 {
+		strategy = "reduce-term"
+	}
+	if !slices.Contains(LoanScheduleStrategies, strategy) {
+		logger.Error(fmt.Sprintf("invalid schedule strategy %s", strategy))
+		return apperror.InvalidArgument("Invalid schedule strategy.")
+	}
+
+	loanAccount, findLoanAccountError := lah.findOwnedLoanAccount(ctx, workspaceId, accountId)
+	if findLoanAccountError != nil {
+		logger.Error(fmt.Sprintf("failed to get all loan accounts from database. %s", findLoanAccountError.Error()))
+		return apperror.Internal()
+	}
+	if loanAccount == nil {
+		logger.Error(fmt.Sprintf("client does not own a loan account with id %s", accountId))
+		return apperror.NotFound("Loan account not found.")
+	}
+
+	state, calculateStateError := calculateLoanAccountState(*loanAccount, time.Now())
+	if calculateStateError != nil {
+		logger.Error(fmt.Sprintf("failed to calculate state of loan account %s. %s", loanAccount.Id, calculateStateError.Error()))
+		return apperror.Internal()
+	}
+
+	// Extra repayments either shorten the term with the original payment or lower the payment for the remaining term
+	payment := state.originalPayment
+	if strategy == "reduce-payment" {
+		payment = utils.CalculateMonthlyRepayment(state.outstanding, state.annualRate, state.remainingMonths)
+	}
+	schedule := utils.GenerateAmortisationSchedule(state.outstanding, state.annualRate, payment, state.nextRepaymentAt)
+	logger.Debug(fmt.Sprintf("generated amortisation schedule with %d repayments", len(schedule)))
+
+	// Construct the response object
+	scheduleRecords := []AmortisationEntryRecord{}
+	totalInterest := decimal.Zero
+	for _, entry := range schedule {
+		totalInterest = totalInterest.Add(entry.Interest)
+		scheduleRecords = append(scheduleRecords, AmortisationEntryRecord{
+			DueAt:     entry.DueAt.Unix(),
+			Payment:   entry.Payment.String(),
+			Interest:  entry.Interest.String(),
+			Principal: entry.Principal.String(),
+			Balance:   entry.Balance.String(),
+		})
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		LooseJson{"success": true, "data": LooseJson{"strategy": strategy, "totalInterest": totalInterest.String(), "schedule": scheduleRecords}},
+	)
+}
+
+func (lah *LoanAccountsHandler) GetAllLoanRepayments(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, lah.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	accountId := c.QueryParam("id")
+	if len(accountId) == 0 {
+		logger.Error("undefined account id")
+		return apperror.InvalidArgument("Undefined account id.")
+	}
+
+	loanAccount, findLoanAccountError := lah.findOwnedLoanAccount(ctx, workspaceId, accountId)
+	if findLoanAccountError != nil {
+		logger.Error(fmt.Sprintf("failed to get all loan accounts from database. %s", findLoanAccountError.Error()))
+		return apperror.Internal()
+	}
+	if loanAccount == nil {
+		logger.Error(fmt.Sprintf("client does not own a loan account with id %s", accountId))
+		return apperror.NotFound("Loan account not found.")
+	}
+
+	// Get all repayments of the loan from database
+	repayments, getRepaymentsError := lah.LoanAccounts.GetAllLoanRepayments(ctx, accountId)
+	if getRepaymentsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all loan repayments from database. %s", getRepaymentsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got loan repayments from database")
+
+	// Construct the response object
+	repaymentRecords := []LoanRepaymentRecord{}
+	for _, repayment := range repayments {
+		repaymentRecords = append(repaymentRecords, LoanRepaymentRecord{
+			Id:         repayment.Id,
+			Amount:     repayment.Amount,
+			Interest:   repayment.Interest,
+			Principal:  repayment.Principal,
+			Extra:      repayment.Extra,
+			ExecutedAt: repayment.ExecutedAt.Unix(),
+		})
+	}
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", repaymentRecords))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": repaymentRecords})
+}
+
+func (lah *LoanAccountsHandler) CreateNewLoanRepayment(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, lah.Logger)
+	data := new(CreateNewLoanRepaymentRequestBody)
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	extra, parseExtraError := strconv.ParseBool(data.Extra)
+	if parseExtraError != nil {
+		return apperror.InvalidField("extra", "format", "has an invalid value")
+	}
+	logger.Debug("validated request parameters")
+
+	// Record the repayment and update loan balance through the ledger
+	repayment, repayError := lah.Ledger.RepayLoan(ctx, service.RepayLoanParams{
+		ClientId:    clientId,
+		WorkspaceId: workspaceId,
+		AccountId:   data.AccountId,
+		Amount:      data.Amount,
+		Extra:       extra,
+		ExecutedAt:  time.Unix(data.ExecutedAt, 0).UTC(),
+	})
+	if repayError != nil {
+		return repayError
+	}
+	logger.Debug(fmt.Sprintf("created a new loan repayment, loan balance is now %s", repayment.Balance))
+
+	return c.JSON(
+		http.StatusOK,
+		LooseJson{"success": true, "data": LooseJson{"interest": repayment.Interest, "principal": repayment.Principal, "balance": repayment.Balance}},
+	)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+)
+
+const loginAttemptAuditLimit = 50
+
+// Bcrypt hash of a throwaway password, compared against when email is unknown so that response time does not reveal registered emails
+const timingEqualiserPasswordHash = "$2a$10$ZPNSt5mBpK4GwhUFU5tDCeYScdfe5NeoHmkIbWUD25NMgnOkfuRre"
+
+type LoginAttemptRecord struct {
+	Id        string `json:"id"`
+	IpAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+func normaliseLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Calculate how long the caller has to wait before another login attempt is allowed, taking the longer of per-email and per-IP throttling
+func (ah *AuthHandler) checkLoginThrottle(c echo.Context, email string) (time.Duration, error) {
+	ctx := c.Request().Context()
+	now := time.Now()
+	since := now.Add(-time.Minute * time.Duration(ah.Env.LoginAttemptWindowInMinute))
+	lockout := time.Minute * time.Duration(ah.Env.LoginLockoutInMinute)
+	backoffBase := time.Second * time.Duration(ah.Env.LoginBackoffBaseInSecond)
+
+	emailSummary, getEmailSummaryError := database.GetLoginFailureSummaryByEmail(ctx, ah.Db, normaliseLoginEmail(email), since)
+	if getEmailSummaryError != nil {
+		return 0, getEmailSummaryError
+	}
+	emailRetryAfter := utils.CalculateLoginRetryAfter(emailSummary.Failures, emailSummary.LastFailureAt, now, utils.LoginThrottlePolicy{
+		BackoffThreshold: ah.Env.LoginBackoffThreshold,
+		BackoffBase:      backoffBase,
+		LockoutThreshold: ah.Env.LoginLockoutThreshold,
+		Lockout:          lockout,
+	})
+
+	ipSummary, getIpSummaryError := database.GetLoginFailureSummaryByIpAddress(ctx, ah.Db, c.RealIP(), since)
+	if getIpSummaryError != nil {
+		return 0, getIpSummaryError
+	}
+	ipRetryAfter := utils.CalculateLoginRetryAfter(ipSummary.Failures, ipSummary.LastFailureAt, now, utils.LoginThrottlePolicy{
+		BackoffThreshold: ah.Env.LoginIpBackoffThreshold,
+		BackoffBase:      backoffBase,
+		LockoutThreshold: ah.Env.LoginIpLockoutThreshold,
+		Lockout:          lockout,
+	})
+
+	if ipRetryAfter > emailRetryAfter {
+		return ipRetryAfter, nil
+	}
+	return emailRetryAfter, nil
+}
+
+func (ah *AuthHandler) respondLoginThrottled(c echo.Context, retryAfter time.Duration) error {
+	c.Response().Header().Set("Retry-After", fmt.Sprintf("%d", int64(math.Ceil(retryAfter.Seconds()))))
+	return apperror.RateLimited("Too many login attempts. Try again later.")
+}
+
+// Record a login attempt for throttling and auditing, failing to do so should not block the login itself
+func (ah *AuthHandler) recordLoginAttempt(c echo.Context, email string, clientId *string, success bool) {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	_, createError := database.CreateNewLoginAttempt(ctx, ah.Db, database.CreateNewLoginAttemptParams{
+		ClientId:  clientId,
+		Email:     normaliseLoginEmail(email),
+		IpAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		Success:   success,
+	})
+	if createError != nil {
+		logger.Error(fmt.Sprintf("failed to record login attempt in database. %s", createError.Error()))
+	}
+}
+
+func (ah *AuthHandler) GetAllFailedLoginAttempts(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	clientId := c.Get("uid").(string)
+	logger.Info("starts")
+
+	loginAttempts, getLoginAttemptsError := database.GetAllFailedLoginAttemptsByClientId(ctx, ah.Db, clientId, loginAttemptAuditLimit)
+	if getLoginAttemptsError != nil {
+		logger.Error(fmt.Sprintf("failed to get failed login attempts from database. %s", getLoginAttemptsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got failed login attempts from database")
+
+	loginAttemptRecords := []LoginAttemptRecord{}
+	for _, loginAttempt := range loginAttempts {
+		loginAttemptRecords = append(loginAttemptRecords, LoginAttemptRecord{
+			Id:        loginAttempt.Id,
+			IpAddress: loginAttempt.IpAddress,
+			UserAgent: loginAttempt.UserAgent,
+			CreatedAt: loginAttempt.CreatedAt.Unix(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": loginAttemptRecords})
+}
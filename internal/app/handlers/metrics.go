@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/metrics"
+	"go.uber.org/zap"
+)
+
+type MetricsHandler struct {
+	Env    *config.Config
+	Logger *zap.Logger
+}
+
+// Expose Prometheus metrics to scrapers presenting the metrics token, which is not a client token as scrapers act for no client
+func (mh *MetricsHandler) GetMetrics(c echo.Context) error {
+	logger := requestLogger(c.Request().Context(), mh.Logger)
+
+	// Metrics are disabled without a token, as every scrape queries database and business figures are not meant to be public
+	if len(mh.Env.MetricsToken) == 0 {
+		return echo.ErrNotFound
+	}
+
+	bearerToken := strings.TrimSpace(strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer"))
+	if subtle.ConstantTimeCompare([]byte(bearerToken), []byte(mh.Env.MetricsToken)) != 1 {
+		logger.Error("invalid metrics token")
+		return apperror.Unauthenticated("Invalid or missing metrics token.")
+	}
+
+	metrics.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
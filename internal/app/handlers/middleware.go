@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"go.uber.org/zap"
+)
+
+// Header selecting the workspace a request operates on, the personal workspace of client is used when absent
+const workspaceHeader = "X-Workspace-Id"
+
+var workspaceIdRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type VerifiedClientMiddleware struct {
+	Db     *pgxpool.Pool
+	Logger *zap.Logger
+}
+
+// Reject requests from clients who have not verified their email address yet, used to gate sensitive operations
+func (vm *VerifiedClientMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		logger := requestLogger(ctx, vm.Logger)
+		clientId := c.Get("uid").(string)
+
+		client, getClientError := database.GetClientById(ctx, vm.Db, clientId)
+		if getClientError != nil {
+			logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+			return apperror.Internal()
+		}
+		if !client.Verified {
+			logger.Error(fmt.Sprintf("client %s has not verified email address", clientId))
+			return apperror.PermissionDenied("Email address not verified.")
+		}
+
+		return next(c)
+	}
+}
+
+type WorkspaceMiddleware struct {
+	Db     *pgxpool.Pool
+	Logger *zap.Logger
+}
+
+// Resolve the workspace of request and authorise client by its membership, viewers are only allowed to read
+func (wm *WorkspaceMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		logger := requestLogger(ctx, wm.Logger)
+		clientId := c.Get("uid").(string)
+
+		var member database.WorkspaceMember
+		var getMemberError error
+		if workspaceId := c.Request().Header.Get(workspaceHeader); len(workspaceId) > 0 {
+			if !workspaceIdRegex.MatchString(workspaceId) {
+				logger.Error(fmt.Sprintf("malformed workspace id %s", workspaceId))
+				return apperror.NotFound("Workspace not found.")
+			}
+			member, getMemberError = database.GetWorkspaceMember(ctx, wm.Db, workspaceId, clientId)
+		} else {
+			member, getMemberError = database.GetOrCreateDefaultWorkspaceMember(ctx, wm.Db, clientId)
+		}
+		if getMemberError != nil {
+			// Workspaces of others are indistinguishable from ones that do not exist
+			if errors.Is(getMemberError, pgx.ErrNoRows) {
+				logger.Error(fmt.Sprintf("client %s is not a member of requested workspace", clientId))
+				return apperror.NotFound("Workspace not found.")
+			}
+			logger.Error(fmt.Sprintf("failed to get workspace member from database. %s", getMemberError.Error()))
+			return apperror.Internal()
+		}
+
+		method := c.Request().Method
+		if method != http.MethodGet && method != http.MethodHead && !utils.CanWriteWorkspace(member.Role) {
+			logger.Error(fmt.Sprintf("client %s with role %s cannot modify workspace %s", clientId, member.Role, member.WorkspaceId))
+			return apperror.PermissionDenied("Insufficient workspace role.")
+		}
+
+		c.Set("workspaceId", member.WorkspaceId)
+		c.Set("workspaceRole", member.Role)
+
+		return next(c)
+	}
+}
+
+// Only let owners of the workspace resolved by New through, which must run before this one
+func (wm *WorkspaceMiddleware) RequireOwner(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		logger := requestLogger(c.Request().Context(), wm.Logger)
+		if c.Get("workspaceRole").(string) != "owner" {
+			logger.Error(fmt.Sprintf("client %s is not an owner of workspace %s", c.Get("uid").(string), c.Get("workspaceId").(string)))
+			return apperror.PermissionDenied("Insufficient workspace role.")
+		}
+
+		return next(c)
+	}
+}
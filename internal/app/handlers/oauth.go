@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/oauth"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const oauthStateCookieName = "oauth_state"
+
+var (
+	errOauthEmailNotVerified  = errors.New("email of identity is not verified by provider")
+	errOauthClientNotVerified = errors.New("existing client with the same email is not verified")
+)
+
+func (ah *AuthHandler) getOauthRedirectUri(provider string) string {
+	return fmt.Sprintf("%s/v1/auth/oauth/%s/callback", strings.TrimSuffix(ah.Env.OauthCallbackBaseUrl, "/"), provider)
+}
+
+// Send the browser back to frontend with the outcome of oauth login in URL fragment, which never reaches any server logs
+func (ah *AuthHandler) redirectOauthResult(c echo.Context, result url.Values) error {
+	return c.Redirect(http.StatusFound, fmt.Sprintf("%s/oauth/callback#%s", strings.TrimSuffix(ah.Env.AppUrl, "/"), result.Encode()))
+}
+
+func (ah *AuthHandler) redirectOauthError(c echo.Context, reason string) error {
+	return ah.redirectOauthResult(c, url.Values{"error": []string{reason}})
+}
+
+// Find the client owning the identity, linking to an existing client by verified email or creating a new client on first login
+func (ah *AuthHandler) resolveOauthClient(ctx context.Context, provider string, identity oauth.Identity) (string, error) {
+	logger := requestLogger(ctx, ah.Logger)
+	oauthIdentity, getIdentityError := database.GetOauthIdentity(ctx, ah.Db, provider, identity.Subject)
+	if getIdentityError == nil {
+		return oauthIdentity.ClientId, nil
+	}
+	if !errors.Is(getIdentityError, pgx.ErrNoRows) {
+		return "", getIdentityError
+	}
+
+	// Never link by an email the provider has not verified, otherwise anyone could take over an account by claiming its email
+	if len(identity.Email) == 0 || !identity.EmailVerified {
+		return "", errOauthEmailNotVerified
+	}
+	identityParams := database.CreateNewOauthIdentityParams{Provider: provider, Subject: identity.Subject, Email: identity.Email}
+
+	client, getClientError := database.GetClientByEmail(ctx, ah.Db, identity.Email)
+	if getClientError == nil {
+		// An unverified client may have been registered by someone else who knows its password, it must not be linked
+		if !client.Verified {
+			return "", errOauthClientNotVerified
+		}
+		identityParams.ClientId = client.Id
+		if _, createIdentityError := database.CreateNewOauthIdentity(ctx, ah.Db, identityParams); createIdentityError != nil {
+			return "", createIdentityError
+		}
+		logger.Info(fmt.Sprintf("linked %s identity to existing client %s", provider, client.Id))
+		return client.Id, nil
+	}
+	if !errors.Is(getClientError, pgx.ErrNoRows) {
+		return "", getClientError
+	}
+
+	defaultCurrencyId, getDefaultCurrencyIdError := database.GetDefaultCurrency(ctx, ah.Db)
+	if getDefaultCurrencyIdError != nil {
+		return "", getDefaultCurrencyIdError
+	}
+
+	// Client signing up through a provider has no password until it resets one
+	randomPassword, generatePasswordError := utils.GenerateRandomToken(32)
+	if generatePasswordError != nil {
+		return "", generatePasswordError
+	}
+	passwordHash, generatePasswordHashError := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if generatePasswordHashError != nil {
+		return "", generatePasswordHashError
+	}
+
+	username, _, _ := strings.Cut(identity.Email, "@")
+	if len(username) > 20 {
+		username = username[:20]
+	}
+
+	clientId, createClientError := database.CreateNewClientWithOauthIdentity(
+		ctx,
+		ah.Db,
+		database.CreateNewClientParams{
+			Email:      identity.Email,
+			Username:   username,
+			Password:   string(passwordHash),
+			CurrencyId: defaultCurrencyId,
+		},
+		identityParams,
+	)
+	if createClientError != nil {
+		return "", createClientError
+	}
+	logger.Info(fmt.Sprintf("created new client %s from %s identity", clientId, provider))
+
+	return clientId, nil
+}
+
+func (ah *AuthHandler) StartOauthLogin(c echo.Context) error {
+	logger := requestLogger(c.Request().Context(), ah.Logger)
+	logger.Info("starts")
+
+	providerName := c.Param("provider")
+	provider, isProviderFound := ah.OauthProviders[providerName]
+	if !isProviderFound {
+		logger.Error(fmt.Sprintf("oauth provider %s is not configured", providerName))
+		return apperror.NotFound("Provider not found.")
+	}
+
+	state, generateStateError := utils.GenerateRandomToken(16)
+	if generateStateError != nil {
+		logger.Error(fmt.Sprintf("failed to generate oauth state. %s", generateStateError.Error()))
+		return apperror.Internal()
+	}
+	nonce, generateNonceError := utils.GenerateRandomToken(16)
+	if generateNonceError != nil {
+		logger.Error(fmt.Sprintf("failed to generate oauth nonce. %s", generateNonceError.Error()))
+		return apperror.Internal()
+	}
+
+	authCodeUrl, buildUrlError := provider.AuthCodeUrl(state, nonce, ah.getOauthRedirectUri(providerName))
+	if buildUrlError != nil {
+		logger.Error(fmt.Sprintf("failed to build authorization url of %s. %s", providerName, buildUrlError.Error()))
+		return apperror.Unavailable("Provider unavailable.")
+	}
+
+	// Bind state and nonce to this browser so that the callback cannot be forged or replayed from elsewhere
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    fmt.Sprintf("%s.%s", state, nonce),
+		MaxAge:   600,
+		Path:     "/v1/auth/oauth",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode, // Cookie has to be sent along the top-level redirect back from provider
+	})
+	logger.Debug(fmt.Sprintf("redirecting to %s for oauth login", providerName))
+
+	return c.Redirect(http.StatusFound, authCodeUrl)
+}
+
+func (ah *AuthHandler) OauthCallback(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	providerName := c.Param("provider")
+	provider, isProviderFound := ah.OauthProviders[providerName]
+	if !isProviderFound {
+		logger.Error(fmt.Sprintf("oauth provider %s is not configured", providerName))
+		return apperror.NotFound("Provider not found.")
+	}
+
+	// Void state cookie as it is single use
+	stateCookie, getStateCookieError := c.Cookie(oauthStateCookieName)
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		Path:     "/v1/auth/oauth",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if getStateCookieError != nil {
+		logger.Error("oauth state cookie does not exist")
+		return ah.redirectOauthError(c, "invalid_state")
+	}
+	state, nonce, _ := strings.Cut(stateCookie.Value, ".")
+	if len(state) == 0 || c.QueryParam("state") != state {
+		logger.Error("oauth state does not match")
+		return ah.redirectOauthError(c, "invalid_state")
+	}
+
+	if providerError := c.QueryParam("error"); len(providerError) > 0 {
+		logger.Error(fmt.Sprintf("%s returned error %s", providerName, providerError))
+		return ah.redirectOauthError(c, "access_denied")
+	}
+
+	identity, exchangeError := provider.Exchange(c.QueryParam("code"), nonce, ah.getOauthRedirectUri(providerName))
+	if exchangeError != nil {
+		logger.Error(fmt.Sprintf("failed to exchange authorization code with %s. %s", providerName, exchangeError.Error()))
+		return ah.redirectOauthError(c, "exchange_failed")
+	}
+	logger.Debug(fmt.Sprintf("got identity %s from %s", identity.Subject, providerName))
+
+	clientId, resolveClientError := ah.resolveOauthClient(ctx, providerName, identity)
+	if resolveClientError != nil {
+		logger.Error(fmt.Sprintf("failed to resolve client of %s identity. %s", providerName, resolveClientError.Error()))
+		if errors.Is(resolveClientError, errOauthEmailNotVerified) {
+			return ah.redirectOauthError(c, "email_not_verified")
+		}
+		if errors.Is(resolveClientError, errOauthClientNotVerified) {
+			return ah.redirectOauthError(c, "account_not_verified")
+		}
+		return ah.redirectOauthError(c, "server_error")
+	}
+
+	// Clients with 2FA enabled still need to pass the second login step
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, clientId)
+	if getClientTotpError != nil && !errors.Is(getClientTotpError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return ah.redirectOauthError(c, "server_error")
+	}
+	if getClientTotpError == nil && clientTotp.ConfirmedAt.Valid {
+		challengeToken, generateChallengeTokenError := ah.TokenUtils.GenerateToken(clientId, 2)
+		if generateChallengeTokenError != nil {
+			logger.Error(fmt.Sprintf("challenge token generation failed. %s", generateChallengeTokenError.Error()))
+			return ah.redirectOauthError(c, "server_error")
+		}
+		logger.Debug("generated challenge token for second login step")
+
+		return ah.redirectOauthResult(c, url.Values{"challenge": []string{challengeToken}})
+	}
+
+	accessToken, refreshToken, startSessionError := ah.startSession(c, clientId)
+	if startSessionError != nil {
+		logger.Error(fmt.Sprintf("failed to start session. %s", startSessionError.Error()))
+		return ah.redirectOauthError(c, "server_error")
+	}
+	logger.Debug("started new session")
+	ah.recordLoginAttempt(c, identity.Email, &clientId, true)
+
+	return ah.redirectOauthResult(c, url.Values{"token": []string{accessToken}, "refresh": []string{refreshToken}})
+}
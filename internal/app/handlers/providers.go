@@ -6,6 +6,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
@@ -23,42 +24,31 @@ type ProviderDetailsRecord struct {
 	CountryId string `json:"countryid"`
 }
 
-var ProviderTypes = []string{"savings", "broker", "credit"}
+var ProviderTypes = []string{"savings", "broker", "credit", "loan"}
 
 func (ph *ProvidersHandler) GetAllProvidersByType(c echo.Context) error {
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	ph.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ph.Logger)
+	logger.Info("starts")
 
 	providerType := c.QueryParam("type")
 	if len(providerType) == 0 {
-		ph.Logger.Error("undefined provider type", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined provider type."},
-		)
+		logger.Error("undefined provider type")
+		return apperror.InvalidArgument("Undefined provider type.")
 	}
 	if !slices.Contains(ProviderTypes, providerType) {
-		ph.Logger.Error(fmt.Sprintf("invalid provider type %s", providerType), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid provider type."},
-		)
+		logger.Error(fmt.Sprintf("invalid provider type %s", providerType))
+		return apperror.InvalidArgument("Invalid provider type.")
 	}
-	ph.Logger.Info(fmt.Sprintf("going to get all providers by type %s", providerType), requestId)
+	logger.Info(fmt.Sprintf("going to get all providers by type %s", providerType))
 
 	// Get all provider details from database
-	providerDetails, getProviderDetailsError := database.GetAllProvidersByType(ph.Db, providerType)
+	providerDetails, getProviderDetailsError := database.GetAllProvidersByType(ctx, ph.Db, providerType)
 	if getProviderDetailsError != nil {
-		ph.Logger.Error(
-			fmt.Sprintf("failed to get provider details from database. %s", getProviderDetailsError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to get provider details from database. %s", getProviderDetailsError.Error()))
+		return apperror.Internal()
 	}
-	ph.Logger.Debug(fmt.Sprintf("got providers from database - %#v", providerDetails), requestId)
+	logger.Debug(fmt.Sprintf("got providers from database - %#v", providerDetails))
 
 	return c.JSON(
 		http.StatusOK,
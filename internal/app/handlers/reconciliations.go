@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/service"
+	"go.uber.org/zap"
+)
+
+type ReconciliationsHandler struct {
+	Logger          *zap.Logger
+	Ledger          *service.LedgerService
+	Accounts        repository.AccountRepository
+	Reconciliations repository.ReconciliationRepository
+}
+
+type ReconciliationRecord struct {
+	Id                      string  `json:"id"`
+	StatementBalance        string  `json:"statementBalance"`
+	RecordedBalance         string  `json:"recordedBalance"`
+	Difference              string  `json:"difference"`
+	Status                  string  `json:"status"`
+	StatementDate           int64   `json:"statementDate"`
+	AdjustmentTransactionId *string `json:"adjustmentTransactionId"`
+	CreatedAt               int64   `json:"createdAt"`
+}
+
+type UnmatchedTransactionRecord struct {
+	TransactionRecord
+	Suggested bool `json:"suggested"`
+}
+
+type ReconcileAccountRequestBody struct {
+	AccountId        string `json:"accountId" validate:"required"`
+	StatementBalance string `json:"statementBalance" validate:"required"`
+	StatementDate    int64  `json:"statementDate" validate:"required"`
+	Adjust           string `json:"adjust" validate:"required"`
+}
+
+func (rh *ReconciliationsHandler) findOwnedAccount(ctx context.Context, workspaceId string, accountId string) (*database.AccountSummary, error) {
+	ownedAccounts, getOwnedAccountsError := rh.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspaceId)
+	if getOwnedAccountsError != nil {
+		return nil, getOwnedAccountsError
+	}
+	for index := range ownedAccounts {
+		if ownedAccounts[index].Id == accountId {
+			return &ownedAccounts[index], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (rh *ReconciliationsHandler) GetAllReconciliations(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, rh.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	accountId := c.QueryParam("id")
+	if len(accountId) == 0 {
+		logger.Error("undefined account id")
+		return apperror.InvalidArgument("Undefined account id.")
+	}
+
+	account, findAccountError := rh.findOwnedAccount(ctx, workspaceId, accountId)
+	if findAccountError != nil {
+		logger.Error(fmt.Sprintf("failed to get all owned accounts from database. %s", findAccountError.Error()))
+		return apperror.Internal()
+	}
+	if account == nil {
+		logger.Error(fmt.Sprintf("client does not own an account with id %s", accountId))
+		return apperror.NotFound("Account not found.")
+	}
+
+	// Get reconciliation history of the account from database
+	reconciliations, getReconciliationsError := rh.Reconciliations.GetAllReconciliations(ctx, accountId)
+	if getReconciliationsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all reconciliations from database. %s", getReconciliationsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got reconciliations from database")
+
+	// Construct the response object
+	reconciliationRecords := []ReconciliationRecord{}
+	for _, reconciliation := range reconciliations {
+		record := ReconciliationRecord{
+			Id:               reconciliation.Id,
+			StatementBalance: reconciliation.StatementBalance,
+			RecordedBalance:  reconciliation.RecordedBalance,
+			Difference:       reconciliation.Difference,
+			Status:           reconciliation.Status,
+			StatementDate:    reconciliation.StatementDate.Unix(),
+			CreatedAt:        reconciliation.CreatedAt.Unix(),
+		}
+		if reconciliation.AdjustmentTransactionId.Valid {
+			adjustmentTransactionId := reconciliation.AdjustmentTransactionId.String
+			record.AdjustmentTransactionId = &adjustmentTransactionId
+		}
+		reconciliationRecords = append(reconciliationRecords, record)
+	}
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", reconciliationRecords))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": reconciliationRecords})
+}
+
+func (rh *ReconciliationsHandler) ReconcileAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, rh.Logger)
+	data := new(ReconcileAccountRequestBody)
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
+
+	// Retrieve request body and validate with schema
+	if bindError := c.Bind(data); bindError != nil {
+		return apperror.Binding(bindError)
+	}
+
+	if validateError := c.Validate(data); validateError != nil {
+		return apperror.Validation(validateError)
+	}
+
+	adjust, parseAdjustError := strconv.ParseBool(data.Adjust)
+	if parseAdjustError != nil {
+		return apperror.InvalidField("adjust", "format", "has an invalid value")
+	}
+	logger.Debug("validated request parameters")
+
+	// Reconcile the account through the ledger, which books the difference as an adjustment if requested
+	reconciliation, reconcileError := rh.Ledger.Reconcile(ctx, service.ReconcileParams{
+		ClientId:         clientId,
+		WorkspaceId:      workspaceId,
+		AccountId:        data.AccountId,
+		StatementBalance: data.StatementBalance,
+		StatementDate:    time.Unix(data.StatementDate, 0),
+		Adjust:           adjust,
+	})
+	if reconcileError != nil {
+		return reconcileError
+	}
+	logger.Debug(fmt.Sprintf("created a new reconciliation with status %s in database", reconciliation.Status))
+
+	// Construct the response object
+	unmatchedTransactionRecords := []UnmatchedTransactionRecord{}
+	for _, unmatched := range reconciliation.Unmatched {
+		transaction := unmatched.Transaction
+		accountId := transaction.AccountId.String
+		unmatchedTransactionRecords = append(unmatchedTransactionRecords, UnmatchedTransactionRecord{
+			TransactionRecord: TransactionRecord{
+				Id:         transaction.Id,
+				Name:       transaction.Name,
+				Income:     transaction.Income,
+				Amount:     transaction.Amount,
+				Category:   transaction.Category,
+				CurrencyId: transaction.CurrencyId,
+				Remarks:    transaction.Remarks.String,
+				AccountId:  &accountId,
+				ExecutedAt: transaction.ExecutedAt.Unix(),
+			},
+			Suggested: unmatched.Suggested,
+		})
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		LooseJson{
+			"success": true,
+			"data": LooseJson{
+				"status":          reconciliation.Status,
+				"recordedBalance": reconciliation.RecordedBalance,
+				"difference":      reconciliation.Difference,
+				"unmatched":       unmatchedTransactionRecords,
+			},
+		},
+	)
+}
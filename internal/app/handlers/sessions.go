@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"go.uber.org/zap"
+)
+
+type SessionsHandler struct {
+	Db     *pgxpool.Pool
+	Logger *zap.Logger
+}
+
+type SessionRecord struct {
+	Id         string `json:"id"`
+	UserAgent  string `json:"userAgent"`
+	IpAddress  string `json:"ipAddress"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastUsedAt int64  `json:"lastUsedAt"`
+	ExpiresAt  int64  `json:"expiresAt"`
+}
+
+func (sh *SessionsHandler) GetAllSessions(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
+	clientId := c.Get("uid").(string)
+	logger.Info("starts")
+
+	// Get all active sessions from database
+	sessions, getSessionsError := database.GetAllActiveSessionsByClientId(ctx, sh.Db, clientId)
+	if getSessionsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all active sessions from database. %s", getSessionsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got active sessions from database")
+
+	// Construct the response object
+	sessionRecords := []SessionRecord{}
+	for _, session := range sessions {
+		sessionRecords = append(sessionRecords, SessionRecord{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IpAddress,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastUsedAt: session.LastUsedAt.Unix(),
+			ExpiresAt:  session.ExpiresAt.Unix(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": sessionRecords})
+}
+
+func (sh *SessionsHandler) RevokeSession(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
+	clientId := c.Get("uid").(string)
+	logger.Info("starts")
+
+	sessionId := c.QueryParam("id")
+	if len(sessionId) == 0 {
+		logger.Error("undefined session id")
+		return apperror.InvalidArgument("Undefined session id.")
+	}
+
+	// Revoke the session together with every session rotated from the same login
+	revoked, revokeError := database.RevokeSessionFamilyBySessionId(ctx, sh.Db, sessionId, clientId)
+	if revokeError != nil {
+		logger.Error(fmt.Sprintf("failed to revoke session in database. %s", revokeError.Error()))
+		return apperror.Internal()
+	}
+	if !revoked {
+		logger.Error(fmt.Sprintf("client does not own an active session with id %s", sessionId))
+		return apperror.NotFound("Session not found.")
+	}
+	logger.Debug("revoked session in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
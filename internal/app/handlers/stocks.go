@@ -1,21 +1,20 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/iancoleman/strcase"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
 	"go.uber.org/zap"
 )
 
 type StocksHandler struct {
-	Db     *pgxpool.Pool
-	Logger *zap.Logger
+	Logger   *zap.Logger
+	Accounts repository.AccountRepository
+	Stocks   repository.StockRepository
 }
 
 type StockRecord struct {
@@ -54,22 +53,17 @@ type UpdateStockHoldingRequestBody struct {
 }
 
 func (sh *StocksHandler) GetAllStocks(c echo.Context) error {
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	sh.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
+	logger.Info("starts")
 
 	// Get all stocks from database
-	stocks, getStocksError := database.GetAllStocks(sh.Db)
+	stocks, getStocksError := sh.Stocks.GetAllStocks(ctx)
 	if getStocksError != nil {
-		sh.Logger.Error(
-			fmt.Sprintf("failed to get stocks from database. %s", getStocksError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to get stocks from database. %s", getStocksError.Error()))
+		return apperror.Internal()
 	}
-	sh.Logger.Debug("got stocks from database", requestId)
+	logger.Debug("got stocks from database")
 
 	// Construct the response object
 	var stockRecords []StockRecord
@@ -83,29 +77,24 @@ func (sh *StocksHandler) GetAllStocks(c echo.Context) error {
 			CurrentPrice: stock.CurrentPrice,
 		})
 	}
-	sh.Logger.Debug(fmt.Sprintf("constructed response object - %#v", stockRecords), requestId)
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", stockRecords))
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": stockRecords})
 }
 
 func (sh *StocksHandler) GetAllStockHoldings(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	sh.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Get all stock holdings of user from database
-	accountStocks, getAccountStocksError := database.GetAllStockHoldings(sh.Db, clientId)
+	accountStocks, getAccountStocksError := sh.Stocks.GetAllStockHoldings(ctx, workspaceId)
 	if getAccountStocksError != nil {
-		sh.Logger.Error(
-			fmt.Sprintf("failed to get all stock holdings from database. %s", getAccountStocksError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to get all stock holdings from database. %s", getAccountStocksError.Error()))
+		return apperror.Internal()
 	}
-	sh.Logger.Debug("got stock holdings from database", requestId)
+	logger.Debug("got stock holdings from database")
 
 	// Construct the response object
 	accountStockHoldingsMap := make(map[string][]StockHolding)
@@ -120,43 +109,42 @@ func (sh *StocksHandler) GetAllStockHoldings(c echo.Context) error {
 			Holdings:  stockHoldings,
 		})
 	}
-	sh.Logger.Debug(fmt.Sprintf("constructed response object - %#v", responseData), requestId)
+	logger.Debug(fmt.Sprintf("constructed response object - %#v", responseData))
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": responseData})
 }
 
 func (sh *StocksHandler) CreateNewStockHolding(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
 	data := new(CreateNewStockHoldingRequestBody)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	sh.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		sh.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Check if workspace owns the account
+	isOwned, checkOwnershipError := sh.Accounts.CheckWorkspaceOwnership(ctx, workspaceId, data.AccountId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of account in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("account %s not found in workspace %s", data.AccountId, workspaceId))
+		return apperror.NotFound("Account not found.")
 	}
-	sh.Logger.Debug("validated request parameters", requestId)
 
 	// Create a new stock holding in database
-	_, createError := database.CreateNewStockHolding(
-		sh.Db,
+	_, createError := sh.Stocks.CreateNewStockHolding(
+		ctx,
 		database.CreateNewStockHoldingParams{
 			AccountId: data.AccountId,
 			StockId:   data.StockId,
@@ -165,52 +153,45 @@ func (sh *StocksHandler) CreateNewStockHolding(c echo.Context) error {
 		},
 	)
 	if createError != nil {
-		sh.Logger.Error(
-			fmt.Sprintf("failed to create new stock holding in database. %s", createError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to create new stock holding in database. %s", createError.Error()))
+		return apperror.Internal()
 	}
-	sh.Logger.Debug("created a new stock holding in database", requestId)
+	logger.Debug("created a new stock holding in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (sh *StocksHandler) UpdateStockHolding(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
 	data := new(UpdateStockHoldingRequestBody)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	sh.Logger.Info("starts", requestId)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	// Retrieve request body and validate with schema
 	if bindError := c.Bind(data); bindError != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Missing required fields"},
-		)
+		return apperror.Binding(bindError)
 	}
 
 	if validateError := c.Validate(data); validateError != nil {
-		var ve validator.ValidationErrors
-		if errors.As(validateError, &ve) {
-			return c.JSON(
-				http.StatusBadRequest,
-				LooseJson{"success": false, "error": fmt.Sprintf("Invalid field %s", strcase.ToLowerCamel(ve[0].Field()))},
-			)
-		}
-		sh.Logger.Error(fmt.Sprintf("invalid field. %s", validateError.Error()), requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Invalid field"},
-		)
+		return apperror.Validation(validateError)
+	}
+	logger.Debug("validated request parameters")
+
+	// Check if workspace owns the account
+	isOwned, checkOwnershipError := sh.Accounts.CheckWorkspaceOwnership(ctx, workspaceId, data.AccountId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of account in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
+	}
+	if !isOwned {
+		logger.Error(fmt.Sprintf("account %s not found in workspace %s", data.AccountId, workspaceId))
+		return apperror.NotFound("Account not found.")
 	}
-	sh.Logger.Debug("validated request parameters", requestId)
 
 	// Update account in database
-	_, updateError := database.UpdateStockHoldingCost(
-		sh.Db,
+	_, updateError := sh.Stocks.UpdateStockHoldingCost(
+		ctx,
 		database.UpdateStockHoldingCostParams{
 			Unit:      data.Unit,
 			Cost:      data.Cost,
@@ -219,80 +200,46 @@ func (sh *StocksHandler) UpdateStockHolding(c echo.Context) error {
 		},
 	)
 	if updateError != nil {
-		sh.Logger.Error(
-			fmt.Sprintf("failed to update stock holding in database. %s", updateError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to update stock holding in database. %s", updateError.Error()))
+		return apperror.Internal()
 	}
-	sh.Logger.Debug("updated stock holding in database", requestId)
+	logger.Debug("updated stock holding in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
 
 func (sh *StocksHandler) DeleteStockHolding(c echo.Context) error {
-	clientId := c.Get("uid").(string)
-	requestId := zap.String("requestId", c.Get("requestId").(string))
-	sh.Logger.Info("starts", requestId)
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, sh.Logger)
+	workspaceId := c.Get("workspaceId").(string)
+	logger.Info("starts")
 
 	accountStockId := c.QueryParam("id")
 	if len(accountStockId) == 0 {
-		sh.Logger.Error("undefined stock holding id", requestId)
-		return c.JSON(
-			http.StatusBadRequest,
-			LooseJson{"success": false, "error": "Undefined stock holding id."},
-		)
-	}
-	sh.Logger.Info(fmt.Sprintf("going to check if client owns the stock holding with id %s", accountStockId), requestId)
-
-	// Get all client owned stock holdings in database
-	ownedStockHoldings, getOwnedStockHoldingsError := database.GetAllStockHoldings(sh.Db, clientId)
-	if getOwnedStockHoldingsError != nil {
-		sh.Logger.Error(
-			fmt.Sprintf("failed to get all owned stock holdings from database. %s", getOwnedStockHoldingsError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error("undefined stock holding id")
+		return apperror.InvalidArgument("Undefined stock holding id.")
 	}
+	logger.Info(fmt.Sprintf("going to check if client owns the stock holding with id %s", accountStockId))
 
-	// Check if client owns the stock holding
-	isOwner := false
-	for _, stockHolding := range ownedStockHoldings {
-		if stockHolding.Id == accountStockId {
-			isOwner = true
-		}
+	// Check if workspace owns the stock holding
+	isOwned, checkOwnershipError := sh.Stocks.CheckStockHoldingWorkspaceOwnership(ctx, workspaceId, accountStockId)
+	if checkOwnershipError != nil {
+		logger.Error(fmt.Sprintf("failed to check ownership of stock holding in database. %s", checkOwnershipError.Error()))
+		return apperror.Internal()
 	}
-	if len(ownedStockHoldings) == 0 || !isOwner {
-		sh.Logger.Error(
-			fmt.Sprintf("client does not own the stock holding %s and not authorized to delete", accountStockId),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusUnauthorized,
-			LooseJson{"success": false, "error": "Unauthorized to delete stock holding."},
-		)
+	if !isOwned {
+		logger.Error(fmt.Sprintf("stock holding %s not found in workspace %s", accountStockId, workspaceId))
+		return apperror.NotFound("Stock holding not found.")
 	}
-	sh.Logger.Info(fmt.Sprintf("going to delete stock holding with id %s", accountStockId), requestId)
+	logger.Info(fmt.Sprintf("going to delete stock holding with id %s", accountStockId))
 
 	// Delete account in database
-	_, deleteError := database.DeleteStockHolding(sh.Db, accountStockId)
+	_, deleteError := sh.Stocks.DeleteStockHolding(ctx, accountStockId)
 	if deleteError != nil {
-		sh.Logger.Error(
-			fmt.Sprintf("failed to delete stock holding in database. %s", deleteError.Error()),
-			requestId,
-		)
-		return c.JSON(
-			http.StatusInternalServerError,
-			LooseJson{"success": false, "error": "Internal server error."},
-		)
+		logger.Error(fmt.Sprintf("failed to delete stock holding in database. %s", deleteError.Error()))
+		return apperror.Internal()
 	}
-	sh.Logger.Debug("deleted stock holding in database", requestId)
+	logger.Debug("deleted stock holding in database")
 
 	return c.JSON(http.StatusOK, LooseJson{"success": true})
 }
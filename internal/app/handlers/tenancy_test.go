@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+)
+
+// Every record of a workspace the handlers under test could change
+type workspaceSnapshot struct {
+	accounts         []database.AccountSummary
+	cash             []database.Cash
+	holdings         []database.AccountStock
+	transactions     []database.Transaction
+	futurePayments   []database.FuturePayment
+	goals            []database.Goal
+	goalAccounts     []database.GoalAccount
+	loanAccounts     []database.LoanAccountDetails
+	creditAccounts   []database.CreditAccountDetails
+	loanRepayments   []database.LoanRepayment
+	creditStatements []database.CreditStatement
+	reconciliations  []database.Reconciliation
+}
+
+func snapshotWorkspace(t *testing.T, repositories *repository.Repositories, workspaceId string) workspaceSnapshot {
+	t.Helper()
+	ctx := context.Background()
+
+	var snapshot workspaceSnapshot
+	var errs [9]error
+	snapshot.accounts, errs[0] = repositories.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, workspaceId)
+	snapshot.cash, errs[1] = repositories.Cash.GetAllCash(ctx, workspaceId)
+	snapshot.holdings, errs[2] = repositories.Stocks.GetAllStockHoldings(ctx, workspaceId)
+	snapshot.transactions, errs[3] = repositories.Transactions.GetAllTransactions(ctx, workspaceId)
+	snapshot.futurePayments, errs[4] = repositories.FuturePayments.GetAllFuturePaymentsByWorkspaceId(ctx, workspaceId)
+	snapshot.goals, errs[5] = repositories.Goals.GetAllGoalsByWorkspaceId(ctx, workspaceId)
+	snapshot.goalAccounts, errs[6] = repositories.Goals.GetAllGoalAccountsByWorkspaceId(ctx, workspaceId)
+	snapshot.loanAccounts, errs[7] = repositories.LoanAccounts.GetAllLoanAccountsByWorkspaceId(ctx, workspaceId)
+	snapshot.creditAccounts, errs[8] = repositories.CreditAccounts.GetAllCreditAccountsByWorkspaceId(ctx, workspaceId)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("failed to snapshot workspace %s. %s", workspaceId, err.Error())
+		}
+	}
+
+	// Records kept per account rather than per workspace
+	for _, account := range snapshot.accounts {
+		loanRepayments, getLoanRepaymentsError := repositories.LoanAccounts.GetAllLoanRepayments(ctx, account.Id)
+		creditStatements, getCreditStatementsError := repositories.CreditAccounts.GetAllCreditStatements(ctx, account.Id)
+		reconciliations, getReconciliationsError := repositories.Reconciliations.GetAllReconciliations(ctx, account.Id)
+		for _, err := range []error{getLoanRepaymentsError, getCreditStatementsError, getReconciliationsError} {
+			if err != nil {
+				t.Fatalf("failed to snapshot account %s. %s", account.Id, err.Error())
+			}
+		}
+		snapshot.loanRepayments = append(snapshot.loanRepayments, loanRepayments...)
+		snapshot.creditStatements = append(snapshot.creditStatements, creditStatements...)
+		snapshot.reconciliations = append(snapshot.reconciliations, reconciliations...)
+	}
+
+	return snapshot
+}
+
+// Ids of another workspace must be indistinguishable from ids which do not exist, and leave both workspaces untouched
+func TestCrossTenantAccessIsNotFound(t *testing.T) {
+	testCases := []struct {
+		name    string
+		handler func(th *testHandlers) echo.HandlerFunc
+		method  string
+		target  func(own testWorkspace, foreign testWorkspace) string
+		body    func(own testWorkspace, foreign testWorkspace) string
+	}{
+		{
+			name:    "update foreign account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.accounts.UpdateAccount },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"balance":"999","currencyId":"` + testCurrencyId + `","accountTypeId":"` + foreign.savings.AccountTypeId + `"}`
+			},
+		},
+		{
+			name:    "delete foreign stock holding",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.stocks.DeleteStockHolding },
+			method:  http.MethodDelete,
+			target: func(own testWorkspace, foreign testWorkspace) string {
+				return "/v1/stocks/holdings?id=" + foreign.stockHoldingId
+			},
+		},
+		{
+			name:    "transfer from foreign cash account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.accounts.TransferBetweenAccounts },
+			method:  http.MethodPost,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/transfer" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"amount":"10","sourceAccountId":"` + foreign.cash.Id + `","targetAccountId":"` + own.cash.Id + `"}`
+			},
+		},
+		{
+			name:    "transfer into foreign cash account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.accounts.TransferBetweenAccounts },
+			method:  http.MethodPost,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/transfer" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"amount":"10","sourceAccountId":"` + own.savings.Id + `","targetAccountId":"` + foreign.cash.Id + `"}`
+			},
+		},
+		{
+			name:    "delete foreign transaction",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.transactions.DeleteTransaction },
+			method:  http.MethodDelete,
+			target: func(own testWorkspace, foreign testWorkspace) string {
+				return "/v1/transactions?id=" + foreign.transactionId
+			},
+		},
+		{
+			name:    "update foreign future payment",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.futurePayments.UpdateFuturePayment },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/future-payments" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return futurePaymentRequestBody(foreign.futurePaymentId, own.cash.Id)
+			},
+		},
+		{
+			name:    "move future payment into foreign account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.futurePayments.UpdateFuturePayment },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/future-payments" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return futurePaymentRequestBody(own.futurePaymentId, foreign.cash.Id)
+			},
+		},
+		{
+			name:    "delete foreign goal",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.goals.DeleteGoal },
+			method:  http.MethodDelete,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/goals?id=" + foreign.goalId },
+		},
+		{
+			name:    "update foreign cash record",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.cash.UpdateCashRecord },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/cash" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"id":"` + foreign.cashId + `","amount":"999","currencyId":"` + testCurrencyId + `"}`
+			},
+		},
+		{
+			name:    "delete foreign cash record",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.cash.DeleteCash },
+			method:  http.MethodDelete,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/cash?id=" + foreign.cashId },
+		},
+		{
+			name:    "update foreign loan account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.loanAccounts.UpdateLoanAccount },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/loan" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"accountId":"` + foreign.loan.Id + `","principal":"999","annualRate":"1","termInMonths":12,"startDate":1700000000}`
+			},
+		},
+		{
+			name:    "repay foreign loan",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.loanAccounts.CreateNewLoanRepayment },
+			method:  http.MethodPost,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/loan/repayments" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"accountId":"` + foreign.loan.Id + `","amount":"10","extra":"true","executedAt":1700000000}`
+			},
+		},
+		{
+			name:    "get foreign loan schedule",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.loanAccounts.GetLoanSchedule },
+			method:  http.MethodGet,
+			target: func(own testWorkspace, foreign testWorkspace) string {
+				return "/v1/accounts/loan/schedule?id=" + foreign.loan.Id
+			},
+		},
+		{
+			name:    "get foreign loan repayments",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.loanAccounts.GetAllLoanRepayments },
+			method:  http.MethodGet,
+			target: func(own testWorkspace, foreign testWorkspace) string {
+				return "/v1/accounts/loan/repayments?id=" + foreign.loan.Id
+			},
+		},
+		{
+			name:    "update foreign credit account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.creditAccounts.UpdateCreditAccount },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/credit" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return creditAccountRequestBody(foreign.credit.Id, own.savings.Id)
+			},
+		},
+		{
+			name:    "repay credit account from foreign account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.creditAccounts.UpdateCreditAccount },
+			method:  http.MethodPut,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/credit" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return creditAccountRequestBody(own.credit.Id, foreign.savings.Id)
+			},
+		},
+		{
+			name:    "get foreign credit statements",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.creditAccounts.GetAllCreditStatements },
+			method:  http.MethodGet,
+			target: func(own testWorkspace, foreign testWorkspace) string {
+				return "/v1/accounts/credit/statements?id=" + foreign.credit.Id
+			},
+		},
+		{
+			name:    "reconcile foreign account",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.reconciliations.ReconcileAccount },
+			method:  http.MethodPost,
+			target:  func(own testWorkspace, foreign testWorkspace) string { return "/v1/accounts/reconcile" },
+			body: func(own testWorkspace, foreign testWorkspace) string {
+				return `{"accountId":"` + foreign.savings.Id + `","statementBalance":"999","statementDate":1700000000,"adjust":"true"}`
+			},
+		},
+		{
+			name:    "get foreign reconciliations",
+			handler: func(th *testHandlers) echo.HandlerFunc { return th.reconciliations.GetAllReconciliations },
+			method:  http.MethodGet,
+			target: func(own testWorkspace, foreign testWorkspace) string {
+				return "/v1/accounts/reconciliations?id=" + foreign.savings.Id
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			th := newTestHandlers()
+			own := th.seedWorkspace(t, "client", "workspace")
+			foreign := th.seedWorkspace(t, "other-client", "other-workspace")
+			ownBefore := snapshotWorkspace(t, th.repositories, own.workspaceId)
+			foreignBefore := snapshotWorkspace(t, th.repositories, foreign.workspaceId)
+
+			body := ""
+			if testCase.body != nil {
+				body = testCase.body(own, foreign)
+			}
+			recorder, err := serve(testCase.handler(th), own, testCase.method, testCase.target(own, foreign), body)
+			assertStatus(t, recorder, err, http.StatusNotFound)
+
+			if ownAfter := snapshotWorkspace(t, th.repositories, own.workspaceId); !reflect.DeepEqual(ownBefore, ownAfter) {
+				t.Errorf("expected own workspace to be unchanged, got %+v instead of %+v", ownAfter, ownBefore)
+			}
+			if foreignAfter := snapshotWorkspace(t, th.repositories, foreign.workspaceId); !reflect.DeepEqual(foreignBefore, foreignAfter) {
+				t.Errorf("expected foreign workspace to be unchanged, got %+v instead of %+v", foreignAfter, foreignBefore)
+			}
+		})
+	}
+}
+
+func futurePaymentRequestBody(futurePaymentId string, accountId string) string {
+	return strings.Join([]string{
+		`{"id":"` + futurePaymentId + `"`,
+		`"name":"Stolen"`,
+		`"income":"true"`,
+		`"amount":"999"`,
+		`"rolling":"false"`,
+		`"accountId":"` + accountId + `"`,
+		`"currencyId":"` + testCurrencyId + `"`,
+		`"scheduledAt":4102444800}`,
+	}, ",")
+}
+
+func creditAccountRequestBody(accountId string, repaymentAccountId string) string {
+	return strings.Join([]string{
+		`{"accountId":"` + accountId + `"`,
+		`"creditLimit":"999"`,
+		`"statementDay":1`,
+		`"dueDay":20`,
+		`"apr":"1"`,
+		`"repaymentAccountId":"` + repaymentAccountId + `"}`,
+	}, ",")
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorCodeRequestBody struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorLoginRequestBody struct {
+	Challenge string `json:"challenge" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+}
+
+// Verify either a TOTP code or an unused recovery code of client, consuming whichever is accepted so it cannot be replayed
+func (ah *AuthHandler) verifySecondFactor(ctx context.Context, clientTotp database.ClientTotp, code string) (bool, error) {
+	if step, isTotpCodeValid := utils.VerifyTotpCode(clientTotp.Secret, code, time.Now()); isTotpCodeValid {
+		return database.UseClientTotpStep(ctx, ah.Db, clientTotp.ClientId, step)
+	}
+
+	return database.UseRecoveryCode(ctx, ah.Db, clientTotp.ClientId, utils.HashToken(utils.NormaliseRecoveryCode(code)))
+}
+
+// Generate a new set of recovery codes together with their hashes for persisting
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes, generateError := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if generateError != nil {
+		return nil, nil, generateError
+	}
+
+	codeHashes := []string{}
+	for _, code := range codes {
+		codeHashes = append(codeHashes, utils.HashToken(code))
+	}
+
+	return codes, codeHashes, nil
+}
+
+// Bind and validate the request body containing a second factor code, returns the error to respond with if it is invalid
+func (ah *AuthHandler) bindTwoFactorCode(c echo.Context, data interface{}) error {
+	if invalidError := bindAndValidate(c, data); invalidError != nil {
+		return invalidError
+	}
+	requestLogger(c.Request().Context(), ah.Logger).Debug("validated request parameters")
+
+	return nil
+}
+
+// Get the enabled TOTP of client, returns the error to respond with if 2FA is not enabled or lookup fails
+func (ah *AuthHandler) getEnabledClientTotp(c echo.Context, clientId string) (database.ClientTotp, error) {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, clientId)
+	if getClientTotpError != nil && !errors.Is(getClientTotpError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return clientTotp, apperror.Internal()
+	}
+	if getClientTotpError != nil || !clientTotp.ConfirmedAt.Valid {
+		logger.Error("client has not enabled 2fa")
+		return clientTotp, apperror.InvalidArgument("Two-factor authentication not enabled.")
+	}
+
+	return clientTotp, nil
+}
+
+// Verify a second factor code of client with 2FA enabled, returns the error to respond with if it is invalid.
+// Guessing is throttled the same way as login so that a stolen access token cannot be used to brute force codes.
+func (ah *AuthHandler) verifyEnabledSecondFactor(c echo.Context, clientId string, code string) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	client, getClientError := database.GetClientById(ctx, ah.Db, clientId)
+	if getClientError != nil {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Internal()
+	}
+
+	retryAfter, checkThrottleError := ah.checkLoginThrottle(c, client.Email)
+	if checkThrottleError != nil {
+		logger.Error(fmt.Sprintf("failed to check login throttling. %s", checkThrottleError.Error()))
+		return apperror.Internal()
+	}
+	if retryAfter > 0 {
+		logger.Error(fmt.Sprintf("second factor verification throttled for another %s", retryAfter.String()))
+		return ah.respondLoginThrottled(c, retryAfter)
+	}
+
+	clientTotp, responseError := ah.getEnabledClientTotp(c, clientId)
+	if responseError != nil {
+		return responseError
+	}
+
+	isCodeValid, verifyError := ah.verifySecondFactor(ctx, clientTotp, code)
+	if verifyError != nil {
+		logger.Error(fmt.Sprintf("failed to verify second factor. %s", verifyError.Error()))
+		return apperror.Internal()
+	}
+	if !isCodeValid {
+		logger.Error("invalid second factor code")
+		ah.recordLoginAttempt(c, client.Email, &client.Id, false)
+		return apperror.InvalidArgument("Invalid code.")
+	}
+
+	return nil
+}
+
+func (ah *AuthHandler) GetTwoFactorStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	clientId := c.Get("uid").(string)
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, clientId)
+	if getClientTotpError != nil && !errors.Is(getClientTotpError, pgx.ErrNoRows) {
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return apperror.Internal()
+	}
+	if getClientTotpError != nil || !clientTotp.ConfirmedAt.Valid {
+		return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"enabled": false, "recoveryCodesRemaining": 0}})
+	}
+
+	recoveryCodesRemaining, countError := database.CountUnusedRecoveryCodes(ctx, ah.Db, clientId)
+	if countError != nil {
+		logger.Error(fmt.Sprintf("failed to count unused recovery codes in database. %s", countError.Error()))
+		return apperror.Internal()
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"enabled": true, "recoveryCodesRemaining": recoveryCodesRemaining}})
+}
+
+func (ah *AuthHandler) EnrolTwoFactor(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	logger.Info("starts")
+
+	clientId := c.Get("uid").(string)
+	client, getClientError := database.GetClientById(ctx, ah.Db, clientId)
+	if getClientError != nil {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Internal()
+	}
+
+	secret, generateSecretError := utils.GenerateTotpSecret()
+	if generateSecretError != nil {
+		logger.Error(fmt.Sprintf("failed to generate totp secret. %s", generateSecretError.Error()))
+		return apperror.Internal()
+	}
+
+	// Secret stays pending until client proves the authenticator app is set up with a valid code
+	isPending, upsertError := database.UpsertPendingClientTotp(ctx, ah.Db, clientId, secret)
+	if upsertError != nil {
+		logger.Error(fmt.Sprintf("failed to store pending totp secret in database. %s", upsertError.Error()))
+		return apperror.Internal()
+	}
+	if !isPending {
+		logger.Error("client has enabled 2fa already")
+		return apperror.Conflict("Two-factor authentication enabled already.")
+	}
+	logger.Debug("stored pending totp secret in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{
+		"secret": secret,
+		"uri":    utils.BuildTotpProvisioningUri(ah.Env.TotpIssuer, client.Email, secret),
+	}})
+}
+
+func (ah *AuthHandler) ConfirmTwoFactor(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(TwoFactorCodeRequestBody)
+	logger.Info("starts")
+
+	if responseError := ah.bindTwoFactorCode(c, data); responseError != nil {
+		return responseError
+	}
+
+	clientId := c.Get("uid").(string)
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, clientId)
+	if getClientTotpError != nil {
+		if errors.Is(getClientTotpError, pgx.ErrNoRows) {
+			logger.Error("client has not started 2fa enrolment")
+			return apperror.InvalidArgument("Two-factor authentication enrolment not started.")
+		}
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return apperror.Internal()
+	}
+	if clientTotp.ConfirmedAt.Valid {
+		logger.Error("client has enabled 2fa already")
+		return apperror.Conflict("Two-factor authentication enabled already.")
+	}
+
+	step, isCodeValid := utils.VerifyTotpCode(clientTotp.Secret, data.Code, time.Now())
+	if !isCodeValid {
+		logger.Error("invalid totp code for confirming enrolment")
+		return apperror.InvalidArgument("Invalid code.")
+	}
+
+	codes, codeHashes, generateCodesError := generateRecoveryCodes()
+	if generateCodesError != nil {
+		logger.Error(fmt.Sprintf("failed to generate recovery codes. %s", generateCodesError.Error()))
+		return apperror.Internal()
+	}
+
+	isConfirmed, confirmError := database.ConfirmClientTotp(ctx, ah.Db, clientId, step, codeHashes)
+	if confirmError != nil {
+		logger.Error(fmt.Sprintf("failed to confirm client totp in database. %s", confirmError.Error()))
+		return apperror.Internal()
+	}
+	if !isConfirmed {
+		logger.Error("client has enabled 2fa already")
+		return apperror.Conflict("Two-factor authentication enabled already.")
+	}
+	logger.Debug("enabled 2fa for client")
+
+	// Recovery codes are only shown once here as only their hashes are persisted
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"recoveryCodes": codes}})
+}
+
+func (ah *AuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(TwoFactorCodeRequestBody)
+	logger.Info("starts")
+
+	if responseError := ah.bindTwoFactorCode(c, data); responseError != nil {
+		return responseError
+	}
+
+	clientId := c.Get("uid").(string)
+	if responseError := ah.verifyEnabledSecondFactor(c, clientId, data.Code); responseError != nil {
+		return responseError
+	}
+
+	codes, codeHashes, generateCodesError := generateRecoveryCodes()
+	if generateCodesError != nil {
+		logger.Error(fmt.Sprintf("failed to generate recovery codes. %s", generateCodesError.Error()))
+		return apperror.Internal()
+	}
+
+	if _, regenerateError := database.RegenerateRecoveryCodes(ctx, ah.Db, clientId, codeHashes); regenerateError != nil {
+		logger.Error(fmt.Sprintf("failed to replace recovery codes in database. %s", regenerateError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("regenerated recovery codes")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"recoveryCodes": codes}})
+}
+
+func (ah *AuthHandler) DisableTwoFactor(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(TwoFactorCodeRequestBody)
+	logger.Info("starts")
+
+	if responseError := ah.bindTwoFactorCode(c, data); responseError != nil {
+		return responseError
+	}
+
+	clientId := c.Get("uid").(string)
+	if responseError := ah.verifyEnabledSecondFactor(c, clientId, data.Code); responseError != nil {
+		return responseError
+	}
+
+	if _, deleteError := database.DeleteClientTotp(ctx, ah.Db, clientId); deleteError != nil {
+		logger.Error(fmt.Sprintf("failed to delete client totp from database. %s", deleteError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("disabled 2fa for client")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+// Second step of login for clients with 2FA enabled, exchanging the challenge token and a valid code for the token pair
+func (ah *AuthHandler) LoginWithTwoFactor(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, ah.Logger)
+	data := new(TwoFactorLoginRequestBody)
+	logger.Info("starts")
+
+	if responseError := ah.bindTwoFactorCode(c, data); responseError != nil {
+		return responseError
+	}
+
+	isChallengeValid, clientId := ah.TokenUtils.VerifyToken(data.Challenge, 2)
+	if !isChallengeValid {
+		logger.Error("invalid challenge token")
+		return apperror.Unauthenticated("Invalid or expired challenge.")
+	}
+	logger.Debug("verified challenge token")
+
+	client, getClientError := database.GetClientById(ctx, ah.Db, clientId)
+	if getClientError != nil {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Unauthenticated("Invalid or expired challenge.")
+	}
+
+	// Guessing codes with a valid challenge token is throttled the same way as guessing passwords
+	retryAfter, checkThrottleError := ah.checkLoginThrottle(c, client.Email)
+	if checkThrottleError != nil {
+		logger.Error(fmt.Sprintf("failed to check login throttling. %s", checkThrottleError.Error()))
+		return apperror.Internal()
+	}
+	if retryAfter > 0 {
+		logger.Error(fmt.Sprintf("login throttled for another %s", retryAfter.String()))
+		return ah.respondLoginThrottled(c, retryAfter)
+	}
+	logger.Debug("checked login throttling")
+
+	clientTotp, getClientTotpError := database.GetClientTotp(ctx, ah.Db, clientId)
+	if getClientTotpError != nil {
+		logger.Error(fmt.Sprintf("failed to get client totp from database. %s", getClientTotpError.Error()))
+		return apperror.Unauthenticated("Invalid or expired challenge.")
+	}
+
+	isCodeValid, verifyError := ah.verifySecondFactor(ctx, clientTotp, data.Code)
+	if verifyError != nil {
+		logger.Error(fmt.Sprintf("failed to verify second factor. %s", verifyError.Error()))
+		return apperror.Internal()
+	}
+	if !isCodeValid {
+		logger.Error("invalid second factor code")
+		ah.recordLoginAttempt(c, client.Email, &client.Id, false)
+		return apperror.Unauthenticated("Invalid code.")
+	}
+	logger.Debug("verified second factor")
+
+	accessToken, refreshToken, startSessionError := ah.startSession(c, clientId)
+	if startSessionError != nil {
+		logger.Error(fmt.Sprintf("failed to start session. %s", startSessionError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("started new session")
+	ah.recordLoginAttempt(c, client.Email, &client.Id, true)
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"token": accessToken, "refresh": refreshToken}})
+}
@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/mailer"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"go.uber.org/zap"
+)
+
+type WorkspacesHandler struct {
+	Db         *pgxpool.Pool
+	Env        *config.Config
+	Logger     *zap.Logger
+	Mailer     mailer.Mailer
+	TokenUtils *utils.TokenUtils
+}
+
+type WorkspaceRecord struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type WorkspaceMemberRecord struct {
+	ClientId  string `json:"clientId"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type WorkspaceInvitationRecord struct {
+	Id        string `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	ExpiresAt int64  `json:"expiresAt"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type WorkspaceRequestBody struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type UpdateWorkspaceMemberRequestBody struct {
+	ClientId string `json:"clientId" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+}
+
+type CreateNewWorkspaceInvitationRequestBody struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required"`
+}
+
+type AcceptWorkspaceInvitationRequestBody struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (wh *WorkspacesHandler) GetAllWorkspaces(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	logger.Info("starts")
+
+	clientId := c.Get("uid").(string)
+	workspaces, getWorkspacesError := database.GetAllWorkspacesByClientId(ctx, wh.Db, clientId)
+	if getWorkspacesError != nil {
+		logger.Error(fmt.Sprintf("failed to get all workspaces from database. %s", getWorkspacesError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got workspaces from database")
+
+	workspaceRecords := []WorkspaceRecord{}
+	for _, workspace := range workspaces {
+		workspaceRecords = append(workspaceRecords, WorkspaceRecord{
+			Id:        workspace.Id,
+			Name:      workspace.Name,
+			Role:      workspace.Role,
+			CreatedAt: workspace.CreatedAt.Unix(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": workspaceRecords})
+}
+
+func (wh *WorkspacesHandler) CreateNewWorkspace(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	data := new(WorkspaceRequestBody)
+	logger.Info("starts")
+
+	if invalidError := bindAndValidate(c, data); invalidError != nil {
+		return invalidError
+	}
+	logger.Debug("validated request parameters")
+
+	clientId := c.Get("uid").(string)
+	workspaceId, createError := database.CreateNewWorkspace(ctx, wh.Db, data.Name, clientId)
+	if createError != nil {
+		logger.Error(fmt.Sprintf("failed to create new workspace in database. %s", createError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug(fmt.Sprintf("created new workspace %s in database", workspaceId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"id": workspaceId}})
+}
+
+func (wh *WorkspacesHandler) UpdateWorkspace(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	data := new(WorkspaceRequestBody)
+	logger.Info("starts")
+
+	if invalidError := bindAndValidate(c, data); invalidError != nil {
+		return invalidError
+	}
+	logger.Debug("validated request parameters")
+
+	workspaceId := c.Get("workspaceId").(string)
+	if _, updateError := database.UpdateWorkspaceName(ctx, wh.Db, workspaceId, data.Name); updateError != nil {
+		logger.Error(fmt.Sprintf("failed to update workspace in database. %s", updateError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("updated workspace in database")
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+// Leave a workspace, which does not go through workspace middleware so that viewers can leave as well
+func (wh *WorkspacesHandler) LeaveWorkspace(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	logger.Info("starts")
+
+	workspaceId := c.QueryParam("id")
+	if len(workspaceId) == 0 {
+		logger.Error("undefined workspace id")
+		return apperror.InvalidArgument("Undefined workspace id.")
+	}
+
+	clientId := c.Get("uid").(string)
+	isLeft, leaveError := database.DeleteWorkspaceMember(ctx, wh.Db, workspaceId, clientId)
+	if leaveError != nil {
+		if errors.Is(leaveError, database.ErrWorkspaceWithoutOwner) {
+			logger.Error(fmt.Sprintf("client is the last owner of workspace %s", workspaceId))
+			return apperror.Conflict("Transfer ownership before leaving workspace.")
+		}
+		logger.Error(fmt.Sprintf("failed to delete workspace member in database. %s", leaveError.Error()))
+		return apperror.Internal()
+	}
+	if !isLeft {
+		logger.Error(fmt.Sprintf("client is not a member of workspace %s", workspaceId))
+		return apperror.NotFound("Workspace not found.")
+	}
+	logger.Debug(fmt.Sprintf("client left workspace %s", workspaceId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (wh *WorkspacesHandler) GetAllWorkspaceMembers(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	logger.Info("starts")
+
+	workspaceId := c.Get("workspaceId").(string)
+	members, getMembersError := database.GetAllWorkspaceMembers(ctx, wh.Db, workspaceId)
+	if getMembersError != nil {
+		logger.Error(fmt.Sprintf("failed to get all workspace members from database. %s", getMembersError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got workspace members from database")
+
+	memberRecords := []WorkspaceMemberRecord{}
+	for _, member := range members {
+		memberRecords = append(memberRecords, WorkspaceMemberRecord{
+			ClientId:  member.ClientId,
+			Email:     member.Email,
+			Username:  member.Username,
+			Role:      member.Role,
+			CreatedAt: member.CreatedAt.Unix(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": memberRecords})
+}
+
+func (wh *WorkspacesHandler) UpdateWorkspaceMember(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	data := new(UpdateWorkspaceMemberRequestBody)
+	logger.Info("starts")
+
+	if invalidError := bindAndValidate(c, data); invalidError != nil {
+		return invalidError
+	}
+	if !utils.IsValidWorkspaceRole(data.Role) {
+		return apperror.InvalidField("role", "format", "has an invalid value")
+	}
+	logger.Debug("validated request parameters")
+
+	workspaceId := c.Get("workspaceId").(string)
+	isUpdated, updateError := database.UpdateWorkspaceMemberRole(ctx, wh.Db, workspaceId, data.ClientId, data.Role)
+	if updateError != nil {
+		if errors.Is(updateError, database.ErrWorkspaceWithoutOwner) {
+			logger.Error(fmt.Sprintf("cannot demote the last owner of workspace %s", workspaceId))
+			return apperror.Conflict("Workspace must have at least one owner.")
+		}
+		logger.Error(fmt.Sprintf("failed to update workspace member in database. %s", updateError.Error()))
+		return apperror.Internal()
+	}
+	if !isUpdated {
+		logger.Error(fmt.Sprintf("client %s is not a member of workspace %s", data.ClientId, workspaceId))
+		return apperror.NotFound("Member not found.")
+	}
+	logger.Debug(fmt.Sprintf("changed role of client %s to %s", data.ClientId, data.Role))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (wh *WorkspacesHandler) DeleteWorkspaceMember(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	logger.Info("starts")
+
+	memberId := c.QueryParam("clientId")
+	if len(memberId) == 0 {
+		logger.Error("undefined client id")
+		return apperror.InvalidArgument("Undefined client id.")
+	}
+
+	workspaceId := c.Get("workspaceId").(string)
+	isDeleted, deleteError := database.DeleteWorkspaceMember(ctx, wh.Db, workspaceId, memberId)
+	if deleteError != nil {
+		if errors.Is(deleteError, database.ErrWorkspaceWithoutOwner) {
+			logger.Error(fmt.Sprintf("cannot remove the last owner of workspace %s", workspaceId))
+			return apperror.Conflict("Workspace must have at least one owner.")
+		}
+		logger.Error(fmt.Sprintf("failed to delete workspace member in database. %s", deleteError.Error()))
+		return apperror.Internal()
+	}
+	if !isDeleted {
+		logger.Error(fmt.Sprintf("client %s is not a member of workspace %s", memberId, workspaceId))
+		return apperror.NotFound("Member not found.")
+	}
+	logger.Debug(fmt.Sprintf("removed client %s from workspace %s", memberId, workspaceId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (wh *WorkspacesHandler) GetAllWorkspaceInvitations(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	logger.Info("starts")
+
+	workspaceId := c.Get("workspaceId").(string)
+	invitations, getInvitationsError := database.GetAllPendingWorkspaceInvitations(ctx, wh.Db, workspaceId)
+	if getInvitationsError != nil {
+		logger.Error(fmt.Sprintf("failed to get all pending workspace invitations from database. %s", getInvitationsError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("got pending workspace invitations from database")
+
+	invitationRecords := []WorkspaceInvitationRecord{}
+	for _, invitation := range invitations {
+		invitationRecords = append(invitationRecords, WorkspaceInvitationRecord{
+			Id:        invitation.Id,
+			Email:     invitation.Email,
+			Role:      invitation.Role,
+			ExpiresAt: invitation.ExpiresAt.Unix(),
+			CreatedAt: invitation.CreatedAt.Unix(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": invitationRecords})
+}
+
+// Invite someone into workspace by mail, the link works for the invited email only
+func (wh *WorkspacesHandler) CreateNewWorkspaceInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	data := new(CreateNewWorkspaceInvitationRequestBody)
+	logger.Info("starts")
+
+	if invalidError := bindAndValidate(c, data); invalidError != nil {
+		return invalidError
+	}
+	if !utils.IsValidWorkspaceRole(data.Role) {
+		return apperror.InvalidField("role", "format", "has an invalid value")
+	}
+	logger.Debug("validated request parameters")
+
+	token, generateTokenError := wh.TokenUtils.GenerateOneTimeToken()
+	if generateTokenError != nil {
+		logger.Error(fmt.Sprintf("failed to generate invitation token. %s", generateTokenError.Error()))
+		return apperror.Internal()
+	}
+
+	clientId := c.Get("uid").(string)
+	workspaceId := c.Get("workspaceId").(string)
+	email := strings.ToLower(data.Email)
+	_, createError := database.CreateNewWorkspaceInvitation(ctx, wh.Db, database.CreateNewWorkspaceInvitationParams{
+		WorkspaceId: workspaceId,
+		Email:       email,
+		Role:        data.Role,
+		TokenHash:   utils.HashToken(token),
+		InvitedBy:   clientId,
+		ExpiresAt:   time.Now().Add(time.Hour * 24 * time.Duration(wh.Env.WorkspaceInvitationExpiryInDay)),
+	})
+	if createError != nil {
+		if errors.Is(createError, database.ErrWorkspaceAlreadyMember) {
+			logger.Error(fmt.Sprintf("invited email is a member of workspace %s already", workspaceId))
+			return apperror.Conflict("Already a member of workspace.")
+		}
+		logger.Error(fmt.Sprintf("failed to create workspace invitation in database. %s", createError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug("created workspace invitation in database")
+
+	sendMailError := wh.Mailer.Send(mailer.Mail{
+		To:      email,
+		Subject: "You have been invited to an Everytrack workspace",
+		Body: fmt.Sprintf(
+			"You have been invited to join a shared workspace on Everytrack as %s.\r\n\r\nAccept the invitation with the link below within %d days:\r\n%s/invitations?token=%s\r\n\r\nIf you were not expecting it, please ignore this mail.",
+			data.Role,
+			wh.Env.WorkspaceInvitationExpiryInDay,
+			wh.Env.AppUrl,
+			token,
+		),
+	})
+	if sendMailError != nil {
+		logger.Error(fmt.Sprintf("failed to send workspace invitation mail. %s", sendMailError.Error()))
+		return apperror.Internal()
+	}
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+func (wh *WorkspacesHandler) RevokeWorkspaceInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	logger.Info("starts")
+
+	invitationId := c.QueryParam("id")
+	if len(invitationId) == 0 {
+		logger.Error("undefined invitation id")
+		return apperror.InvalidArgument("Undefined invitation id.")
+	}
+
+	workspaceId := c.Get("workspaceId").(string)
+	isRevoked, revokeError := database.RevokeWorkspaceInvitation(ctx, wh.Db, invitationId, workspaceId)
+	if revokeError != nil {
+		logger.Error(fmt.Sprintf("failed to revoke workspace invitation in database. %s", revokeError.Error()))
+		return apperror.Internal()
+	}
+	if !isRevoked {
+		logger.Error(fmt.Sprintf("pending invitation %s not found in workspace %s", invitationId, workspaceId))
+		return apperror.NotFound("Invitation not found.")
+	}
+	logger.Debug(fmt.Sprintf("revoked workspace invitation %s", invitationId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true})
+}
+
+// Join the workspace of an invitation, which requires the verified email of client to match the invited one
+func (wh *WorkspacesHandler) AcceptWorkspaceInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	logger := requestLogger(ctx, wh.Logger)
+	data := new(AcceptWorkspaceInvitationRequestBody)
+	logger.Info("starts")
+
+	if invalidError := bindAndValidate(c, data); invalidError != nil {
+		return invalidError
+	}
+	logger.Debug("validated request parameters")
+
+	// Reject forged token before touching database
+	if !wh.TokenUtils.VerifyOneTimeToken(data.Token) {
+		logger.Error("invalid signature of invitation token")
+		return apperror.NotFound("Invitation not found.")
+	}
+
+	clientId := c.Get("uid").(string)
+	client, getClientError := database.GetClientById(ctx, wh.Db, clientId)
+	if getClientError != nil {
+		logger.Error(fmt.Sprintf("failed to get client from database. %s", getClientError.Error()))
+		return apperror.Internal()
+	}
+
+	workspaceId, acceptError := database.AcceptWorkspaceInvitation(ctx, wh.Db, utils.HashToken(data.Token), clientId, client.Email)
+	if acceptError != nil {
+		if errors.Is(acceptError, database.ErrWorkspaceInviteNotFound) {
+			logger.Error("workspace invitation does not exist, expired, used already or addressed to another email")
+			return apperror.NotFound("Invitation not found.")
+		}
+		logger.Error(fmt.Sprintf("failed to accept workspace invitation in database. %s", acceptError.Error()))
+		return apperror.Internal()
+	}
+	logger.Debug(fmt.Sprintf("client joined workspace %s", workspaceId))
+
+	return c.JSON(http.StatusOK, LooseJson{"success": true, "data": LooseJson{"id": workspaceId}})
+}
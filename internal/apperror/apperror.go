@@ -0,0 +1,122 @@
+package apperror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Machine-readable category of an error, stable across releases so that clients can branch on it
+type Code string
+
+const (
+	CodeInvalidArgument  Code = "invalid_argument"
+	CodeValidationFailed Code = "validation_failed"
+	CodeUnauthenticated  Code = "unauthenticated"
+	CodePermissionDenied Code = "permission_denied"
+	CodeNotFound         Code = "not_found"
+	CodeConflict         Code = "conflict"
+	// Raised by router and body binding rather than our own code
+	CodeMethodNotAllowed     Code = "method_not_allowed"
+	CodePayloadTooLarge      Code = "payload_too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeRateLimited          Code = "rate_limited"
+	CodeUnavailable          Code = "unavailable"
+	CodeInternal             Code = "internal"
+)
+
+// HTTP status code of every error code, anything unknown is treated as internal server error
+var statuses = map[Code]int{
+	CodeInvalidArgument:      http.StatusBadRequest,
+	CodeValidationFailed:     http.StatusBadRequest,
+	CodeUnauthenticated:      http.StatusUnauthorized,
+	CodePermissionDenied:     http.StatusForbidden,
+	CodeNotFound:             http.StatusNotFound,
+	CodeConflict:             http.StatusConflict,
+	CodeMethodNotAllowed:     http.StatusMethodNotAllowed,
+	CodePayloadTooLarge:      http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	CodeRateLimited:          http.StatusTooManyRequests,
+	CodeUnavailable:          http.StatusBadGateway,
+	CodeInternal:             http.StatusInternalServerError,
+}
+
+// Message of every internal error shown to clients, the actual cause is only logged
+const internalMessage = "Internal server error."
+
+// Error returned by services and handlers, rendered into response by the HTTP error handler of server.
+// Message is safe to show to clients except for internal errors, whose message only describes the failed step for logging.
+type Error struct {
+	Code    Code
+	Message string
+	// Validation failure of every invalid field, only set for validation errors
+	Fields []FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s. %s", e.Message, e.Err.Error())
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Status() int {
+	if status, found := statuses[e.Code]; found {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message shown to clients, which hides the details of internal errors
+func (e *Error) PublicMessage() string {
+	if e.Status() >= http.StatusInternalServerError && e.Code != CodeUnavailable {
+		return internalMessage
+	}
+	return e.Message
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func InvalidArgument(message string) *Error {
+	return New(CodeInvalidArgument, message)
+}
+
+func Unauthenticated(message string) *Error {
+	return New(CodeUnauthenticated, message)
+}
+
+func PermissionDenied(message string) *Error {
+	return New(CodePermissionDenied, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func RateLimited(message string) *Error {
+	return New(CodeRateLimited, message)
+}
+
+func Unavailable(message string) *Error {
+	return New(CodeUnavailable, message)
+}
+
+// Internal error whose cause has been logged already by the caller
+func Internal() *Error {
+	return New(CodeInternal, internalMessage)
+}
+
+// Internal error carrying its cause, which is logged by the HTTP error handler
+func Wrap(message string, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
@@ -0,0 +1,86 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Failure of a single field in request, named the same way as in request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error of a request body which cannot be decoded, e.g. malformed JSON or a field of wrong type
+func Binding(err error) *Error {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && len(typeError.Field) > 0 {
+		field := typeError.Field
+		return &Error{
+			Code:    CodeValidationFailed,
+			Message: fmt.Sprintf("Invalid field %s.", field),
+			Fields:  []FieldError{{Field: field, Rule: "type", Param: typeError.Type.String(), Message: fmt.Sprintf("must be of type %s", typeError.Type.String())}},
+			Err:     err,
+		}
+	}
+	return &Error{Code: CodeInvalidArgument, Message: "Invalid request body.", Err: err}
+}
+
+// Error listing every field failing validation of request body, instead of only the first one
+func Validation(err error) *Error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return &Error{Code: CodeValidationFailed, Message: "Invalid field.", Err: err}
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	names := make([]string, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		field := fieldError.Field()
+		fields = append(fields, FieldError{Field: field, Rule: fieldError.Tag(), Param: fieldError.Param(), Message: ruleMessage(fieldError)})
+		names = append(names, field)
+	}
+
+	message := fmt.Sprintf("Invalid field %s.", names[0])
+	if len(names) > 1 {
+		message = fmt.Sprintf("Invalid fields %s.", strings.Join(names, ", "))
+	}
+	return &Error{Code: CodeValidationFailed, Message: message, Fields: fields}
+}
+
+// Invalid field which is checked by handler itself, e.g. a value failing to parse after passing validation
+func InvalidField(field string, rule string, message string) *Error {
+	return &Error{
+		Code:    CodeValidationFailed,
+		Message: fmt.Sprintf("Invalid field %s.", field),
+		Fields:  []FieldError{{Field: field, Rule: rule, Message: message}},
+	}
+}
+
+// Human readable description of the rule a field fails, in the form of "field <message>"
+func ruleMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.ReplaceAll(fieldError.Param(), " ", ", "))
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	case "len":
+		return fmt.Sprintf("must have length of %s", fieldError.Param())
+	case "uuid", "uuid4":
+		return "must be a valid uuid"
+	default:
+		return fmt.Sprintf("failed %s rule", fieldError.Tag())
+	}
+}
@@ -14,15 +14,97 @@ type Config struct {
 	WebServerHost   string   `env:"WEB_SERVER_HOST,notEmpty"`
 	WebServerPort   int16    `env:"WEB_SERVER_PORT,notEmpty"`
 	DomainWhitelist []string `env:"DOMAIN_WHITELIST,notEmpty"`
+	// Addresses / CIDR ranges of reverse proxies whose X-Forwarded-For is trusted, client IP is the peer address when empty
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	// Error responses are json / problem, where problem is application/problem+json of RFC 7807
+	ErrorResponseFormat string `env:"ERROR_RESPONSE_FORMAT" envDefault:"json"`
+	// Time given to in-flight requests and running jobs to finish after receiving SIGTERM / SIGINT
+	ShutdownTimeoutInSecond int `env:"SHUTDOWN_TIMEOUT_IN_SECOND" envDefault:"30"`
+	// Readiness fails when exchange rates are older than this, 0 disables the check
+	ReadinessExchangeRateMaxAgeInHour int `env:"READINESS_EXCHANGE_RATE_MAX_AGE_IN_HOUR" envDefault:"0"`
 	// Postgres
 	Database string `env:"DATABASE,notEmpty"`
+	// Connection pool of database, connections are recycled once they reach max lifetime or stay idle for too long
+	DatabaseMaxConns                int32 `env:"DATABASE_MAX_CONNS" envDefault:"10"`
+	DatabaseMinConns                int32 `env:"DATABASE_MIN_CONNS" envDefault:"0"`
+	DatabaseMaxConnLifetimeInMinute int   `env:"DATABASE_MAX_CONN_LIFETIME_IN_MINUTE" envDefault:"60"`
+	DatabaseMaxConnIdleTimeInMinute int   `env:"DATABASE_MAX_CONN_IDLE_TIME_IN_MINUTE" envDefault:"30"`
+	DatabaseConnectTimeoutInSecond  int   `env:"DATABASE_CONNECT_TIMEOUT_IN_SECOND" envDefault:"5"`
+	// Deadline of every query issued by database package, 0 means queries only end with their request or job
+	DatabaseQueryTimeoutInSecond int `env:"DATABASE_QUERY_TIMEOUT_IN_SECOND" envDefault:"10"`
+	// Apply pending migrations and seed reference data when web server starts
+	MigrateOnStartup bool `env:"MIGRATE_ON_STARTUP" envDefault:"true"`
 	// Authentication Token
 	AccessTokenExpiryInHour  int    `env:"ACCESS_TOKEN_EXPIRY_IN_HOUR,notEmpty"`
 	RefreshTokenExpiryInHour int    `env:"REFRESH_TOKEN_EXPIRY_IN_HOUR,notEmpty"`
 	RefreshTokenSecret       string `env:"REFRESH_TOKEN_SECRET,notEmpty"`
 	AccessTokenSecret        string `env:"ACCESS_TOKEN_SECRET,notEmpty"`
+	// JWT signing algorithm is one of HS256 / RS256 / EdDSA, asymmetric keys are stored in keys directory
+	JwtSigningAlgorithm  string `env:"JWT_SIGNING_ALGORITHM" envDefault:"HS256"`
+	JwtKeysDirectory     string `env:"JWT_KEYS_DIRECTORY" envDefault:"keys"`
+	JwtKeyRotationInHour int    `env:"JWT_KEY_ROTATION_IN_HOUR" envDefault:"720"`
+	// Tokens signed with HS256 secrets are still accepted in asymmetric mode until this time, never when empty
+	JwtHmacAcceptedUntil time.Time `env:"JWT_HMAC_ACCEPTED_UNTIL"`
+	// One-time Token for email verification and password reset
+	OneTimeTokenSecret               string `env:"ONE_TIME_TOKEN_SECRET,notEmpty"`
+	VerificationTokenExpiryInHour    int    `env:"VERIFICATION_TOKEN_EXPIRY_IN_HOUR" envDefault:"24"`
+	PasswordResetTokenExpiryInMinute int    `env:"PASSWORD_RESET_TOKEN_EXPIRY_IN_MINUTE" envDefault:"30"`
+	// Two-factor Authentication
+	TotpIssuer                       string `env:"TOTP_ISSUER" envDefault:"Everytrack"`
+	TwoFactorChallengeExpiryInMinute int    `env:"TWO_FACTOR_CHALLENGE_EXPIRY_IN_MINUTE" envDefault:"5"`
+	// Login Throttling
+	LoginAttemptWindowInMinute int `env:"LOGIN_ATTEMPT_WINDOW_IN_MINUTE" envDefault:"60"`
+	LoginBackoffThreshold      int `env:"LOGIN_BACKOFF_THRESHOLD" envDefault:"3"`
+	LoginBackoffBaseInSecond   int `env:"LOGIN_BACKOFF_BASE_IN_SECOND" envDefault:"2"`
+	LoginLockoutThreshold      int `env:"LOGIN_LOCKOUT_THRESHOLD" envDefault:"10"`
+	LoginLockoutInMinute       int `env:"LOGIN_LOCKOUT_IN_MINUTE" envDefault:"15"`
+	LoginIpBackoffThreshold    int `env:"LOGIN_IP_BACKOFF_THRESHOLD" envDefault:"20"`
+	LoginIpLockoutThreshold    int `env:"LOGIN_IP_LOCKOUT_THRESHOLD" envDefault:"100"`
+	// OAuth / OpenID Connect Login
+	OauthCallbackBaseUrl string `env:"OAUTH_CALLBACK_BASE_URL" envDefault:"http://localhost:3001"`
+	GoogleIssuer         string `env:"GOOGLE_ISSUER" envDefault:"https://accounts.google.com"`
+	GoogleClientId       string `env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret   string `env:"GOOGLE_CLIENT_SECRET"`
+	GithubBaseUrl        string `env:"GITHUB_BASE_URL" envDefault:"https://github.com"`
+	GithubApiUrl         string `env:"GITHUB_API_URL" envDefault:"https://api.github.com"`
+	GithubClientId       string `env:"GITHUB_CLIENT_ID"`
+	GithubClientSecret   string `env:"GITHUB_CLIENT_SECRET"`
+	OidcProviderName     string `env:"OIDC_PROVIDER_NAME" envDefault:"oidc"`
+	OidcIssuer           string `env:"OIDC_ISSUER"`
+	OidcClientId         string `env:"OIDC_CLIENT_ID"`
+	OidcClientSecret     string `env:"OIDC_CLIENT_SECRET"`
+	// Account Deletion
+	AccountDeletionGracePeriodInDay int `env:"ACCOUNT_DELETION_GRACE_PERIOD_IN_DAY" envDefault:"14"`
+	// Workspace
+	WorkspaceInvitationExpiryInDay int `env:"WORKSPACE_INVITATION_EXPIRY_IN_DAY" envDefault:"7"`
+	// Mailer is one of smtp / file / log, every mail fails when it is not set
+	Mailer              string `env:"MAILER"`
+	MailFrom            string `env:"MAIL_FROM" envDefault:"no-reply@everytrack.app"`
+	MailerFileDirectory string `env:"MAILER_FILE_DIRECTORY" envDefault:"tmp/mails"`
+	SmtpHost            string `env:"SMTP_HOST"`
+	SmtpPort            int    `env:"SMTP_PORT" envDefault:"587"`
+	SmtpUsername        string `env:"SMTP_USERNAME"`
+	SmtpPassword        string `env:"SMTP_PASSWORD"`
+	// Frontend
+	AppUrl string `env:"APP_URL" envDefault:"http://localhost:3000"`
 	// Logger
 	LogLevel string `env:"LOG_LEVEL,notEmpty"`
+	// Fields whose name ends with one of these are masked in logged request bodies, headers and messages
+	LogRedactFields  []string `env:"LOG_REDACT_FIELDS" envDefault:"password,token,secret,apikey,authorization,cookie,recoverycodes"`
+	LogRedactEmails  bool     `env:"LOG_REDACT_EMAILS" envDefault:"true"`
+	LogRedactAmounts bool     `env:"LOG_REDACT_AMOUNTS" envDefault:"false"`
+	// Request bodies are logged at debug level, body policy is one of redacted / omit and applies to routes not listed below
+	LogBodyPolicy     string   `env:"LOG_BODY_POLICY" envDefault:"redacted"`
+	LogBodyOmitRoutes []string `env:"LOG_BODY_OMIT_ROUTES"`
+	// Metrics
+	// Bearer token Prometheus has to present when scraping /metrics, which is disabled when empty
+	MetricsToken string `env:"METRICS_TOKEN"`
+	// Tracing exporter is one of none / stdout / otlp, trace ids are added to logs regardless
+	TracingExporter     string  `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingServiceName  string  `env:"TRACING_SERVICE_NAME" envDefault:"everytrack-backend"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	TracingOtlpEndpoint string  `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4318"`
+	TracingOtlpInsecure bool    `env:"TRACING_OTLP_INSECURE" envDefault:"true"`
 	// External API
 }
 
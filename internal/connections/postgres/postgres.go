@@ -7,18 +7,28 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
 )
 
-func New(connString string) *pgxpool.Pool {
+func New(env *config.Config) *pgxpool.Pool {
 	logTemplate := "{\"level\":\"%s\",\"timestamp\":\"%s\",\"function\":\"github.com/nighostchris/everytrack-backend/internal/connections/postgres.New\",\"message\":\"%s\"}\n"
 	fmt.Printf(logTemplate, "info", time.Now().Format(time.RFC3339Nano), "initializing database connection")
 
 	// https://pkg.go.dev/github.com/jackc/pgx/v5#ParseConfig
-	connConfig, parseConfigError := pgxpool.ParseConfig(connString)
+	connConfig, parseConfigError := pgxpool.ParseConfig(env.Database)
 	if parseConfigError != nil {
 		fmt.Printf(logTemplate, "error", time.Now().Format(time.RFC3339Nano), parseConfigError.Error())
 		os.Exit(1)
 	}
+	connConfig.MaxConns = env.DatabaseMaxConns
+	connConfig.MinConns = env.DatabaseMinConns
+	connConfig.MaxConnLifetime = time.Duration(env.DatabaseMaxConnLifetimeInMinute) * time.Minute
+	connConfig.MaxConnIdleTime = time.Duration(env.DatabaseMaxConnIdleTimeInMinute) * time.Minute
+	connConfig.ConnConfig.ConnectTimeout = time.Duration(env.DatabaseConnectTimeoutInSecond) * time.Second
+	connConfig.ConnConfig.Tracer = &tracing.PgxTracer{}
+	database.SetQueryTimeout(time.Duration(env.DatabaseQueryTimeoutInSecond) * time.Second)
 
 	// https://pkg.go.dev/github.com/jackc/pgx/v5/pgxpool#NewWithConfig
 	pool, initPoolError := pgxpool.NewWithConfig(context.Background(), connConfig)
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Error response formats, problem being application/problem+json of RFC 7807
+const (
+	ErrorFormatJson    = "json"
+	ErrorFormatProblem = "problem"
+)
+
+const mimeApplicationProblemJson = "application/problem+json"
+
+// Error codes of errors raised by echo itself, e.g. unknown routes
+var echoErrorCodes = map[int]apperror.Code{
+	http.StatusBadRequest:            apperror.CodeInvalidArgument,
+	http.StatusUnauthorized:          apperror.CodeUnauthenticated,
+	http.StatusForbidden:             apperror.CodePermissionDenied,
+	http.StatusNotFound:              apperror.CodeNotFound,
+	http.StatusMethodNotAllowed:      apperror.CodeMethodNotAllowed,
+	http.StatusRequestEntityTooLarge: apperror.CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  apperror.CodeUnsupportedMediaType,
+	http.StatusTooManyRequests:       apperror.CodeRateLimited,
+}
+
+// Render every error returned by handlers and middlewares into a response, so that all errors share the same shape
+type ErrorHandler struct {
+	Logger *zap.Logger
+	// Format of error responses, clients asking for problem+json in Accept header get it regardless
+	Format string
+}
+
+func (eh *ErrorHandler) Handle(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	appError := toAppError(err)
+	requestLogger := logger.FromContext(c.Request().Context(), eh.Logger)
+	if appError.Status() >= http.StatusInternalServerError {
+		requestLogger.Error(fmt.Sprintf("request failed with %s error. %s", appError.Code, appError.Error()))
+	} else {
+		requestLogger.Info(fmt.Sprintf("request rejected with %s error. %s", appError.Code, appError.Error()))
+	}
+
+	requestId, _ := c.Get("requestId").(string)
+	var responseError error
+	switch {
+	case c.Request().Method == http.MethodHead:
+		responseError = c.NoContent(appError.Status())
+	case eh.Format == ErrorFormatProblem || strings.Contains(c.Request().Header.Get(echo.HeaderAccept), mimeApplicationProblemJson):
+		c.Response().Header().Set(echo.HeaderContentType, mimeApplicationProblemJson)
+		problem := map[string]interface{}{
+			"type":      fmt.Sprintf("urn:everytrack:error:%s", appError.Code),
+			"title":     http.StatusText(appError.Status()),
+			"status":    appError.Status(),
+			"detail":    appError.PublicMessage(),
+			"instance":  c.Request().URL.Path,
+			"code":      appError.Code,
+			"requestId": requestId,
+		}
+		if len(appError.Fields) > 0 {
+			problem["errors"] = appError.Fields
+		}
+		responseError = c.JSON(appError.Status(), problem)
+	default:
+		body := map[string]interface{}{"success": false, "error": appError.PublicMessage(), "code": appError.Code, "requestId": requestId}
+		if len(appError.Fields) > 0 {
+			body["fields"] = appError.Fields
+		}
+		responseError = c.JSON(appError.Status(), body)
+	}
+
+	if responseError != nil {
+		requestLogger.Error(fmt.Sprintf("failed to write error response. %s", responseError.Error()))
+	}
+}
+
+// Errors other than the ones raised by our code or echo are unexpected, which are hidden from clients as internal errors
+func toAppError(err error) *apperror.Error {
+	var appError *apperror.Error
+	if errors.As(err, &appError) {
+		return appError
+	}
+
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		code, found := echoErrorCodes[httpError.Code]
+		if !found {
+			return apperror.Wrap("unexpected echo error", err)
+		}
+		// Status text in sentence case, e.g. Method not allowed.
+		text := http.StatusText(httpError.Code)
+		message := fmt.Sprintf("%s%s.", text[:1], strings.ToLower(text[1:]))
+		return &apperror.Error{Code: code, Message: message, Err: err}
+	}
+
+	return apperror.Wrap("unexpected error", err)
+}
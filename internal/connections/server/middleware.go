@@ -2,45 +2,119 @@ package server
 
 import (
 	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/nighostchris/everytrack-backend/internal/apperror"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/logger"
+	"github.com/nighostchris/everytrack-backend/internal/metrics"
+	"github.com/nighostchris/everytrack-backend/internal/redact"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
 	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
 )
 
 type AuthMiddleware struct {
+	Db         *pgxpool.Pool
 	Logger     *zap.Logger
 	TokenUtils *utils.TokenUtils
 }
 
+// Request ids of caller are only trusted if short and free of characters which could tamper with logs
+var requestIdRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Request body policies, redacted logs bodies with sensitive fields masked while omit never logs them
+const (
+	BodyPolicyRedacted = "redacted"
+	BodyPolicyOmit     = "omit"
+)
+
+// Routes whose bodies carry credentials or one-time codes, which are never logged whatever the policy is
+var bodyOmitRoutes = []string{
+	"/v1/auth/login",
+	"/v1/auth/login/2fa",
+	"/v1/auth/signup",
+	"/v1/auth/verify",
+	"/v1/auth/refresh",
+	"/v1/auth/password/forgot",
+	"/v1/auth/password/reset",
+	"/v1/auth/2fa/confirm",
+	"/v1/auth/2fa/disable",
+	"/v1/auth/2fa/recovery-codes",
+	"/v1/settings/deletion",
+}
+
 type LogMiddleware struct {
-	Logger *zap.Logger
+	Logger   *zap.Logger
+	Redactor *redact.Redactor
+	// Body policy of every route apart from the omitted ones
+	BodyPolicy     string
+	BodyOmitRoutes []string
 }
 
+type MetricsMiddleware struct{}
+
+type TracingMiddleware struct{}
+
 func (am *AuthMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		whitelistPaths := []string{"/", "/v1/auth/login", "/v1/auth/logout", "/v1/auth/refresh", "/v1/auth/signup"}
+		whitelistPaths := []string{
+			"/",
+			"/healthz",
+			"/readyz",
+			"/metrics",
+			"/.well-known/jwks.json",
+			"/v1/auth/login",
+			"/v1/auth/login/2fa",
+			"/v1/auth/logout",
+			"/v1/auth/refresh",
+			"/v1/auth/signup",
+			"/v1/auth/verify",
+			"/v1/auth/password/forgot",
+			"/v1/auth/password/reset",
+		}
+
+		// Paths carrying dynamic segments or query parameters, e.g. oauth callback
+		whitelistPrefixes := []string{"/v1/auth/oauth/"}
 
 		// Ignore authentication check if the request path is whitelisted
 		if slices.Contains(whitelistPaths, c.Request().RequestURI) {
-			next(c)
-			return nil
+			return next(c)
+		}
+		for _, whitelistPrefix := range whitelistPrefixes {
+			if strings.HasPrefix(c.Request().URL.Path, whitelistPrefix) {
+				return next(c)
+			}
 		}
-		am.Logger.Info("going through auth middleware")
+		requestLogger := logger.FromContext(c.Request().Context(), am.Logger)
+		requestLogger.Info("going through auth middleware")
 		accessToken, getTokenError := c.Request().Cookie("token")
 
 		// Deal with case where access token does not exist in cookie
 		if getTokenError != nil {
-			am.Logger.Error("access token does not exist in cookie")
+			requestLogger.Error("access token does not exist in cookie")
 			// Try to extract bearer access token from Authorization header
 			authHeader := c.Request().Header.Get("Authorization")
 			if len(authHeader) == 0 {
-				am.Logger.Error("access token does not exist in authorization header as well")
-				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"success": false})
+				requestLogger.Error("access token does not exist in authorization header as well")
+				return apperror.Unauthenticated("Invalid or missing access token.")
 			}
 
 			// Try to extract access token from bearer access token
@@ -48,20 +122,24 @@ func (am *AuthMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
 			regex := regexp.MustCompile(regexExpression)
 			bearerToken := regex.ReplaceAllString(authHeader, "")
 			if len(bearerToken) == 0 {
-				am.Logger.Error("access token does not exist in authorization header as well")
-				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"success": false})
+				requestLogger.Error("access token does not exist in authorization header as well")
+				return apperror.Unauthenticated("Invalid or missing access token.")
+			}
+
+			// Personal access tokens are looked up in database instead of being verified as JWT
+			if strings.HasPrefix(bearerToken, utils.ApiTokenPrefix) {
+				return am.authenticateApiToken(c, next, bearerToken)
 			}
 
 			// Verify access token
 			isAccessTokenValid, uid := am.TokenUtils.VerifyToken(bearerToken, 0)
 			if !isAccessTokenValid {
-				am.Logger.Error("invalid access token")
-				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"success": false})
+				requestLogger.Error("invalid access token")
+				return apperror.Unauthenticated("Invalid or missing access token.")
 			}
 
 			c.Set("uid", uid)
-			next(c)
-			return nil
+			return next(c)
 		}
 
 		// Verify the access token in cookie
@@ -70,46 +148,173 @@ func (am *AuthMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
 		// Allow request to go through if access token is valid
 		if isTokenValid {
 			c.Set("uid", uid)
-			next(c)
-			return nil
+			return next(c)
 		}
-		am.Logger.Error("invalid access token")
+		requestLogger.Error("invalid access token")
 
-		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"success": false})
+		return apperror.Unauthenticated("Invalid or missing access token.")
 	}
 }
 
+// Authenticate request with personal access token, only allowing it through if token scopes cover the requested resource
+func (am *AuthMiddleware) authenticateApiToken(c echo.Context, next echo.HandlerFunc, token string) error {
+	ctx := c.Request().Context()
+	requestLogger := logger.FromContext(ctx, am.Logger)
+	apiToken, useTokenError := database.UseApiToken(ctx, am.Db, utils.HashToken(token))
+	if useTokenError != nil {
+		if errors.Is(useTokenError, pgx.ErrNoRows) {
+			requestLogger.Error("api token does not exist, expired or revoked")
+		} else {
+			requestLogger.Error(fmt.Sprintf("failed to get api token from database. %s", useTokenError.Error()))
+		}
+		return apperror.Unauthenticated("Invalid or missing access token.")
+	}
+
+	// Resource is the route group right after version prefix, e.g. accounts in /v1/accounts/credit
+	pathSegments := strings.Split(strings.Trim(c.Request().URL.Path, "/"), "/")
+	resource := ""
+	if len(pathSegments) >= 2 {
+		resource = pathSegments[1]
+	}
+	method := c.Request().Method
+	write := method != http.MethodGet && method != http.MethodHead
+	if !utils.HasApiTokenScope(apiToken.Scopes, resource, write) {
+		requestLogger.Error(fmt.Sprintf("api token %s is not scoped for %s %s", apiToken.Id, method, c.Request().URL.Path))
+		return apperror.PermissionDenied("Insufficient token scope.")
+	}
+
+	c.Set("uid", apiToken.ClientId)
+	c.Set("apiTokenId", apiToken.Id)
+	return next(c)
+}
+
+// Assign a request id to every request, attach a logger carrying it to request context and write one access log once the request is done.
+// Request id of caller in X-Request-ID header is kept if well-formed, so that logs can be correlated across services.
 func (lm *LogMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		blacklistPaths := []string{"/v1/auth/login", "/v1/auth/signup"}
-
-		if !slices.Contains(blacklistPaths, c.Request().RequestURI) {
-			var data string = ""
-			if c.Request().Header.Get("Content-Type") == echo.MIMEApplicationJSON {
-				rawBody, _ := io.ReadAll(c.Request().Body)
-				regexExpression := "\\s|\n|\t"
-				regex := regexp.MustCompile(regexExpression)
-				data = regex.ReplaceAllString(string(rawBody), "")
-				c.Request().Body = io.NopCloser(bytes.NewBuffer(rawBody))
+		startedAt := time.Now()
+		request := c.Request()
+
+		requestId := request.Header.Get(echo.HeaderXRequestID)
+		if !requestIdRegex.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+		c.Set("requestId", requestId)
+		c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+
+		requestLogger := lm.Logger.With(append(tracing.LogFields(request.Context()), zap.String("requestId", requestId))...)
+		c.SetRequest(request.WithContext(logger.WithContext(request.Context(), requestLogger)))
+
+		// Reading the body is skipped entirely unless it would be logged
+		if requestLogger.Core().Enabled(zap.DebugLevel) {
+			fields := []zap.Field{zap.Any("headers", lm.Redactor.Headers(request.Header))}
+			if lm.bodyPolicy(c.Path()) == BodyPolicyRedacted && request.Header.Get("Content-Type") == echo.MIMEApplicationJSON {
+				rawBody, _ := io.ReadAll(request.Body)
+				request.Body = io.NopCloser(bytes.NewBuffer(rawBody))
+				fields = append(fields, zap.String("data", lm.Redactor.Json(rawBody)))
 			}
-			requestId := c.Request().Header.Get(echo.HeaderXRequestID)
-			c.Set("requestId", requestId)
+			requestLogger.Debug("incoming request", fields...)
+		}
+
+		// Let echo write the error response first, otherwise status of the response is not final yet
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
 
-			lm.Logger.Info(
-				"incoming request",
-				zap.String("requestId", requestId),
-				zap.String("method", c.Request().Method),
-				zap.String("path", c.Request().RequestURI),
-				zap.String("data", data),
-			)
+		route := c.Path()
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+		uid, _ := c.Get("uid").(string)
+		status := c.Response().Status
+		fields := []zap.Field{
+			zap.String("method", request.Method),
+			zap.String("route", route),
+			zap.String("path", request.URL.Path),
+			zap.Int("status", status),
+			zap.Float64("latencyMs", float64(time.Since(startedAt).Microseconds())/1000),
+			zap.Int64("bytes", c.Response().Size),
+			zap.String("uid", uid),
+			zap.String("ip", c.RealIP()),
+			zap.String("userAgent", request.UserAgent()),
 		}
+		if status >= http.StatusInternalServerError {
+			requestLogger.Error("request finished", fields...)
+		} else {
+			requestLogger.Info("request finished", fields...)
+		}
+
+		return nil
+	}
+}
 
-		next(c)
+// Unknown body policies are treated as omit, so that a typo in config does not leak bodies
+func (lm *LogMiddleware) bodyPolicy(route string) string {
+	if slices.Contains(bodyOmitRoutes, route) || slices.Contains(lm.BodyOmitRoutes, route) || lm.BodyPolicy != BodyPolicyRedacted {
+		return BodyPolicyOmit
+	}
+	return BodyPolicyRedacted
+}
+
+// Random uuid v4, same format as ids generated by postgres
+func newRequestId() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	bytes[6] = (bytes[6] & 0x0f) | 0x40
+	bytes[8] = (bytes[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", bytes[0:4], bytes[4:6], bytes[6:8], bytes[8:10], bytes[10:])
+}
+
+// Record count and latency of every request by route template instead of actual path, so that ids do not blow up label cardinality
+func (mm *MetricsMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		startedAt := time.Now()
+		// Let echo write the error response first, otherwise status of the response is not final yet
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
 
-		lm.Logger.Info(
-			"request finished",
-			zap.Int("status", c.Response().Status),
+		route := c.Path()
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Response().Status)
+		metrics.HttpRequestsTotal.WithLabelValues(c.Request().Method, route, status).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(c.Request().Method, route, status).Observe(time.Since(startedAt).Seconds())
+
+		return nil
+	}
+}
+
+// Start a span for every request, continuing the trace of caller if traceparent header is present.
+// Request context carries the span afterwards, so that database queries and outbound calls become its children.
+func (tm *TracingMiddleware) New(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Request()
+		route := c.Path()
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+		ctx, span := tracing.Tracer().Start(
+			ctx,
+			fmt.Sprintf("%s %s", request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPMethod(request.Method), semconv.HTTPRoute(route), semconv.URLPath(request.URL.Path)),
 		)
+		defer span.End()
+		c.SetRequest(request.WithContext(ctx))
+
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 
 		return nil
 	}
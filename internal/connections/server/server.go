@@ -1,10 +1,18 @@
 package server
 
 import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/redact"
 	"github.com/nighostchris/everytrack-backend/internal/utils"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
@@ -21,19 +29,72 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return nil
 }
 
-func New(domainWhitelist []string, logger *zap.Logger, env *config.Config) *echo.Echo {
+// Without trusted proxies the peer address is the client IP, otherwise X-Forwarded-For is walked back until an untrusted address
+func ipExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	ranges := []*net.IPNet{}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if len(proxy) == 0 {
+			continue
+		}
+		// Single addresses are accepted as well as CIDR ranges
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy = proxy + "/128"
+			} else {
+				proxy = proxy + "/32"
+			}
+		}
+		_, ipRange, parseError := net.ParseCIDR(proxy)
+		if parseError != nil {
+			return nil, parseError
+		}
+		ranges = append(ranges, ipRange)
+	}
+
+	if len(ranges) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	// Only the configured ranges are trusted, rather than every loopback and private address which echo trusts by default
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, ipRange := range ranges {
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+func New(env *config.Config, db *pgxpool.Pool, logger *zap.Logger, tokenUtils *utils.TokenUtils) *echo.Echo {
 	logger.Info("initializing web server")
 
 	e := echo.New()
 	// Hiding framework promotional banner
 	e.HideBanner = true
-	// Bind go-playground validator to the server
-	e.Validator = &CustomValidator{validator: validator.New()}
+	// Resolve client IP used by login throttling and logs, forwarded headers are only honoured when sent by trusted proxies
+	extractIP, ipExtractorError := ipExtractor(env.TrustedProxies)
+	if ipExtractorError != nil {
+		logger.Error(fmt.Sprintf("failed to parse trusted proxies. %s", ipExtractorError.Error()))
+		os.Exit(1)
+	}
+	e.IPExtractor = extractIP
+	// Bind go-playground validator to the server, naming fields by their json tag so that validation errors match request body
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	e.Validator = &CustomValidator{validator: validate}
+	// Render errors returned by handlers and middlewares in the same shape
+	errorHandler := ErrorHandler{Logger: logger, Format: env.ErrorResponseFormat}
+	e.HTTPErrorHandler = errorHandler.Handle
 	// Middleware - CORS
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOriginFunc: func(origin string) (bool, error) {
 			// TO Fix: origin is https://localhost:3000, need to update function below to handle without wildcard
-			if slices.Contains(domainWhitelist, "*") || slices.Contains(domainWhitelist, origin) {
+			if slices.Contains(env.DomainWhitelist, "*") || slices.Contains(env.DomainWhitelist, origin) {
 				return true, nil
 			} else {
 				return false, nil
@@ -41,13 +102,26 @@ func New(domainWhitelist []string, logger *zap.Logger, env *config.Config) *echo
 		},
 		AllowMethods:     []string{"GET", "PUT", "POST", "DELETE"},
 		AllowCredentials: true,
+		// Let browser clients read the request id, which is quoted when reporting issues
+		ExposeHeaders: []string{echo.HeaderXRequestID},
 	}))
+	// Middleware - Tracing, registered ahead of the others so that their database queries belong to the request span
+	tracingMiddleware := TracingMiddleware{}
+	e.Use(tracingMiddleware.New)
+	// Middleware - Metrics, registered ahead of auth so that rejected requests are counted too
+	metricsMiddleware := MetricsMiddleware{}
+	e.Use(metricsMiddleware.New)
+	// Middleware - Log, registered ahead of auth so that rejected requests get a request id and access log too
+	logMiddleware := LogMiddleware{
+		Logger:         logger,
+		Redactor:       redact.New(env),
+		BodyPolicy:     env.LogBodyPolicy,
+		BodyOmitRoutes: env.LogBodyOmitRoutes,
+	}
+	e.Use(logMiddleware.New)
 	// Middleware - Auth
-	authMiddleware := AuthMiddleware{Logger: logger, TokenUtils: &utils.TokenUtils{Env: env, Logger: logger}}
+	authMiddleware := AuthMiddleware{Db: db, Logger: logger, TokenUtils: tokenUtils}
 	e.Use(authMiddleware.New)
-	// Middleware - Log
-	logMiddleware := LogMiddleware{Logger: logger}
-	e.Use(logMiddleware.New)
 
 	return e
 }
@@ -23,13 +23,14 @@ type AccountSummary struct {
 
 type CheckExistingAccountParams struct {
 	Name            string `json:"name"`
-	ClientId        string `json:"client_id"`
+	WorkspaceId     string `json:"workspace_id"`
 	AssetProviderId string `json:"asset_provider_id"`
 }
 
 type CreateNewAccountParams struct {
 	Name            string `json:"name"`
 	ClientId        string `json:"client_id"`
+	WorkspaceId     string `json:"workspace_id"`
 	CurrencyId      string `json:"currency_id"`
 	AssetProviderId string `json:"asset_provider_id"`
 }
@@ -38,17 +39,21 @@ type UpdateAccountParams struct {
 	Balance       string `json:"balance"`
 	CurrencyId    string `json:"currency_id"`
 	AccountTypeId string `json:"account_type_id"`
+	WorkspaceId   string `json:"workspace_id"`
 }
 
-func GetAllAccountSummaryByType(db *pgxpool.Pool, providerType string, clientId string) ([]AccountSummary, error) {
+func GetAllAccountSummaryByType(ctx context.Context, db *pgxpool.Pool, providerType string, workspaceId string) ([]AccountSummary, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	accountSummary := []AccountSummary{}
 	query := `SELECT a.id, a.balance, apat.name, ap.id as asset_provider_id, apat.id as account_type_id, c.id as currency_id
 	FROM everytrack_backend.account AS a
 	INNER JOIN everytrack_backend.asset_provider_account_type AS apat ON a.asset_provider_account_type_id = apat.id
 	INNER JOIN everytrack_backend.asset_provider AS ap ON apat.asset_provider_id = ap.id
 	INNER JOIN everytrack_backend.currency AS c ON c.id = a.currency_id
-	WHERE ap.type = $1 AND a.client_id = $2;`
-	rows, queryError := db.Query(context.Background(), query, providerType, clientId)
+	WHERE ap.type = $1 AND a.workspace_id = $2;`
+	rows, queryError := db.Query(ctx, query, providerType, workspaceId)
 	if queryError != nil {
 		return []AccountSummary{}, queryError
 	}
@@ -74,10 +79,49 @@ func GetAllAccountSummaryByType(db *pgxpool.Pool, providerType string, clientId
 	return accountSummary, nil
 }
 
-func GetAccountBalance(db *pgxpool.Pool, accountId string) (string, error) {
+func GetAllAccountSummaryByWorkspaceId(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]AccountSummary, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	accountSummary := []AccountSummary{}
+	query := `SELECT a.id, a.balance, apat.name, ap.id as asset_provider_id, apat.id as account_type_id, a.currency_id
+	FROM everytrack_backend.account AS a
+	INNER JOIN everytrack_backend.asset_provider_account_type AS apat ON a.asset_provider_account_type_id = apat.id
+	INNER JOIN everytrack_backend.asset_provider AS ap ON apat.asset_provider_id = ap.id
+	WHERE a.workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return []AccountSummary{}, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var summary AccountSummary
+		scanError := rows.Scan(
+			&summary.Id,
+			&summary.Balance,
+			&summary.Name,
+			&summary.AssetProviderId,
+			&summary.AccountTypeId,
+			&summary.CurrencyId,
+		)
+		if scanError != nil {
+			return []AccountSummary{}, scanError
+		}
+		accountSummary = append(accountSummary, summary)
+	}
+
+	return accountSummary, nil
+}
+
+func GetAccountBalance(ctx context.Context, db *pgxpool.Pool, accountId string) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var balance string
 	getBalanceQuery := `SELECT balance FROM everytrack_backend.account WHERE id = $1;`
-	getBalanceError := db.QueryRow(context.Background(), getBalanceQuery, accountId).Scan(&balance)
+	getBalanceError := db.QueryRow(ctx, getBalanceQuery, accountId).Scan(&balance)
 	if getBalanceError != nil {
 		return balance, getBalanceError
 	}
@@ -85,14 +129,17 @@ func GetAccountBalance(db *pgxpool.Pool, accountId string) (string, error) {
 	return balance, nil
 }
 
-func GetAccountSummary(db *pgxpool.Pool, accountId string) (AccountSummary, error) {
+func GetAccountSummary(ctx context.Context, db *pgxpool.Pool, accountId string) (AccountSummary, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var summary AccountSummary
 	getAccountSummaryQuery := `SELECT a.id, apat.name, a.balance, a.currency_id, a.asset_provider_account_type_id, ap.id as asset_provider_id
 	FROM everytrack_backend.account AS a
 	INNER JOIN everytrack_backend.asset_provider_account_type AS apat ON a.asset_provider_account_type_id = apat.id
 	INNER JOIN everytrack_backend.asset_provider AS ap ON apat.asset_provider_id = ap.id
 	WHERE a.id = $1;`
-	getAccountSummaryError := db.QueryRow(context.Background(), getAccountSummaryQuery, accountId).Scan(&summary.Id, &summary.Name, &summary.Balance, &summary.CurrencyId, &summary.AccountTypeId, &summary.AssetProviderId)
+	getAccountSummaryError := db.QueryRow(ctx, getAccountSummaryQuery, accountId).Scan(&summary.Id, &summary.Name, &summary.Balance, &summary.CurrencyId, &summary.AccountTypeId, &summary.AssetProviderId)
 	if getAccountSummaryError != nil {
 		return summary, getAccountSummaryError
 	}
@@ -100,13 +147,16 @@ func GetAccountSummary(db *pgxpool.Pool, accountId string) (AccountSummary, erro
 	return summary, nil
 }
 
-func CheckExistingAccount(db *pgxpool.Pool, params CheckExistingAccountParams) (bool, error) {
+func CheckExistingAccount(ctx context.Context, db *pgxpool.Pool, params CheckExistingAccountParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var existingRowCount int
 	query := `SELECT count(*)
 	FROM everytrack_backend.asset_provider_account_type as apat
 	INNER JOIN everytrack_backend.account as acc ON acc.asset_provider_account_type_id = apat.id
-	WHERE apat.name = $1 AND acc.client_id = $2 AND apat.asset_provider_id = $3;`
-	queryError := db.QueryRow(context.Background(), query, params.Name, params.ClientId, params.AssetProviderId).Scan(&existingRowCount)
+	WHERE apat.name = $1 AND acc.workspace_id = $2 AND apat.asset_provider_id = $3;`
+	queryError := db.QueryRow(ctx, query, params.Name, params.WorkspaceId, params.AssetProviderId).Scan(&existingRowCount)
 	if queryError != nil {
 		return false, queryError
 	}
@@ -118,19 +168,22 @@ func CheckExistingAccount(db *pgxpool.Pool, params CheckExistingAccountParams) (
 	return false, nil
 }
 
-func CreateNewAccount(db *pgxpool.Pool, params CreateNewAccountParams) (bool, error) {
+func CreateNewAccount(ctx context.Context, db *pgxpool.Pool, params CreateNewAccountParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	// Create new asset provider account type first
 	var assetProviderAccountTypeId string
 	insertAccountTypeQuery := "INSERT INTO everytrack_backend.asset_provider_account_type (asset_provider_id, name) VALUES ($1, $2) RETURNING id;"
-	insertAccountTypeError := db.QueryRow(context.Background(), insertAccountTypeQuery, params.AssetProviderId, params.Name).Scan(&assetProviderAccountTypeId)
+	insertAccountTypeError := db.QueryRow(ctx, insertAccountTypeQuery, params.AssetProviderId, params.Name).Scan(&assetProviderAccountTypeId)
 
 	if insertAccountTypeError != nil {
 		return false, insertAccountTypeError
 	}
 
 	// Create new account using the newly created account type id above
-	insertAccountQuery := "INSERT INTO everytrack_backend.account (client_id, asset_provider_account_type_id, currency_id, balance) VALUES ($1, $2, $3, $4);"
-	_, insertAccountError := db.Exec(context.Background(), insertAccountQuery, params.ClientId, assetProviderAccountTypeId, params.CurrencyId, "0")
+	insertAccountQuery := "INSERT INTO everytrack_backend.account (client_id, workspace_id, asset_provider_account_type_id, currency_id, balance) VALUES ($1, $2, $3, $4, $5);"
+	_, insertAccountError := db.Exec(ctx, insertAccountQuery, params.ClientId, params.WorkspaceId, assetProviderAccountTypeId, params.CurrencyId, "0")
 
 	if insertAccountError != nil {
 		return false, insertAccountError
@@ -139,9 +192,12 @@ func CreateNewAccount(db *pgxpool.Pool, params CreateNewAccountParams) (bool, er
 	return true, nil
 }
 
-func UpdateAccount(db *pgxpool.Pool, params UpdateAccountParams) (bool, error) {
-	query := "UPDATE everytrack_backend.account SET balance = $1, currency_id = $2 WHERE asset_provider_account_type_id = $3;"
-	_, updateError := db.Exec(context.Background(), query, params.Balance, params.CurrencyId, params.AccountTypeId)
+func UpdateAccount(ctx context.Context, db *pgxpool.Pool, params UpdateAccountParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.account SET balance = $1, currency_id = $2 WHERE asset_provider_account_type_id = $3 AND workspace_id = $4;"
+	_, updateError := db.Exec(ctx, query, params.Balance, params.CurrencyId, params.AccountTypeId, params.WorkspaceId)
 
 	if updateError != nil {
 		return false, updateError
@@ -150,9 +206,12 @@ func UpdateAccount(db *pgxpool.Pool, params UpdateAccountParams) (bool, error) {
 	return true, nil
 }
 
-func UpdateAccountBalance(db *pgxpool.Pool, balance string, accountId string) (bool, error) {
+func UpdateAccountBalance(ctx context.Context, db *pgxpool.Pool, balance string, accountId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE everytrack_backend.account SET balance = $1 WHERE id = $2;"
-	_, updateError := db.Exec(context.Background(), query, balance, accountId)
+	_, updateError := db.Exec(ctx, query, balance, accountId)
 
 	if updateError != nil {
 		return false, updateError
@@ -161,18 +220,21 @@ func UpdateAccountBalance(db *pgxpool.Pool, balance string, accountId string) (b
 	return true, nil
 }
 
-func DeleteAccount(db *pgxpool.Pool, accountId string) (bool, error) {
+func DeleteAccount(ctx context.Context, db *pgxpool.Pool, accountId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	// Retrieve relevant asset_provider_account_type from database
 	var accountTypeId string
 	getAccountTypeIdQuery := `SELECT asset_provider_account_type_id FROM everytrack_backend.account WHERE id = $1;`
-	getAccountTypeIdError := db.QueryRow(context.Background(), getAccountTypeIdQuery, accountId).Scan(&accountTypeId)
+	getAccountTypeIdError := db.QueryRow(ctx, getAccountTypeIdQuery, accountId).Scan(&accountTypeId)
 	if getAccountTypeIdError != nil {
 		return false, getAccountTypeIdError
 	}
 
 	// Delete relevant account_stock records from database
 	deleteStockHoldingsQuery := "DELETE FROM everytrack_backend.account_stock WHERE account_id = $1;"
-	_, deleteStockHoldingsError := db.Exec(context.Background(), deleteStockHoldingsQuery, accountId)
+	_, deleteStockHoldingsError := db.Exec(ctx, deleteStockHoldingsQuery, accountId)
 
 	if deleteStockHoldingsError != nil {
 		return false, deleteStockHoldingsError
@@ -180,7 +242,7 @@ func DeleteAccount(db *pgxpool.Pool, accountId string) (bool, error) {
 
 	// Delete account from database
 	query := "DELETE FROM everytrack_backend.account WHERE id = $1;"
-	_, deleteError := db.Exec(context.Background(), query, accountId)
+	_, deleteError := db.Exec(ctx, query, accountId)
 
 	if deleteError != nil {
 		return false, deleteError
@@ -188,7 +250,7 @@ func DeleteAccount(db *pgxpool.Pool, accountId string) (bool, error) {
 
 	// Delete relevant asset_provider_account_type from database since it's 1-1 relationship
 	deleteAccountTypeQuery := "DELETE FROM everytrack_backend.asset_provider_account_type WHERE id = $1;"
-	_, deleteAccountTypeError := db.Exec(context.Background(), deleteAccountTypeQuery, accountTypeId)
+	_, deleteAccountTypeError := db.Exec(ctx, deleteAccountTypeQuery, accountTypeId)
 
 	if deleteAccountTypeError != nil {
 		return false, deleteAccountTypeError
@@ -20,13 +20,16 @@ type UpdateStockHoldingCostParams struct {
 	AccountId string `json:"account_id"`
 }
 
-func GetAllStockHoldings(db *pgxpool.Pool, clientId string) ([]AccountStock, error) {
+func GetAllStockHoldings(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]AccountStock, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	accountStocks := []AccountStock{}
 	query := `SELECT accs.id, account_id, stock_id, unit, cost
 	FROM everytrack_backend.account_stock AS accs
 	INNER JOIN everytrack_backend.account AS a ON a.id = accs.account_id
-	WHERE a.client_id = $1;`
-	rows, queryError := db.Query(context.Background(), query, clientId)
+	WHERE a.workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
 	if queryError != nil {
 		return []AccountStock{}, queryError
 	}
@@ -45,9 +48,12 @@ func GetAllStockHoldings(db *pgxpool.Pool, clientId string) ([]AccountStock, err
 	return accountStocks, nil
 }
 
-func CreateNewStockHolding(db *pgxpool.Pool, params CreateNewStockHoldingParams) (bool, error) {
+func CreateNewStockHolding(ctx context.Context, db *pgxpool.Pool, params CreateNewStockHoldingParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "INSERT INTO everytrack_backend.account_stock (account_id, stock_id, unit, cost) VALUES ($1, $2, $3, $4);"
-	_, createError := db.Exec(context.Background(), query, params.AccountId, params.StockId, params.Unit, params.Cost)
+	_, createError := db.Exec(ctx, query, params.AccountId, params.StockId, params.Unit, params.Cost)
 
 	if createError != nil {
 		return false, createError
@@ -56,9 +62,12 @@ func CreateNewStockHolding(db *pgxpool.Pool, params CreateNewStockHoldingParams)
 	return true, nil
 }
 
-func UpdateStockHoldingCost(db *pgxpool.Pool, params UpdateStockHoldingCostParams) (bool, error) {
+func UpdateStockHoldingCost(ctx context.Context, db *pgxpool.Pool, params UpdateStockHoldingCostParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE everytrack_backend.account_stock SET cost = $1, unit = $2 WHERE stock_id = $3 AND account_id = $4;"
-	_, updateError := db.Exec(context.Background(), query, params.Cost, params.Unit, params.StockId, params.AccountId)
+	_, updateError := db.Exec(ctx, query, params.Cost, params.Unit, params.StockId, params.AccountId)
 
 	if updateError != nil {
 		return false, updateError
@@ -67,9 +76,12 @@ func UpdateStockHoldingCost(db *pgxpool.Pool, params UpdateStockHoldingCostParam
 	return true, nil
 }
 
-func DeleteStockHolding(db *pgxpool.Pool, accountStockId string) (bool, error) {
+func DeleteStockHolding(ctx context.Context, db *pgxpool.Pool, accountStockId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "DELETE FROM everytrack_backend.account_stock WHERE id = $1;"
-	_, deleteError := db.Exec(context.Background(), query, accountStockId)
+	_, deleteError := db.Exec(ctx, query, accountStockId)
 
 	if deleteError != nil {
 		return false, deleteError
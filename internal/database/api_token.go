@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreateNewApiTokenParams struct {
+	ClientId  string     `json:"client_id"`
+	Name      string     `json:"name"`
+	TokenHash string     `json:"token_hash"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func GetAllActiveApiTokensByClientId(ctx context.Context, db *pgxpool.Pool, clientId string) ([]ApiToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	apiTokens := []ApiToken{}
+	query := `SELECT id, client_id, name, token_hash, scopes, last_used_at, expires_at, revoked_at, created_at
+	FROM everytrack_backend.api_token
+	WHERE client_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+	ORDER BY created_at DESC;`
+	rows, queryError := db.Query(ctx, query, clientId)
+	if queryError != nil {
+		return apiTokens, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var apiToken ApiToken
+		scanError := rows.Scan(
+			&apiToken.Id,
+			&apiToken.ClientId,
+			&apiToken.Name,
+			&apiToken.TokenHash,
+			&apiToken.Scopes,
+			&apiToken.LastUsedAt,
+			&apiToken.ExpiresAt,
+			&apiToken.RevokedAt,
+			&apiToken.CreatedAt,
+		)
+		if scanError != nil {
+			return apiTokens, scanError
+		}
+		apiTokens = append(apiTokens, apiToken)
+	}
+
+	return apiTokens, nil
+}
+
+func CreateNewApiToken(ctx context.Context, db *pgxpool.Pool, params CreateNewApiTokenParams) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var id string
+	query := "INSERT INTO everytrack_backend.api_token (client_id, name, token_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id;"
+	queryError := db.QueryRow(ctx, query, params.ClientId, params.Name, params.TokenHash, params.Scopes, params.ExpiresAt).Scan(&id)
+
+	if queryError != nil {
+		return id, queryError
+	}
+
+	return id, nil
+}
+
+// Look up an active token by its hash and record the usage at the same time.
+// Returns pgx.ErrNoRows if the token does not exist, has expired or has been revoked.
+func UseApiToken(ctx context.Context, db *pgxpool.Pool, tokenHash string) (ApiToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var apiToken ApiToken
+	query := `UPDATE everytrack_backend.api_token SET last_used_at = now()
+	WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+	RETURNING id, client_id, name, token_hash, scopes, last_used_at, expires_at, revoked_at, created_at;`
+	queryError := db.QueryRow(ctx, query, tokenHash).Scan(
+		&apiToken.Id,
+		&apiToken.ClientId,
+		&apiToken.Name,
+		&apiToken.TokenHash,
+		&apiToken.Scopes,
+		&apiToken.LastUsedAt,
+		&apiToken.ExpiresAt,
+		&apiToken.RevokedAt,
+		&apiToken.CreatedAt,
+	)
+
+	if queryError != nil {
+		return apiToken, queryError
+	}
+
+	return apiToken, nil
+}
+
+func RevokeApiToken(ctx context.Context, db *pgxpool.Pool, tokenId string, clientId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.api_token SET revoked_at = now() WHERE id = $1 AND client_id = $2 AND revoked_at IS NULL;"
+	result, updateError := db.Exec(ctx, query, tokenId, clientId)
+
+	if updateError != nil {
+		return false, updateError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
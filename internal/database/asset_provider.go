@@ -13,10 +13,13 @@ type ProviderDetail struct {
 	CountryId string `json:"countryId"`
 }
 
-func GetAllProvidersByType(db *pgxpool.Pool, providerType string) ([]ProviderDetail, error) {
+func GetAllProvidersByType(ctx context.Context, db *pgxpool.Pool, providerType string) ([]ProviderDetail, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var providerDetails []ProviderDetail
 	query := `SELECT id, name, icon, country_id FROM everytrack_backend.asset_provider WHERE type = $1;`
-	rows, queryError := db.Query(context.Background(), query, providerType)
+	rows, queryError := db.Query(ctx, query, providerType)
 	if queryError != nil {
 		return []ProviderDetail{}, queryError
 	}
@@ -7,22 +7,26 @@ import (
 )
 
 type CreateNewCashRecordParams struct {
-	Amount     string `json:"amount"`
-	ClientId   string `json:"client_id"`
-	CurrencyId string `json:"currency_id"`
+	Amount      string `json:"amount"`
+	ClientId    string `json:"client_id"`
+	WorkspaceId string `json:"workspace_id"`
+	CurrencyId  string `json:"currency_id"`
 }
 
 type UpdateCashRecordParams struct {
-	Id         string `json:"id"`
-	Amount     string `json:"amount"`
-	ClientId   string `json:"client_id"`
-	CurrencyId string `json:"currency_id"`
+	Id          string `json:"id"`
+	Amount      string `json:"amount"`
+	WorkspaceId string `json:"workspace_id"`
+	CurrencyId  string `json:"currency_id"`
 }
 
-func GetAllCash(db *pgxpool.Pool, clientId string) ([]Cash, error) {
+func GetAllCash(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]Cash, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	cashRecords := []Cash{}
-	query := `SELECT id, currency_id, amount FROM everytrack_backend.cash WHERE client_id = $1;`
-	rows, queryError := db.Query(context.Background(), query, clientId)
+	query := `SELECT id, currency_id, amount FROM everytrack_backend.cash WHERE workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
 	if queryError != nil {
 		return cashRecords, queryError
 	}
@@ -45,12 +49,16 @@ func GetAllCash(db *pgxpool.Pool, clientId string) ([]Cash, error) {
 	return cashRecords, nil
 }
 
-func CreateNewCashRecord(db *pgxpool.Pool, params CreateNewCashRecordParams) (bool, error) {
-	query := "INSERT INTO everytrack_backend.cash (client_id, currency_id, amount) VALUES ($1, $2, $3);"
+func CreateNewCashRecord(ctx context.Context, db *pgxpool.Pool, params CreateNewCashRecordParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO everytrack_backend.cash (client_id, workspace_id, currency_id, amount) VALUES ($1, $2, $3, $4);"
 	_, createError := db.Exec(
-		context.Background(),
+		ctx,
 		query,
 		params.ClientId,
+		params.WorkspaceId,
 		params.CurrencyId,
 		params.Amount,
 	)
@@ -62,9 +70,12 @@ func CreateNewCashRecord(db *pgxpool.Pool, params CreateNewCashRecordParams) (bo
 	return true, nil
 }
 
-func UpdateCashRecord(db *pgxpool.Pool, params UpdateCashRecordParams) (bool, error) {
-	query := "UPDATE everytrack_backend.cash SET amount = $1, currency_id = $2 WHERE id = $3 AND client_id = $4;"
-	_, updateError := db.Exec(context.Background(), query, params.Amount, params.CurrencyId, params.Id, params.ClientId)
+func UpdateCashRecord(ctx context.Context, db *pgxpool.Pool, params UpdateCashRecordParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.cash SET amount = $1, currency_id = $2 WHERE id = $3 AND workspace_id = $4;"
+	_, updateError := db.Exec(ctx, query, params.Amount, params.CurrencyId, params.Id, params.WorkspaceId)
 
 	if updateError != nil {
 		return false, updateError
@@ -73,9 +84,12 @@ func UpdateCashRecord(db *pgxpool.Pool, params UpdateCashRecordParams) (bool, er
 	return true, nil
 }
 
-func DeleteCashRecord(db *pgxpool.Pool, cashId string, clientId string) (bool, error) {
-	query := "DELETE FROM everytrack_backend.cash WHERE id = $1 AND client_id = $2;"
-	_, deleteError := db.Exec(context.Background(), query, cashId, clientId)
+func DeleteCashRecord(ctx context.Context, db *pgxpool.Pool, cashId string, workspaceId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM everytrack_backend.cash WHERE id = $1 AND workspace_id = $2;"
+	_, deleteError := db.Exec(ctx, query, cashId, workspaceId)
 
 	if deleteError != nil {
 		return false, deleteError
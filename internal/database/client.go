@@ -19,10 +19,13 @@ type UpdateClientSettingsParams struct {
 	CurrencyId string `json:"currency_id"`
 }
 
-func CreateNewClient(db *pgxpool.Pool, params CreateNewClientParams) (string, error) {
+func CreateNewClient(ctx context.Context, db *pgxpool.Pool, params CreateNewClientParams) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var id string
 	query := "INSERT INTO everytrack_backend.client (email, username, password, currency_id) VALUES ($1, $2, $3, $4) RETURNING id;"
-	queryError := db.QueryRow(context.Background(), query, params.Email, params.Username, params.Password, params.CurrencyId).Scan(&id)
+	queryError := db.QueryRow(ctx, query, params.Email, params.Username, params.Password, params.CurrencyId).Scan(&id)
 
 	if queryError != nil {
 		return id, queryError
@@ -31,10 +34,13 @@ func CreateNewClient(db *pgxpool.Pool, params CreateNewClientParams) (string, er
 	return id, nil
 }
 
-func GetClientByEmail(db *pgxpool.Pool, email string) (Client, error) {
+func GetClientByEmail(ctx context.Context, db *pgxpool.Pool, email string) (Client, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var client Client
-	query := "SELECT id, email, password, currency_id, created_at, updated_at FROM everytrack_backend.client WHERE email = $1;"
-	queryError := db.QueryRow(context.Background(), query, email).Scan(&client.Id, &client.Email, &client.Password, &client.CurrencyId, &client.CreatedAt, &client.UpdatedAt)
+	query := "SELECT id, email, password, currency_id, verified, created_at, updated_at FROM everytrack_backend.client WHERE email = $1;"
+	queryError := db.QueryRow(ctx, query, email).Scan(&client.Id, &client.Email, &client.Password, &client.CurrencyId, &client.Verified, &client.CreatedAt, &client.UpdatedAt)
 
 	if queryError != nil {
 		return client, queryError
@@ -43,10 +49,13 @@ func GetClientByEmail(db *pgxpool.Pool, email string) (Client, error) {
 	return client, nil
 }
 
-func GetClientById(db *pgxpool.Pool, id string) (Client, error) {
+func GetClientById(ctx context.Context, db *pgxpool.Pool, id string) (Client, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var client Client
-	query := "SELECT id, email, username, password, currency_id, created_at, updated_at FROM everytrack_backend.client WHERE id = $1;"
-	queryError := db.QueryRow(context.Background(), query, id).Scan(&client.Id, &client.Email, &client.Username, &client.Password, &client.CurrencyId, &client.CreatedAt, &client.UpdatedAt)
+	query := "SELECT id, email, username, password, currency_id, verified, created_at, updated_at FROM everytrack_backend.client WHERE id = $1;"
+	queryError := db.QueryRow(ctx, query, id).Scan(&client.Id, &client.Email, &client.Username, &client.Password, &client.CurrencyId, &client.Verified, &client.CreatedAt, &client.UpdatedAt)
 
 	if queryError != nil {
 		return client, queryError
@@ -55,9 +64,12 @@ func GetClientById(db *pgxpool.Pool, id string) (Client, error) {
 	return client, nil
 }
 
-func UpdateClientSettings(db *pgxpool.Pool, params UpdateClientSettingsParams) (bool, error) {
+func UpdateClientSettings(ctx context.Context, db *pgxpool.Pool, params UpdateClientSettingsParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE everytrack_backend.client SET username = $1, currency_id = $2 WHERE id = $3;"
-	_, updateError := db.Exec(context.Background(), query, params.Username, params.CurrencyId, params.ClientId)
+	_, updateError := db.Exec(ctx, query, params.Username, params.CurrencyId, params.ClientId)
 
 	if updateError != nil {
 		return false, updateError
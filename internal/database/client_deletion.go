@@ -0,0 +1,302 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Tables holding records of client accounts, emptied before the accounts themselves are deleted
+var clientAccountChildTables = []string{
+	"account_stock",
+	"goal_account",
+	"credit_statement",
+	"credit_account",
+	"loan_repayment",
+	"loan_account",
+	"reconciliation",
+}
+
+// Tables holding records of a workspace, in the order they have to be emptied to satisfy foreign keys
+var workspaceRecordTables = []string{
+	"goal",
+	"transaction",
+	"future_payment",
+	"cash",
+}
+
+// Tables holding records owned directly by client, in the order they have to be emptied to satisfy foreign keys
+var clientOwnedTables = []string{
+	"session",
+	"api_token",
+	"client_token",
+	"recovery_code",
+	"client_totp",
+	"oauth_identity",
+}
+
+type clientWorkspace struct {
+	id          string
+	memberCount int
+}
+
+func GetClientDeletion(ctx context.Context, db *pgxpool.Pool, clientId string) (ClientDeletion, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var clientDeletion ClientDeletion
+	query := "SELECT client_id, scheduled_at, created_at FROM everytrack_backend.client_deletion WHERE client_id = $1;"
+	queryError := db.QueryRow(ctx, query, clientId).Scan(&clientDeletion.ClientId, &clientDeletion.ScheduledAt, &clientDeletion.CreatedAt)
+
+	if queryError != nil {
+		return clientDeletion, queryError
+	}
+
+	return clientDeletion, nil
+}
+
+func GetAllDueClientDeletions(ctx context.Context, db *pgxpool.Pool) ([]ClientDeletion, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	clientDeletions := []ClientDeletion{}
+	query := "SELECT client_id, scheduled_at, created_at FROM everytrack_backend.client_deletion WHERE scheduled_at <= now() ORDER BY scheduled_at;"
+	rows, queryError := db.Query(ctx, query)
+	if queryError != nil {
+		return clientDeletions, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var clientDeletion ClientDeletion
+		scanError := rows.Scan(&clientDeletion.ClientId, &clientDeletion.ScheduledAt, &clientDeletion.CreatedAt)
+		if scanError != nil {
+			return clientDeletions, scanError
+		}
+		clientDeletions = append(clientDeletions, clientDeletion)
+	}
+
+	return clientDeletions, nil
+}
+
+// Schedule deletion of client after the grace period, returns false if a deletion has been scheduled already
+func ScheduleClientDeletion(ctx context.Context, db *pgxpool.Pool, clientId string, scheduledAt time.Time) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO everytrack_backend.client_deletion (client_id, scheduled_at) VALUES ($1, $2) ON CONFLICT (client_id) DO NOTHING;"
+	result, insertError := db.Exec(ctx, query, clientId, scheduledAt)
+
+	if insertError != nil {
+		return false, insertError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func CancelClientDeletion(ctx context.Context, db *pgxpool.Pool, clientId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM everytrack_backend.client_deletion WHERE client_id = $1;"
+	result, deleteError := db.Exec(ctx, query, clientId)
+
+	if deleteError != nil {
+		return false, deleteError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// Permanently erase client together with every record it owns within a single database transaction.
+// Returns false without erasing anything if the deletion is not due or has been cancelled in the meantime.
+func EraseClient(ctx context.Context, db *pgxpool.Pool, clientId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	// Claim the scheduled deletion first so that a concurrent cancellation either wins or waits for this transaction
+	claimQuery := "DELETE FROM everytrack_backend.client_deletion WHERE client_id = $1 AND scheduled_at <= now();"
+	result, claimError := tx.Exec(ctx, claimQuery, clientId)
+	if claimError != nil {
+		return false, claimError
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	var email string
+	getEmailQuery := "SELECT email FROM everytrack_backend.client WHERE id = $1 FOR UPDATE;"
+	if getEmailError := tx.QueryRow(ctx, getEmailQuery, clientId).Scan(&email); getEmailError != nil {
+		return false, getEmailError
+	}
+
+	// Workspaces client shares with others survive, their records are handed over to another member
+	workspaces := []clientWorkspace{}
+	getWorkspacesQuery := `SELECT wm.workspace_id, (SELECT count(*) FROM everytrack_backend.workspace_member WHERE workspace_id = wm.workspace_id)
+	FROM everytrack_backend.workspace_member AS wm WHERE wm.client_id = $1;`
+	rows, getWorkspacesError := tx.Query(ctx, getWorkspacesQuery, clientId)
+	if getWorkspacesError != nil {
+		return false, getWorkspacesError
+	}
+	for rows.Next() {
+		var workspace clientWorkspace
+		if scanError := rows.Scan(&workspace.id, &workspace.memberCount); scanError != nil {
+			rows.Close()
+			return false, scanError
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	rows.Close()
+	if rowsError := rows.Err(); rowsError != nil {
+		return false, rowsError
+	}
+
+	for _, workspace := range workspaces {
+		if workspace.memberCount > 1 {
+			if handOverError := handOverWorkspace(ctx, tx, workspace.id, clientId); handOverError != nil {
+				return false, handOverError
+			}
+			continue
+		}
+		if eraseRecordsError := eraseRecords(ctx, tx, "workspace_id", workspace.id); eraseRecordsError != nil {
+			return false, eraseRecordsError
+		}
+		for _, table := range []string{"workspace_invitation", "workspace_member"} {
+			query := "DELETE FROM everytrack_backend." + table + " WHERE workspace_id = $1;"
+			if _, deleteError := tx.Exec(ctx, query, workspace.id); deleteError != nil {
+				return false, deleteError
+			}
+		}
+		deleteWorkspaceQuery := "DELETE FROM everytrack_backend.workspace WHERE id = $1;"
+		if _, deleteWorkspaceError := tx.Exec(ctx, deleteWorkspaceQuery, workspace.id); deleteWorkspaceError != nil {
+			return false, deleteWorkspaceError
+		}
+	}
+
+	// Pick up any record left outside of the workspaces
+	if eraseRecordsError := eraseRecords(ctx, tx, "client_id", clientId); eraseRecordsError != nil {
+		return false, eraseRecordsError
+	}
+
+	for _, table := range clientOwnedTables {
+		query := "DELETE FROM everytrack_backend." + table + " WHERE client_id = $1;"
+		if _, deleteError := tx.Exec(ctx, query, clientId); deleteError != nil {
+			return false, deleteError
+		}
+	}
+
+	deleteInvitationsQuery := "DELETE FROM everytrack_backend.workspace_invitation WHERE invited_by = $1 OR lower(email) = lower($2);"
+	if _, deleteInvitationsError := tx.Exec(ctx, deleteInvitationsQuery, clientId, email); deleteInvitationsError != nil {
+		return false, deleteInvitationsError
+	}
+
+	// Login attempts made with the email before it was registered also count as personal data of client
+	deleteLoginAttemptsQuery := "DELETE FROM everytrack_backend.login_attempt WHERE client_id = $1 OR email = lower($2);"
+	if _, deleteLoginAttemptsError := tx.Exec(ctx, deleteLoginAttemptsQuery, clientId, email); deleteLoginAttemptsError != nil {
+		return false, deleteLoginAttemptsError
+	}
+
+	deleteClientQuery := "DELETE FROM everytrack_backend.client WHERE id = $1;"
+	if _, deleteClientError := tx.Exec(ctx, deleteClientQuery, clientId); deleteClientError != nil {
+		return false, deleteClientError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Delete records together with the accounts they belong to, filtered by either client_id or workspace_id column
+func eraseRecords(ctx context.Context, tx pgx.Tx, column string, id string) error {
+	unlinkGoalsQuery := `DELETE FROM everytrack_backend.goal_account
+	WHERE goal_id IN (SELECT id FROM everytrack_backend.goal WHERE ` + column + ` = $1);`
+	if _, unlinkGoalsError := tx.Exec(ctx, unlinkGoalsQuery, id); unlinkGoalsError != nil {
+		return unlinkGoalsError
+	}
+
+	for _, table := range clientAccountChildTables {
+		query := "DELETE FROM everytrack_backend." + table + " WHERE account_id IN (SELECT id FROM everytrack_backend.account WHERE " + column + " = $1);"
+		if _, deleteError := tx.Exec(ctx, query, id); deleteError != nil {
+			return deleteError
+		}
+	}
+
+	for _, table := range workspaceRecordTables {
+		query := "DELETE FROM everytrack_backend." + table + " WHERE " + column + " = $1;"
+		if _, deleteError := tx.Exec(ctx, query, id); deleteError != nil {
+			return deleteError
+		}
+	}
+
+	// Delete accounts together with their asset_provider_account_type since it's 1-1 relationship
+	accountTypeIds := []string{}
+	deleteAccountsQuery := "DELETE FROM everytrack_backend.account WHERE " + column + " = $1 RETURNING asset_provider_account_type_id;"
+	rows, deleteAccountsError := tx.Query(ctx, deleteAccountsQuery, id)
+	if deleteAccountsError != nil {
+		return deleteAccountsError
+	}
+	for rows.Next() {
+		var accountTypeId string
+		if scanError := rows.Scan(&accountTypeId); scanError != nil {
+			rows.Close()
+			return scanError
+		}
+		accountTypeIds = append(accountTypeIds, accountTypeId)
+	}
+	rows.Close()
+	if rowsError := rows.Err(); rowsError != nil {
+		return rowsError
+	}
+
+	deleteAccountTypesQuery := "DELETE FROM everytrack_backend.asset_provider_account_type WHERE id = ANY($1);"
+	if _, deleteAccountTypesError := tx.Exec(ctx, deleteAccountTypesQuery, accountTypeIds); deleteAccountTypesError != nil {
+		return deleteAccountTypesError
+	}
+
+	return nil
+}
+
+// Remove client from a shared workspace, promoting the longest standing member if client was its last owner
+// and handing the records client created over to an owner so that they survive the erasure
+func handOverWorkspace(ctx context.Context, tx pgx.Tx, workspaceId string, clientId string) error {
+	removeMemberQuery := "DELETE FROM everytrack_backend.workspace_member WHERE workspace_id = $1 AND client_id = $2;"
+	if _, removeMemberError := tx.Exec(ctx, removeMemberQuery, workspaceId, clientId); removeMemberError != nil {
+		return removeMemberError
+	}
+
+	promoteQuery := `UPDATE everytrack_backend.workspace_member SET role = 'owner'
+	WHERE workspace_id = $1 AND client_id = (
+		SELECT client_id FROM everytrack_backend.workspace_member WHERE workspace_id = $1 ORDER BY created_at LIMIT 1
+	) AND NOT EXISTS (
+		SELECT 1 FROM everytrack_backend.workspace_member WHERE workspace_id = $1 AND role = 'owner'
+	);`
+	if _, promoteError := tx.Exec(ctx, promoteQuery, workspaceId); promoteError != nil {
+		return promoteError
+	}
+
+	var successorId string
+	getSuccessorQuery := "SELECT client_id FROM everytrack_backend.workspace_member WHERE workspace_id = $1 AND role = 'owner' ORDER BY created_at LIMIT 1;"
+	if getSuccessorError := tx.QueryRow(ctx, getSuccessorQuery, workspaceId).Scan(&successorId); getSuccessorError != nil {
+		return getSuccessorError
+	}
+
+	for _, table := range append([]string{"account"}, workspaceRecordTables...) {
+		query := "UPDATE everytrack_backend." + table + " SET client_id = $1 WHERE workspace_id = $2 AND client_id = $3;"
+		if _, reassignError := tx.Exec(ctx, query, successorId, workspaceId, clientId); reassignError != nil {
+			return reassignError
+		}
+	}
+
+	return nil
+}
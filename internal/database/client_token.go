@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreateNewClientTokenParams struct {
+	ClientId  string    `json:"client_id"`
+	Purpose   string    `json:"purpose"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Issue a new one-time token, voiding every unused token of the same purpose previously issued to the client
+func CreateNewClientToken(ctx context.Context, db *pgxpool.Pool, params CreateNewClientTokenParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	voidQuery := "UPDATE everytrack_backend.client_token SET used_at = now() WHERE client_id = $1 AND purpose = $2 AND used_at IS NULL;"
+	if _, voidError := tx.Exec(ctx, voidQuery, params.ClientId, params.Purpose); voidError != nil {
+		return false, voidError
+	}
+
+	createQuery := "INSERT INTO everytrack_backend.client_token (client_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4);"
+	if _, createError := tx.Exec(ctx, createQuery, params.ClientId, params.Purpose, params.TokenHash, params.ExpiresAt); createError != nil {
+		return false, createError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Mark a valid token as used and return the client it was issued to.
+// Returns pgx.ErrNoRows if the token does not exist, has expired or has been used already.
+func consumeClientToken(ctx context.Context, tx pgx.Tx, tokenHash string, purpose string) (string, error) {
+	var clientId string
+	query := `UPDATE everytrack_backend.client_token SET used_at = now()
+	WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+	RETURNING client_id;`
+	queryError := tx.QueryRow(ctx, query, tokenHash, purpose).Scan(&clientId)
+
+	if queryError != nil {
+		return clientId, queryError
+	}
+
+	return clientId, nil
+}
+
+func VerifyClientEmail(ctx context.Context, db *pgxpool.Pool, tokenHash string) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	clientId, consumeError := consumeClientToken(ctx, tx, tokenHash, "email-verification")
+	if consumeError != nil {
+		return "", consumeError
+	}
+
+	verifyQuery := "UPDATE everytrack_backend.client SET verified = true, updated_at = now() WHERE id = $1;"
+	if _, verifyError := tx.Exec(ctx, verifyQuery, clientId); verifyError != nil {
+		return "", verifyError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return clientId, nil
+}
+
+// Replace the password of the client owning the token and revoke all of its sessions and api tokens
+func ResetClientPassword(ctx context.Context, db *pgxpool.Pool, tokenHash string, passwordHash string) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	clientId, consumeError := consumeClientToken(ctx, tx, tokenHash, "password-reset")
+	if consumeError != nil {
+		return "", consumeError
+	}
+
+	// Receiving the reset mail proves ownership of the email address as well
+	updatePasswordQuery := "UPDATE everytrack_backend.client SET password = $1, verified = true, updated_at = now() WHERE id = $2;"
+	if _, updatePasswordError := tx.Exec(ctx, updatePasswordQuery, passwordHash, clientId); updatePasswordError != nil {
+		return "", updatePasswordError
+	}
+
+	revokeSessionsQuery := "UPDATE everytrack_backend.session SET revoked_at = now() WHERE client_id = $1 AND revoked_at IS NULL;"
+	if _, revokeSessionsError := tx.Exec(ctx, revokeSessionsQuery, clientId); revokeSessionsError != nil {
+		return "", revokeSessionsError
+	}
+
+	// Tokens created by whoever knew the old password must not outlive it either
+	revokeApiTokensQuery := "UPDATE everytrack_backend.api_token SET revoked_at = now() WHERE client_id = $1 AND revoked_at IS NULL;"
+	if _, revokeApiTokensError := tx.Exec(ctx, revokeApiTokensQuery, clientId); revokeApiTokensError != nil {
+		return "", revokeApiTokensError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return clientId, nil
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func GetClientTotp(ctx context.Context, db *pgxpool.Pool, clientId string) (ClientTotp, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var clientTotp ClientTotp
+	query := "SELECT client_id, secret, last_used_step, confirmed_at, created_at FROM everytrack_backend.client_totp WHERE client_id = $1;"
+	queryError := db.QueryRow(ctx, query, clientId).Scan(
+		&clientTotp.ClientId,
+		&clientTotp.Secret,
+		&clientTotp.LastUsedStep,
+		&clientTotp.ConfirmedAt,
+		&clientTotp.CreatedAt,
+	)
+
+	if queryError != nil {
+		return clientTotp, queryError
+	}
+
+	return clientTotp, nil
+}
+
+// Store a pending TOTP secret awaiting confirmation, returns false if client has 2FA enabled already
+func UpsertPendingClientTotp(ctx context.Context, db *pgxpool.Pool, clientId string, secret string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO everytrack_backend.client_totp (client_id, secret) VALUES ($1, $2)
+	ON CONFLICT (client_id) DO UPDATE SET secret = excluded.secret, last_used_step = 0, created_at = now()
+	WHERE client_totp.confirmed_at IS NULL;`
+	result, upsertError := db.Exec(ctx, query, clientId, secret)
+
+	if upsertError != nil {
+		return false, upsertError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, clientId string, codeHashes []string) error {
+	deleteQuery := "DELETE FROM everytrack_backend.recovery_code WHERE client_id = $1;"
+	if _, deleteError := tx.Exec(ctx, deleteQuery, clientId); deleteError != nil {
+		return deleteError
+	}
+
+	for _, codeHash := range codeHashes {
+		insertQuery := "INSERT INTO everytrack_backend.recovery_code (client_id, code_hash) VALUES ($1, $2);"
+		if _, insertError := tx.Exec(ctx, insertQuery, clientId, codeHash); insertError != nil {
+			return insertError
+		}
+	}
+
+	return nil
+}
+
+// Enable 2FA by confirming the pending secret with the time step of the first valid code and issuing recovery codes
+func ConfirmClientTotp(ctx context.Context, db *pgxpool.Pool, clientId string, step int64, codeHashes []string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	confirmQuery := "UPDATE everytrack_backend.client_totp SET confirmed_at = now(), last_used_step = $1 WHERE client_id = $2 AND confirmed_at IS NULL;"
+	result, confirmError := tx.Exec(ctx, confirmQuery, step, clientId)
+	if confirmError != nil {
+		return false, confirmError
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if replaceError := replaceRecoveryCodes(ctx, tx, clientId, codeHashes); replaceError != nil {
+		return false, replaceError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+func RegenerateRecoveryCodes(ctx context.Context, db *pgxpool.Pool, clientId string, codeHashes []string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	if replaceError := replaceRecoveryCodes(ctx, tx, clientId, codeHashes); replaceError != nil {
+		return false, replaceError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Record the time step of an accepted code, returns false if the same or a later step has been used already
+func UseClientTotpStep(ctx context.Context, db *pgxpool.Pool, clientId string, step int64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.client_totp SET last_used_step = $1 WHERE client_id = $2 AND last_used_step < $1;"
+	result, updateError := db.Exec(ctx, query, step, clientId)
+
+	if updateError != nil {
+		return false, updateError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// Consume a recovery code, returns false if it does not exist or has been used already
+func UseRecoveryCode(ctx context.Context, db *pgxpool.Pool, clientId string, codeHash string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.recovery_code SET used_at = now() WHERE client_id = $1 AND code_hash = $2 AND used_at IS NULL;"
+	result, updateError := db.Exec(ctx, query, clientId, codeHash)
+
+	if updateError != nil {
+		return false, updateError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func CountUnusedRecoveryCodes(ctx context.Context, db *pgxpool.Pool, clientId string) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var count int
+	query := "SELECT COUNT(*) FROM everytrack_backend.recovery_code WHERE client_id = $1 AND used_at IS NULL;"
+	queryError := db.QueryRow(ctx, query, clientId).Scan(&count)
+
+	if queryError != nil {
+		return count, queryError
+	}
+
+	return count, nil
+}
+
+func DeleteClientTotp(ctx context.Context, db *pgxpool.Pool, clientId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	if replaceError := replaceRecoveryCodes(ctx, tx, clientId, []string{}); replaceError != nil {
+		return false, replaceError
+	}
+
+	deleteQuery := "DELETE FROM everytrack_backend.client_totp WHERE client_id = $1;"
+	if _, deleteError := tx.Exec(ctx, deleteQuery, clientId); deleteError != nil {
+		return false, deleteError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
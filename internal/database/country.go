@@ -6,10 +6,13 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func GetAllCountries(db *pgxpool.Pool) ([]Country, error) {
+func GetAllCountries(ctx context.Context, db *pgxpool.Pool) ([]Country, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	countries := []Country{}
 	query := `SELECT id, name, code FROM everytrack_backend.country;`
-	rows, queryError := db.Query(context.Background(), query)
+	rows, queryError := db.Query(ctx, query)
 	if queryError != nil {
 		return countries, queryError
 	}
@@ -28,10 +31,13 @@ func GetAllCountries(db *pgxpool.Pool) ([]Country, error) {
 	return countries, nil
 }
 
-func GetCountryByCode(db *pgxpool.Pool, code string) (Country, error) {
+func GetCountryByCode(ctx context.Context, db *pgxpool.Pool, code string) (Country, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var country Country
 	query := `SELECT id, name, code FROM everytrack_backend.country WHERE code = $1;`
-	queryError := db.QueryRow(context.Background(), query, code).Scan(&country.Id, &country.Name, &country.Code)
+	queryError := db.QueryRow(ctx, query, code).Scan(&country.Id, &country.Name, &country.Code)
 	if queryError != nil {
 		return country, queryError
 	}
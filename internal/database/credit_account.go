@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreditAccountDetails struct {
+	Id                 string         `json:"id"`
+	Name               string         `json:"name"`
+	Balance            string         `json:"balance"`
+	ClientId           string         `json:"clientId"`
+	WorkspaceId        string         `json:"workspaceId"`
+	CurrencyId         string         `json:"currencyId"`
+	CreditLimit        string         `json:"creditLimit"`
+	StatementDay       int            `json:"statementDay"`
+	DueDay             int            `json:"dueDay"`
+	Apr                string         `json:"apr"`
+	RepaymentAccountId sql.NullString `json:"repaymentAccountId"`
+}
+
+type UpsertCreditAccountParams struct {
+	AccountId          string  `json:"account_id"`
+	CreditLimit        string  `json:"credit_limit"`
+	StatementDay       int     `json:"statement_day"`
+	DueDay             int     `json:"due_day"`
+	Apr                string  `json:"apr"`
+	RepaymentAccountId *string `json:"repayment_account_id"`
+}
+
+type CreateNewCreditStatementParams struct {
+	Name               string    `json:"name"`
+	Balance            string    `json:"balance"`
+	ClientId           string    `json:"client_id"`
+	WorkspaceId        string    `json:"workspace_id"`
+	AccountId          string    `json:"account_id"`
+	RepaymentAccountId string    `json:"repayment_account_id"`
+	CurrencyId         string    `json:"currency_id"`
+	PeriodStart        time.Time `json:"period_start"`
+	PeriodEnd          time.Time `json:"period_end"`
+	DueAt              time.Time `json:"due_at"`
+}
+
+const creditAccountDetailsQuery = `SELECT a.id, apat.name, a.balance, a.client_id, a.workspace_id, a.currency_id, ca.credit_limit, ca.statement_day, ca.due_day, ca.apr, ca.repayment_account_id
+	FROM everytrack_backend.account AS a
+	INNER JOIN everytrack_backend.credit_account AS ca ON ca.account_id = a.id
+	INNER JOIN everytrack_backend.asset_provider_account_type AS apat ON a.asset_provider_account_type_id = apat.id`
+
+func scanCreditAccountDetails(ctx context.Context, db *pgxpool.Pool, query string, args ...any) ([]CreditAccountDetails, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	creditAccounts := []CreditAccountDetails{}
+	rows, queryError := db.Query(ctx, query, args...)
+	if queryError != nil {
+		return creditAccounts, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var details CreditAccountDetails
+		scanError := rows.Scan(
+			&details.Id,
+			&details.Name,
+			&details.Balance,
+			&details.ClientId,
+			&details.WorkspaceId,
+			&details.CurrencyId,
+			&details.CreditLimit,
+			&details.StatementDay,
+			&details.DueDay,
+			&details.Apr,
+			&details.RepaymentAccountId,
+		)
+		if scanError != nil {
+			return []CreditAccountDetails{}, scanError
+		}
+		creditAccounts = append(creditAccounts, details)
+	}
+
+	return creditAccounts, nil
+}
+
+func GetAllCreditAccounts(ctx context.Context, db *pgxpool.Pool) ([]CreditAccountDetails, error) {
+	return scanCreditAccountDetails(ctx, db, creditAccountDetailsQuery+";")
+}
+
+func GetAllCreditAccountsByWorkspaceId(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]CreditAccountDetails, error) {
+	return scanCreditAccountDetails(ctx, db, creditAccountDetailsQuery+" WHERE a.workspace_id = $1;", workspaceId)
+}
+
+func GetCreditAccount(ctx context.Context, db *pgxpool.Pool, accountId string) (CreditAccount, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var creditAccount CreditAccount
+	query := `SELECT account_id, credit_limit, statement_day, due_day, apr, repayment_account_id, created_at, updated_at FROM everytrack_backend.credit_account WHERE account_id = $1;`
+	queryError := db.QueryRow(ctx, query, accountId).Scan(
+		&creditAccount.AccountId,
+		&creditAccount.CreditLimit,
+		&creditAccount.StatementDay,
+		&creditAccount.DueDay,
+		&creditAccount.Apr,
+		&creditAccount.RepaymentAccountId,
+		&creditAccount.CreatedAt,
+		&creditAccount.UpdatedAt,
+	)
+	if queryError != nil {
+		return creditAccount, queryError
+	}
+
+	return creditAccount, nil
+}
+
+func UpsertCreditAccount(ctx context.Context, db *pgxpool.Pool, params UpsertCreditAccountParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO everytrack_backend.credit_account (account_id, credit_limit, statement_day, due_day, apr, repayment_account_id) VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (account_id) DO UPDATE SET credit_limit = EXCLUDED.credit_limit, statement_day = EXCLUDED.statement_day, due_day = EXCLUDED.due_day, apr = EXCLUDED.apr, repayment_account_id = EXCLUDED.repayment_account_id, updated_at = now();`
+	_, upsertError := db.Exec(ctx, query, params.AccountId, params.CreditLimit, params.StatementDay, params.DueDay, params.Apr, params.RepaymentAccountId)
+
+	if upsertError != nil {
+		return false, upsertError
+	}
+
+	return true, nil
+}
+
+func GetAllCreditStatements(ctx context.Context, db *pgxpool.Pool, accountId string) ([]CreditStatement, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statements := []CreditStatement{}
+	query := `SELECT id, account_id, future_payment_id, balance, period_start, period_end, due_at, created_at
+	FROM everytrack_backend.credit_statement
+	WHERE account_id = $1
+	ORDER BY period_end DESC;`
+	rows, queryError := db.Query(ctx, query, accountId)
+	if queryError != nil {
+		return statements, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var statement CreditStatement
+		scanError := rows.Scan(
+			&statement.Id,
+			&statement.AccountId,
+			&statement.FuturePaymentId,
+			&statement.Balance,
+			&statement.PeriodStart,
+			&statement.PeriodEnd,
+			&statement.DueAt,
+			&statement.CreatedAt,
+		)
+		if scanError != nil {
+			return statements, scanError
+		}
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+func CheckExistingCreditStatement(ctx context.Context, db *pgxpool.Pool, accountId string, periodEnd time.Time) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var existingRowCount int
+	query := `SELECT count(*) FROM everytrack_backend.credit_statement WHERE account_id = $1 AND period_end = $2;`
+	queryError := db.QueryRow(ctx, query, accountId, periodEnd).Scan(&existingRowCount)
+	if queryError != nil {
+		return false, queryError
+	}
+
+	return existingRowCount > 0, nil
+}
+
+// Record a closed statement and schedule the repayment of its balance on due date in a single database transaction.
+// The repayment is a transfer from the repayment account, which must belong to the same workspace as the credit account.
+// No repayment is scheduled when nothing is owed for the statement period, the statement is left as a reminder only.
+func CreateNewCreditStatement(ctx context.Context, db *pgxpool.Pool, params CreateNewCreditStatementParams, withRepayment bool) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var futurePaymentId *string
+	if withRepayment {
+		if checkError := checkAccountsInWorkspace(ctx, tx, params.WorkspaceId, params.AccountId, params.RepaymentAccountId); checkError != nil {
+			return false, checkError
+		}
+
+		var id string
+		createFuturePaymentQuery := `INSERT INTO everytrack_backend.future_payment (client_id, workspace_id, account_id, source_account_id, currency_id, name, amount, income, rolling, category, scheduled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, false, 'credit-repayment', $8) RETURNING id;`
+		createFuturePaymentError := tx.QueryRow(
+			ctx,
+			createFuturePaymentQuery,
+			params.ClientId,
+			params.WorkspaceId,
+			params.AccountId,
+			params.RepaymentAccountId,
+			params.CurrencyId,
+			params.Name,
+			params.Balance,
+			params.DueAt,
+		).Scan(&id)
+		if createFuturePaymentError != nil {
+			return false, createFuturePaymentError
+		}
+		futurePaymentId = &id
+	}
+
+	createStatementQuery := `INSERT INTO everytrack_backend.credit_statement (account_id, future_payment_id, balance, period_start, period_end, due_at) VALUES ($1, $2, $3, $4, $5, $6);`
+	_, createStatementError := tx.Exec(
+		ctx,
+		createStatementQuery,
+		params.AccountId,
+		futurePaymentId,
+		params.Balance,
+		params.PeriodStart,
+		params.PeriodEnd,
+		params.DueAt,
+	)
+	if createStatementError != nil {
+		return false, createStatementError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
@@ -6,10 +6,13 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func GetAllCurrencies(db *pgxpool.Pool) ([]Currency, error) {
+func GetAllCurrencies(ctx context.Context, db *pgxpool.Pool) ([]Currency, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var currencies []Currency
 	query := `SELECT id, ticker, symbol FROM everytrack_backend.currency;`
-	rows, queryError := db.Query(context.Background(), query)
+	rows, queryError := db.Query(ctx, query)
 	if queryError != nil {
 		return []Currency{}, queryError
 	}
@@ -28,10 +31,13 @@ func GetAllCurrencies(db *pgxpool.Pool) ([]Currency, error) {
 	return currencies, nil
 }
 
-func GetDefaultCurrency(db *pgxpool.Pool) (string, error) {
+func GetDefaultCurrency(ctx context.Context, db *pgxpool.Pool) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var id string
 	query := `SELECT id FROM everytrack_backend.currency WHERE ticker = $1;`
-	queryError := db.QueryRow(context.Background(), query, "GBP").Scan(&id)
+	queryError := db.QueryRow(ctx, query, "GBP").Scan(&id)
 	if queryError != nil {
 		return id, queryError
 	}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -12,10 +13,13 @@ type UpdateExchangeRateParams struct {
 	TargetCurrencyId string `json:"target_currency_id"`
 }
 
-func GetAllExchangeRates(db *pgxpool.Pool) ([]ExchangeRate, error) {
+func GetAllExchangeRates(ctx context.Context, db *pgxpool.Pool) ([]ExchangeRate, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var exchangeRates []ExchangeRate
 	query := `SELECT base_currency_id, target_currency_id, rate FROM everytrack_backend.exchange_rate;`
-	rows, queryError := db.Query(context.Background(), query)
+	rows, queryError := db.Query(ctx, query)
 	if queryError != nil {
 		return []ExchangeRate{}, queryError
 	}
@@ -34,24 +38,27 @@ func GetAllExchangeRates(db *pgxpool.Pool) ([]ExchangeRate, error) {
 	return exchangeRates, nil
 }
 
-func UpdateExchangeRate(db *pgxpool.Pool, params UpdateExchangeRateParams) (bool, error) {
+func UpdateExchangeRate(ctx context.Context, db *pgxpool.Pool, params UpdateExchangeRateParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var existingRowCount int
 	query := `SELECT count(*) FROM everytrack_backend.exchange_rate WHERE base_currency_id = $1 AND target_currency_id = $2;`
-	queryError := db.QueryRow(context.Background(), query, params.BaseCurrencyId, params.TargetCurrencyId).Scan(&existingRowCount)
+	queryError := db.QueryRow(ctx, query, params.BaseCurrencyId, params.TargetCurrencyId).Scan(&existingRowCount)
 	if queryError != nil {
 		return false, queryError
 	}
 
 	if existingRowCount > 0 {
-		updateQuery := `UPDATE everytrack_backend.exchange_rate SET rate = $1 WHERE base_currency_id = $2 AND target_currency_id = $3;`
-		_, updateError := db.Exec(context.Background(), updateQuery, params.Rate, params.BaseCurrencyId, params.TargetCurrencyId)
+		updateQuery := `UPDATE everytrack_backend.exchange_rate SET rate = $1, updated_at = now() WHERE base_currency_id = $2 AND target_currency_id = $3;`
+		_, updateError := db.Exec(ctx, updateQuery, params.Rate, params.BaseCurrencyId, params.TargetCurrencyId)
 
 		if updateError != nil {
 			return false, updateError
 		}
 	} else {
 		createQuery := `INSERT INTO everytrack_backend.exchange_rate (base_currency_id, target_currency_id, rate) VALUES ($1, $2, $3);`
-		_, createError := db.Exec(context.Background(), createQuery, params.BaseCurrencyId, params.TargetCurrencyId, params.Rate)
+		_, createError := db.Exec(ctx, createQuery, params.BaseCurrencyId, params.TargetCurrencyId, params.Rate)
 
 		if createError != nil {
 			return false, createError
@@ -60,3 +67,18 @@ func UpdateExchangeRate(db *pgxpool.Pool, params UpdateExchangeRateParams) (bool
 
 	return true, nil
 }
+
+// Returns when exchange rates were last refreshed, invalid if there is no exchange rate at all
+func GetLatestExchangeRateUpdate(ctx context.Context, db *pgxpool.Pool) (sql.NullTime, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var updatedAt sql.NullTime
+	query := `SELECT max(updated_at) FROM everytrack_backend.exchange_rate;`
+	queryError := db.QueryRow(ctx, query).Scan(&updatedAt)
+	if queryError != nil {
+		return updatedAt, queryError
+	}
+
+	return updatedAt, nil
+}
@@ -17,6 +17,7 @@ type CreateNewFuturePaymentParams struct {
 	Category    string    `json:"category"`
 	Frequency   *int64    `json:"frequency"`
 	ClientId    string    `json:"client_id"`
+	WorkspaceId string    `json:"workspace_id"`
 	AccountId   string    `json:"account_id"`
 	CurrencyId  string    `json:"currency_id"`
 	ScheduledAt time.Time `json:"scheduled_at"`
@@ -33,13 +34,17 @@ type UpdateFuturePaymentParams struct {
 	Frequency   *int64    `json:"frequency"`
 	AccountId   string    `json:"account_id"`
 	CurrencyId  string    `json:"currency_id"`
+	WorkspaceId string    `json:"workspace_id"`
 	ScheduledAt time.Time `json:"scheduled_at"`
 }
 
-func GetAllFuturePayments(db *pgxpool.Pool) ([]FuturePayment, error) {
+func GetAllFuturePayments(ctx context.Context, db *pgxpool.Pool) ([]FuturePayment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	futurePayments := []FuturePayment{}
-	query := `SELECT id, client_id, account_id, currency_id, name, amount, income, rolling, category, frequency, remarks, scheduled_at FROM everytrack_backend.future_payment;`
-	rows, queryError := db.Query(context.Background(), query)
+	query := `SELECT id, client_id, workspace_id, account_id, currency_id, name, amount, income, rolling, category, frequency, remarks, scheduled_at, source_account_id, ends_at FROM everytrack_backend.future_payment;`
+	rows, queryError := db.Query(ctx, query)
 	if queryError != nil {
 		return futurePayments, queryError
 	}
@@ -51,6 +56,7 @@ func GetAllFuturePayments(db *pgxpool.Pool) ([]FuturePayment, error) {
 		scanError := rows.Scan(
 			&futurePayment.Id,
 			&futurePayment.ClientId,
+			&futurePayment.WorkspaceId,
 			&futurePayment.AccountId,
 			&futurePayment.CurrencyId,
 			&futurePayment.Name,
@@ -61,6 +67,8 @@ func GetAllFuturePayments(db *pgxpool.Pool) ([]FuturePayment, error) {
 			&futurePayment.Frequency,
 			&futurePayment.Remarks,
 			&futurePayment.ScheduledAt,
+			&futurePayment.SourceAccountId,
+			&futurePayment.EndsAt,
 		)
 		if scanError != nil {
 			return futurePayments, scanError
@@ -71,10 +79,13 @@ func GetAllFuturePayments(db *pgxpool.Pool) ([]FuturePayment, error) {
 	return futurePayments, nil
 }
 
-func GetAllFuturePaymentsByClientId(db *pgxpool.Pool, clientId string) ([]FuturePayment, error) {
+func GetAllFuturePaymentsByWorkspaceId(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]FuturePayment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	futurePayments := []FuturePayment{}
-	query := `SELECT id, account_id, currency_id, name, amount, income, rolling, category, frequency, remarks, scheduled_at FROM everytrack_backend.future_payment WHERE client_id = $1;`
-	rows, queryError := db.Query(context.Background(), query, clientId)
+	query := `SELECT id, account_id, currency_id, name, amount, income, rolling, category, frequency, remarks, scheduled_at, source_account_id, ends_at FROM everytrack_backend.future_payment WHERE workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
 	if queryError != nil {
 		return futurePayments, queryError
 	}
@@ -95,6 +106,8 @@ func GetAllFuturePaymentsByClientId(db *pgxpool.Pool, clientId string) ([]Future
 			&futurePayment.Frequency,
 			&futurePayment.Remarks,
 			&futurePayment.ScheduledAt,
+			&futurePayment.SourceAccountId,
+			&futurePayment.EndsAt,
 		)
 		if scanError != nil {
 			return futurePayments, scanError
@@ -105,12 +118,31 @@ func GetAllFuturePaymentsByClientId(db *pgxpool.Pool, clientId string) ([]Future
 	return futurePayments, nil
 }
 
-func CreateNewFuturePayment(db *pgxpool.Pool, params CreateNewFuturePaymentParams) (bool, error) {
-	query := "INSERT INTO everytrack_backend.future_payment (client_id, account_id, currency_id, name, amount, income, rolling, category, frequency, remarks, scheduled_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);"
+// Count future payments of all clients whose schedule has passed and are waiting to be executed
+func CountDueFuturePayments(ctx context.Context, db *pgxpool.Pool) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var count int64
+	query := `SELECT count(*) FROM everytrack_backend.future_payment WHERE scheduled_at < now();`
+	queryError := db.QueryRow(ctx, query).Scan(&count)
+	if queryError != nil {
+		return count, queryError
+	}
+
+	return count, nil
+}
+
+func CreateNewFuturePayment(ctx context.Context, db *pgxpool.Pool, params CreateNewFuturePaymentParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO everytrack_backend.future_payment (client_id, workspace_id, account_id, currency_id, name, amount, income, rolling, category, frequency, remarks, scheduled_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);"
 	_, createError := db.Exec(
-		context.Background(),
+		ctx,
 		query,
 		params.ClientId,
+		params.WorkspaceId,
 		params.AccountId,
 		params.CurrencyId,
 		params.Name,
@@ -130,9 +162,12 @@ func CreateNewFuturePayment(db *pgxpool.Pool, params CreateNewFuturePaymentParam
 	return true, nil
 }
 
-func UpdateFuturePayment(db *pgxpool.Pool, params UpdateFuturePaymentParams) (bool, error) {
-	query := "UPDATE everytrack_backend.future_payment SET name = $1, income = $2, amount = $3, remarks = $4, rolling = $5, category = $6, frequency = $7, account_id = $8, currency_id = $9, scheduled_at = $10 WHERE id = $11;"
-	_, updateError := db.Exec(context.Background(), query, params.Name, params.Income, params.Amount, params.Remarks, params.Rolling, params.Category, params.Frequency, params.AccountId, params.CurrencyId, params.ScheduledAt, params.Id)
+func UpdateFuturePayment(ctx context.Context, db *pgxpool.Pool, params UpdateFuturePaymentParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.future_payment SET name = $1, income = $2, amount = $3, remarks = $4, rolling = $5, category = $6, frequency = $7, account_id = $8, currency_id = $9, scheduled_at = $10 WHERE id = $11 AND workspace_id = $12;"
+	_, updateError := db.Exec(ctx, query, params.Name, params.Income, params.Amount, params.Remarks, params.Rolling, params.Category, params.Frequency, params.AccountId, params.CurrencyId, params.ScheduledAt, params.Id, params.WorkspaceId)
 
 	if updateError != nil {
 		return false, updateError
@@ -141,9 +176,12 @@ func UpdateFuturePayment(db *pgxpool.Pool, params UpdateFuturePaymentParams) (bo
 	return true, nil
 }
 
-func UpdateFuturePaymentSchedule(db *pgxpool.Pool, scheduledAt time.Time, id string) (bool, error) {
+func UpdateFuturePaymentSchedule(ctx context.Context, db *pgxpool.Pool, scheduledAt time.Time, id string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE everytrack_backend.future_payment SET scheduled_at = $1 WHERE id = $2;"
-	_, updateError := db.Exec(context.Background(), query, scheduledAt, id)
+	_, updateError := db.Exec(ctx, query, scheduledAt, id)
 
 	if updateError != nil {
 		return false, updateError
@@ -152,9 +190,12 @@ func UpdateFuturePaymentSchedule(db *pgxpool.Pool, scheduledAt time.Time, id str
 	return true, nil
 }
 
-func DeleteFuturePayment(db *pgxpool.Pool, futurePaymentId string, clientId string) (bool, error) {
-	query := "DELETE FROM everytrack_backend.future_payment WHERE id = $1 AND client_id = $2;"
-	_, deleteError := db.Exec(context.Background(), query, futurePaymentId, clientId)
+func DeleteFuturePayment(ctx context.Context, db *pgxpool.Pool, futurePaymentId string, workspaceId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM everytrack_backend.future_payment WHERE id = $1 AND workspace_id = $2;"
+	_, deleteError := db.Exec(ctx, query, futurePaymentId, workspaceId)
 
 	if deleteError != nil {
 		return false, deleteError
@@ -0,0 +1,340 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GoalFundingParams struct {
+	Name            string    `json:"name"`
+	Amount          string    `json:"amount"`
+	Frequency       int64     `json:"frequency"`
+	AccountId       string    `json:"account_id"`
+	SourceAccountId string    `json:"source_account_id"`
+	CurrencyId      string    `json:"currency_id"`
+	ScheduledAt     time.Time `json:"scheduled_at"`
+	EndsAt          time.Time `json:"ends_at"`
+}
+
+type CreateNewGoalParams struct {
+	Name         string             `json:"name"`
+	ClientId     string             `json:"client_id"`
+	WorkspaceId  string             `json:"workspace_id"`
+	CurrencyId   string             `json:"currency_id"`
+	TargetAmount string             `json:"target_amount"`
+	Deadline     time.Time          `json:"deadline"`
+	AccountIds   []string           `json:"account_ids"`
+	Funding      *GoalFundingParams `json:"funding"`
+}
+
+type UpdateGoalParams struct {
+	Id           string             `json:"id"`
+	Name         string             `json:"name"`
+	ClientId     string             `json:"client_id"`
+	WorkspaceId  string             `json:"workspace_id"`
+	CurrencyId   string             `json:"currency_id"`
+	TargetAmount string             `json:"target_amount"`
+	Deadline     time.Time          `json:"deadline"`
+	AccountIds   []string           `json:"account_ids"`
+	Funding      *GoalFundingParams `json:"funding"`
+}
+
+func GetAllGoalsByWorkspaceId(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]Goal, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	goals := []Goal{}
+	query := `SELECT id, client_id, workspace_id, currency_id, future_payment_id, name, target_amount, deadline, created_at, updated_at
+	FROM everytrack_backend.goal
+	WHERE workspace_id = $1
+	ORDER BY deadline;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return goals, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var goal Goal
+		scanError := rows.Scan(
+			&goal.Id,
+			&goal.ClientId,
+			&goal.WorkspaceId,
+			&goal.CurrencyId,
+			&goal.FuturePaymentId,
+			&goal.Name,
+			&goal.TargetAmount,
+			&goal.Deadline,
+			&goal.CreatedAt,
+			&goal.UpdatedAt,
+		)
+		if scanError != nil {
+			return goals, scanError
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals, nil
+}
+
+func GetAllGoalAccountsByWorkspaceId(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]GoalAccount, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	goalAccounts := []GoalAccount{}
+	query := `SELECT ga.goal_id, ga.account_id
+	FROM everytrack_backend.goal_account AS ga
+	INNER JOIN everytrack_backend.goal AS g ON g.id = ga.goal_id
+	WHERE g.workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return goalAccounts, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var goalAccount GoalAccount
+		scanError := rows.Scan(&goalAccount.GoalId, &goalAccount.AccountId)
+		if scanError != nil {
+			return goalAccounts, scanError
+		}
+		goalAccounts = append(goalAccounts, goalAccount)
+	}
+
+	return goalAccounts, nil
+}
+
+// Create the rolling future payment transferring from the source account into a goal account until the deadline.
+// Both accounts must belong to the workspace of the goal. Returns its id, nil when the goal is not funded automatically.
+func createGoalFunding(ctx context.Context, tx pgx.Tx, clientId string, workspaceId string, funding *GoalFundingParams) (*string, error) {
+	if funding == nil {
+		return nil, nil
+	}
+	if checkError := checkAccountsInWorkspace(ctx, tx, workspaceId, funding.AccountId, funding.SourceAccountId); checkError != nil {
+		return nil, checkError
+	}
+
+	var id string
+	query := `INSERT INTO everytrack_backend.future_payment (client_id, workspace_id, account_id, source_account_id, currency_id, name, amount, income, rolling, category, frequency, scheduled_at, ends_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, true, true, 'savings-goal', $8, $9, $10) RETURNING id;`
+	queryError := tx.QueryRow(
+		ctx,
+		query,
+		clientId,
+		workspaceId,
+		funding.AccountId,
+		funding.SourceAccountId,
+		funding.CurrencyId,
+		funding.Name,
+		funding.Amount,
+		funding.Frequency,
+		funding.ScheduledAt,
+		funding.EndsAt,
+	).Scan(&id)
+	if queryError != nil {
+		return nil, queryError
+	}
+
+	return &id, nil
+}
+
+// Check if the goal funded by a future payment has reached its target, false if the payment does not fund any goal.
+// Linked accounts are locked until the end of the database transaction, so that the balances cannot move past the check.
+func isFundedGoalReached(ctx context.Context, tx pgx.Tx, futurePaymentId string) (bool, error) {
+	lockQuery := `SELECT a.id
+	FROM everytrack_backend.account AS a
+	INNER JOIN everytrack_backend.goal_account AS ga ON ga.account_id = a.id
+	INNER JOIN everytrack_backend.goal AS g ON g.id = ga.goal_id
+	WHERE g.future_payment_id = $1
+	ORDER BY a.id
+	FOR UPDATE OF a;`
+	if _, lockError := tx.Exec(ctx, lockQuery, futurePaymentId); lockError != nil {
+		return false, lockError
+	}
+
+	// Balances are converted into the goal currency, a missing exchange rate fails the check instead of counting as zero
+	var isReached bool
+	var missingRateCount int
+	query := `SELECT coalesce(sum(a.balance * CASE WHEN a.currency_id = g.currency_id THEN 1 ELSE er.rate END), 0) >= g.target_amount,
+	count(a.id) FILTER (WHERE a.currency_id <> g.currency_id AND er.rate IS NULL)
+	FROM everytrack_backend.goal AS g
+	LEFT JOIN everytrack_backend.goal_account AS ga ON ga.goal_id = g.id
+	LEFT JOIN everytrack_backend.account AS a ON a.id = ga.account_id
+	LEFT JOIN everytrack_backend.exchange_rate AS er ON er.base_currency_id = a.currency_id AND er.target_currency_id = g.currency_id
+	WHERE g.future_payment_id = $1
+	GROUP BY g.id, g.target_amount;`
+	queryError := tx.QueryRow(ctx, query, futurePaymentId).Scan(&isReached, &missingRateCount)
+	if errors.Is(queryError, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if queryError != nil {
+		return false, queryError
+	}
+	if missingRateCount > 0 {
+		return false, fmt.Errorf("missing exchange rate into goal currency for %d accounts funded by future payment %s", missingRateCount, futurePaymentId)
+	}
+
+	return isReached, nil
+}
+
+func linkGoalAccounts(ctx context.Context, tx pgx.Tx, goalId string, accountIds []string) error {
+	for _, accountId := range accountIds {
+		query := "INSERT INTO everytrack_backend.goal_account (goal_id, account_id) VALUES ($1, $2);"
+		if _, insertError := tx.Exec(ctx, query, goalId, accountId); insertError != nil {
+			return insertError
+		}
+	}
+
+	return nil
+}
+
+func CreateNewGoal(ctx context.Context, db *pgxpool.Pool, params CreateNewGoalParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	futurePaymentId, createFundingError := createGoalFunding(ctx, tx, params.ClientId, params.WorkspaceId, params.Funding)
+	if createFundingError != nil {
+		return false, createFundingError
+	}
+
+	var goalId string
+	createGoalQuery := "INSERT INTO everytrack_backend.goal (client_id, workspace_id, currency_id, future_payment_id, name, target_amount, deadline) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;"
+	createGoalError := tx.QueryRow(
+		ctx,
+		createGoalQuery,
+		params.ClientId,
+		params.WorkspaceId,
+		params.CurrencyId,
+		futurePaymentId,
+		params.Name,
+		params.TargetAmount,
+		params.Deadline,
+	).Scan(&goalId)
+	if createGoalError != nil {
+		return false, createGoalError
+	}
+
+	if linkError := linkGoalAccounts(ctx, tx, goalId, params.AccountIds); linkError != nil {
+		return false, linkError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Update a goal by replacing its linked accounts and funding future payment
+func UpdateGoal(ctx context.Context, db *pgxpool.Pool, params UpdateGoalParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var previousFuturePaymentId *string
+	getGoalQuery := "SELECT future_payment_id FROM everytrack_backend.goal WHERE id = $1 AND workspace_id = $2 FOR UPDATE;"
+	getGoalError := tx.QueryRow(ctx, getGoalQuery, params.Id, params.WorkspaceId).Scan(&previousFuturePaymentId)
+	if getGoalError != nil {
+		return false, getGoalError
+	}
+
+	futurePaymentId, createFundingError := createGoalFunding(ctx, tx, params.ClientId, params.WorkspaceId, params.Funding)
+	if createFundingError != nil {
+		return false, createFundingError
+	}
+
+	updateGoalQuery := "UPDATE everytrack_backend.goal SET currency_id = $1, future_payment_id = $2, name = $3, target_amount = $4, deadline = $5, updated_at = now() WHERE id = $6;"
+	_, updateGoalError := tx.Exec(
+		ctx,
+		updateGoalQuery,
+		params.CurrencyId,
+		futurePaymentId,
+		params.Name,
+		params.TargetAmount,
+		params.Deadline,
+		params.Id,
+	)
+	if updateGoalError != nil {
+		return false, updateGoalError
+	}
+
+	if previousFuturePaymentId != nil {
+		deleteFuturePaymentQuery := "DELETE FROM everytrack_backend.future_payment WHERE id = $1;"
+		if _, deleteError := tx.Exec(ctx, deleteFuturePaymentQuery, *previousFuturePaymentId); deleteError != nil {
+			return false, deleteError
+		}
+	}
+
+	unlinkAccountsQuery := "DELETE FROM everytrack_backend.goal_account WHERE goal_id = $1;"
+	if _, unlinkError := tx.Exec(ctx, unlinkAccountsQuery, params.Id); unlinkError != nil {
+		return false, unlinkError
+	}
+	if linkError := linkGoalAccounts(ctx, tx, params.Id, params.AccountIds); linkError != nil {
+		return false, linkError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+func DeleteGoal(ctx context.Context, db *pgxpool.Pool, goalId string, workspaceId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var futurePaymentId *string
+	getGoalQuery := "SELECT future_payment_id FROM everytrack_backend.goal WHERE id = $1 AND workspace_id = $2;"
+	getGoalError := tx.QueryRow(ctx, getGoalQuery, goalId, workspaceId).Scan(&futurePaymentId)
+	if getGoalError != nil {
+		return false, getGoalError
+	}
+
+	unlinkAccountsQuery := "DELETE FROM everytrack_backend.goal_account WHERE goal_id = $1;"
+	if _, unlinkError := tx.Exec(ctx, unlinkAccountsQuery, goalId); unlinkError != nil {
+		return false, unlinkError
+	}
+
+	deleteGoalQuery := "DELETE FROM everytrack_backend.goal WHERE id = $1;"
+	if _, deleteGoalError := tx.Exec(ctx, deleteGoalQuery, goalId); deleteGoalError != nil {
+		return false, deleteGoalError
+	}
+
+	if futurePaymentId != nil {
+		deleteFuturePaymentQuery := "DELETE FROM everytrack_backend.future_payment WHERE id = $1;"
+		if _, deleteError := tx.Exec(ctx, deleteFuturePaymentQuery, *futurePaymentId); deleteError != nil {
+			return false, deleteError
+		}
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
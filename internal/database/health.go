@@ -0,0 +1,15 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Check if database is reachable with a connection from pool
+func Ping(ctx context.Context, db *pgxpool.Pool) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return db.Ping(ctx)
+}
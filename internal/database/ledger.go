@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Create a transaction record and apply its amount on the account balance, both or neither of them are persisted
+func RecordTransaction(ctx context.Context, db *pgxpool.Pool, params CreateNewTransactionParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	if createError := createTransaction(ctx, tx, params); createError != nil {
+		return false, createError
+	}
+	if _, applyError := applyToAccountBalance(ctx, tx, params.AccountId, params.Amount, params.Income); applyError != nil {
+		return false, applyError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Record both sides of a transfer and move the amount between the account balances in a single database transaction
+func RecordTransfer(ctx context.Context, db *pgxpool.Pool, source CreateNewTransactionParams, target CreateNewTransactionParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	for _, params := range []CreateNewTransactionParams{source, target} {
+		if createError := createTransaction(ctx, tx, params); createError != nil {
+			return false, createError
+		}
+		if _, applyError := applyToAccountBalance(ctx, tx, params.AccountId, params.Amount, params.Income); applyError != nil {
+			return false, applyError
+		}
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Delete a transaction and revert its amount on the account balance.
+// Returns false if the transaction does not exist in the workspace, e.g. it has been deleted by a concurrent request.
+func DeleteTransactionAndRevertBalance(ctx context.Context, db *pgxpool.Pool, transactionId string, workspaceId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var accountId, amount string
+	var income bool
+	deleteQuery := "DELETE FROM everytrack_backend.transaction WHERE id = $1 AND workspace_id = $2 RETURNING account_id, amount, income;"
+	deleteError := tx.QueryRow(ctx, deleteQuery, transactionId, workspaceId).Scan(&accountId, &amount, &income)
+	if errors.Is(deleteError, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if deleteError != nil {
+		return false, deleteError
+	}
+
+	// Reverting an income is the same as spending the amount, and vice versa
+	if _, applyError := applyToAccountBalance(ctx, tx, accountId, amount, !income); applyError != nil {
+		return false, applyError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Apply a due future payment on the account balance, and its source account balance if any, then move it to the next schedule if given or delete it otherwise.
+// Payment funding a goal which has reached its target is deleted instead, checked within the same database transaction.
+// Returns false without touching the balance if the payment has been executed already, e.g. by an overlapping cron run, or its goal has been reached.
+func ExecuteFuturePayment(ctx context.Context, db *pgxpool.Pool, payment FuturePayment, nextScheduledAt *time.Time) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	isGoalReached := false
+	if payment.SourceAccountId.Valid {
+		var checkGoalError error
+		if isGoalReached, checkGoalError = isFundedGoalReached(ctx, tx, payment.Id); checkGoalError != nil {
+			return false, checkGoalError
+		}
+	}
+
+	// Matching the schedule the payment was read with locks the row, and finds nothing once another run has moved it on
+	var result pgconn.CommandTag
+	var updateError error
+	if nextScheduledAt != nil && !isGoalReached {
+		updateQuery := "UPDATE everytrack_backend.future_payment SET scheduled_at = $1 WHERE id = $2 AND scheduled_at = $3;"
+		result, updateError = tx.Exec(ctx, updateQuery, *nextScheduledAt, payment.Id, payment.ScheduledAt)
+	} else {
+		deleteQuery := "DELETE FROM everytrack_backend.future_payment WHERE id = $1 AND scheduled_at = $2;"
+		result, updateError = tx.Exec(ctx, deleteQuery, payment.Id, payment.ScheduledAt)
+	}
+	if updateError != nil {
+		return false, updateError
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+	if isGoalReached {
+		if commitError := tx.Commit(ctx); commitError != nil {
+			return false, commitError
+		}
+		return false, nil
+	}
+
+	if _, applyError := applyToAccountBalance(ctx, tx, payment.AccountId, payment.Amount, payment.Income); applyError != nil {
+		return false, applyError
+	}
+	// Payment funding a goal is a transfer, the amount leaves its source account as it arrives
+	if payment.SourceAccountId.Valid {
+		if _, applyError := applyToAccountBalance(ctx, tx, payment.SourceAccountId.String, payment.Amount, !payment.Income); applyError != nil {
+			return false, applyError
+		}
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+func createTransaction(ctx context.Context, tx pgx.Tx, params CreateNewTransactionParams) error {
+	query := "INSERT INTO everytrack_backend.transaction (client_id, workspace_id, account_id, currency_id, name, category, amount, income, remarks, executed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);"
+	_, createError := tx.Exec(
+		ctx,
+		query,
+		params.ClientId,
+		params.WorkspaceId,
+		params.AccountId,
+		params.CurrencyId,
+		params.Name,
+		params.Category,
+		params.Amount,
+		params.Income,
+		params.Remarks,
+		params.ExecutedAt,
+	)
+
+	return createError
+}
+
+// Add an income to, or deduct an expense from, the balance within the same statement so that concurrent changes are not lost.
+// Returns the balance after the change, or pgx.ErrNoRows if the account does not exist.
+func applyToAccountBalance(ctx context.Context, tx pgx.Tx, accountId string, amount string, income bool) (string, error) {
+	var balance string
+	query := `UPDATE everytrack_backend.account
+	SET balance = trunc(CASE WHEN $1 THEN balance + $2::numeric ELSE balance - $2::numeric END, 2)
+	WHERE id = $3
+	RETURNING balance;`
+	updateError := tx.QueryRow(ctx, query, income, amount, accountId).Scan(&balance)
+
+	return balance, updateError
+}
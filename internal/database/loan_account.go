@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LoanAccountDetails struct {
+	Id           string    `json:"id"`
+	Name         string    `json:"name"`
+	Balance      string    `json:"balance"`
+	ClientId     string    `json:"clientId"`
+	WorkspaceId  string    `json:"workspaceId"`
+	CurrencyId   string    `json:"currencyId"`
+	Principal    string    `json:"principal"`
+	AnnualRate   string    `json:"annualRate"`
+	TermInMonths int       `json:"termInMonths"`
+	StartDate    time.Time `json:"startDate"`
+}
+
+type UpsertLoanAccountParams struct {
+	AccountId    string    `json:"account_id"`
+	Principal    string    `json:"principal"`
+	AnnualRate   string    `json:"annual_rate"`
+	TermInMonths int       `json:"term_in_months"`
+	StartDate    time.Time `json:"start_date"`
+}
+
+type CreateNewLoanRepaymentParams struct {
+	Name        string    `json:"name"`
+	Amount      string    `json:"amount"`
+	Interest    string    `json:"interest"`
+	HasInterest bool      `json:"has_interest"`
+	Principal   string    `json:"principal"`
+	Extra       bool      `json:"extra"`
+	ClientId    string    `json:"client_id"`
+	WorkspaceId string    `json:"workspace_id"`
+	AccountId   string    `json:"account_id"`
+	CurrencyId  string    `json:"currency_id"`
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+func GetAllLoanAccountsByWorkspaceId(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]LoanAccountDetails, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	loanAccounts := []LoanAccountDetails{}
+	query := `SELECT a.id, apat.name, a.balance, a.client_id, a.workspace_id, a.currency_id, la.principal, la.annual_rate, la.term_in_months, la.start_date
+	FROM everytrack_backend.account AS a
+	INNER JOIN everytrack_backend.loan_account AS la ON la.account_id = a.id
+	INNER JOIN everytrack_backend.asset_provider_account_type AS apat ON a.asset_provider_account_type_id = apat.id
+	WHERE a.workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return loanAccounts, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var details LoanAccountDetails
+		scanError := rows.Scan(
+			&details.Id,
+			&details.Name,
+			&details.Balance,
+			&details.ClientId,
+			&details.WorkspaceId,
+			&details.CurrencyId,
+			&details.Principal,
+			&details.AnnualRate,
+			&details.TermInMonths,
+			&details.StartDate,
+		)
+		if scanError != nil {
+			return []LoanAccountDetails{}, scanError
+		}
+		loanAccounts = append(loanAccounts, details)
+	}
+
+	return loanAccounts, nil
+}
+
+// Create or update loan details of an account, booking the drawdown into the ledger if given and the loan details are new
+func UpsertLoanAccount(ctx context.Context, db *pgxpool.Pool, params UpsertLoanAccountParams, drawdown *CreateNewTransactionParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	// xmax is only zero for rows inserted rather than updated, so that concurrent first updates cannot book the drawdown twice
+	var isInserted bool
+	query := `INSERT INTO everytrack_backend.loan_account (account_id, principal, annual_rate, term_in_months, start_date) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (account_id) DO UPDATE SET principal = EXCLUDED.principal, annual_rate = EXCLUDED.annual_rate, term_in_months = EXCLUDED.term_in_months, start_date = EXCLUDED.start_date, updated_at = now()
+	RETURNING xmax = 0;`
+	upsertError := tx.QueryRow(ctx, query, params.AccountId, params.Principal, params.AnnualRate, params.TermInMonths, params.StartDate).Scan(&isInserted)
+	if upsertError != nil {
+		return false, upsertError
+	}
+
+	if drawdown != nil && isInserted {
+		if createError := createTransaction(ctx, tx, *drawdown); createError != nil {
+			return false, createError
+		}
+		if _, applyError := applyToAccountBalance(ctx, tx, drawdown.AccountId, drawdown.Amount, drawdown.Income); applyError != nil {
+			return false, applyError
+		}
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+func GetAllLoanRepayments(ctx context.Context, db *pgxpool.Pool, accountId string) ([]LoanRepayment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	repayments := []LoanRepayment{}
+	query := `SELECT id, account_id, amount, interest, principal, extra, executed_at
+	FROM everytrack_backend.loan_repayment
+	WHERE account_id = $1
+	ORDER BY executed_at DESC;`
+	rows, queryError := db.Query(ctx, query, accountId)
+	if queryError != nil {
+		return repayments, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var repayment LoanRepayment
+		scanError := rows.Scan(
+			&repayment.Id,
+			&repayment.AccountId,
+			&repayment.Amount,
+			&repayment.Interest,
+			&repayment.Principal,
+			&repayment.Extra,
+			&repayment.ExecutedAt,
+		)
+		if scanError != nil {
+			return repayments, scanError
+		}
+		repayments = append(repayments, repayment)
+	}
+
+	return repayments, nil
+}
+
+// Record a loan repayment in the ledger within a single database transaction.
+// The interest portion is booked as an expense and the full amount as an income of the loan account,
+// so that the account balance only moves by the principal portion. Returns the balance after the repayment.
+func CreateNewLoanRepayment(ctx context.Context, db *pgxpool.Pool, params CreateNewLoanRepaymentParams) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	repaymentTransactions := []CreateNewTransactionParams{}
+	if params.HasInterest {
+		repaymentTransactions = append(repaymentTransactions, CreateNewTransactionParams{
+			Name:        params.Name + " interest",
+			Income:      false,
+			Amount:      params.Interest,
+			Category:    "loan-interest",
+			ClientId:    params.ClientId,
+			WorkspaceId: params.WorkspaceId,
+			AccountId:   params.AccountId,
+			CurrencyId:  params.CurrencyId,
+			ExecutedAt:  params.ExecutedAt,
+		})
+	}
+	repaymentTransactions = append(repaymentTransactions, CreateNewTransactionParams{
+		Name:        params.Name,
+		Income:      true,
+		Amount:      params.Amount,
+		Category:    "loan-repayment",
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		AccountId:   params.AccountId,
+		CurrencyId:  params.CurrencyId,
+		ExecutedAt:  params.ExecutedAt,
+	})
+	for _, transaction := range repaymentTransactions {
+		if createError := createTransaction(ctx, tx, transaction); createError != nil {
+			return "", createError
+		}
+	}
+
+	// Both transactions net off to the principal portion, which is applied as a single change on the balance
+	balance, applyError := applyToAccountBalance(ctx, tx, params.AccountId, params.Principal, true)
+	if applyError != nil {
+		return "", applyError
+	}
+
+	createRepaymentQuery := "INSERT INTO everytrack_backend.loan_repayment (account_id, amount, interest, principal, extra, executed_at) VALUES ($1, $2, $3, $4, $5, $6);"
+	_, createRepaymentError := tx.Exec(
+		ctx,
+		createRepaymentQuery,
+		params.AccountId,
+		params.Amount,
+		params.Interest,
+		params.Principal,
+		params.Extra,
+		params.ExecutedAt,
+	)
+	if createRepaymentError != nil {
+		return "", createRepaymentError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return balance, nil
+}
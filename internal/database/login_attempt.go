@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreateNewLoginAttemptParams struct {
+	ClientId  *string `json:"client_id"`
+	Email     string  `json:"email"`
+	IpAddress string  `json:"ip_address"`
+	UserAgent string  `json:"user_agent"`
+	Success   bool    `json:"success"`
+}
+
+type LoginFailureSummary struct {
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+func CreateNewLoginAttempt(ctx context.Context, db *pgxpool.Pool, params CreateNewLoginAttemptParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO everytrack_backend.login_attempt (client_id, email, ip_address, user_agent, success) VALUES ($1, $2, $3, $4, $5);"
+	_, createError := db.Exec(ctx, query, params.ClientId, params.Email, params.IpAddress, params.UserAgent, params.Success)
+
+	if createError != nil {
+		return false, createError
+	}
+
+	return true, nil
+}
+
+// Summarise failed attempts against an email since the given time, a successful login resets the count
+func GetLoginFailureSummaryByEmail(ctx context.Context, db *pgxpool.Pool, email string, since time.Time) (LoginFailureSummary, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var summary LoginFailureSummary
+	query := `SELECT COUNT(*), COALESCE(MAX(created_at), to_timestamp(0))
+	FROM everytrack_backend.login_attempt
+	WHERE email = $1 AND success = false AND created_at > GREATEST($2, COALESCE(
+		(SELECT MAX(created_at) FROM everytrack_backend.login_attempt WHERE email = $1 AND success = true),
+		to_timestamp(0)
+	));`
+	queryError := db.QueryRow(ctx, query, email, since).Scan(&summary.Failures, &summary.LastFailureAt)
+
+	if queryError != nil {
+		return summary, queryError
+	}
+
+	return summary, nil
+}
+
+// Summarise failed attempts from an IP address since the given time.
+// Successful logins do not reset the count, otherwise an attacker could reset it with an account of their own.
+func GetLoginFailureSummaryByIpAddress(ctx context.Context, db *pgxpool.Pool, ipAddress string, since time.Time) (LoginFailureSummary, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var summary LoginFailureSummary
+	query := `SELECT COUNT(*), COALESCE(MAX(created_at), to_timestamp(0))
+	FROM everytrack_backend.login_attempt
+	WHERE ip_address = $1 AND success = false AND created_at > $2;`
+	queryError := db.QueryRow(ctx, query, ipAddress, since).Scan(&summary.Failures, &summary.LastFailureAt)
+
+	if queryError != nil {
+		return summary, queryError
+	}
+
+	return summary, nil
+}
+
+func GetAllFailedLoginAttemptsByClientId(ctx context.Context, db *pgxpool.Pool, clientId string, limit int) ([]LoginAttempt, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	loginAttempts := []LoginAttempt{}
+	query := `SELECT id, client_id, email, ip_address, user_agent, success, created_at
+	FROM everytrack_backend.login_attempt
+	WHERE client_id = $1 AND success = false
+	ORDER BY created_at DESC
+	LIMIT $2;`
+	rows, queryError := db.Query(ctx, query, clientId, limit)
+	if queryError != nil {
+		return loginAttempts, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var loginAttempt LoginAttempt
+		scanError := rows.Scan(
+			&loginAttempt.Id,
+			&loginAttempt.ClientId,
+			&loginAttempt.Email,
+			&loginAttempt.IpAddress,
+			&loginAttempt.UserAgent,
+			&loginAttempt.Success,
+			&loginAttempt.CreatedAt,
+		)
+		if scanError != nil {
+			return loginAttempts, scanError
+		}
+		loginAttempts = append(loginAttempts, loginAttempt)
+	}
+
+	return loginAttempts, nil
+}
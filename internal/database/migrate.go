@@ -0,0 +1,339 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrations are named <version>_<name>.up.sql / <version>_<name>.down.sql and applied in ascending version order
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Seeds are idempotent and executed in file name order after migrations
+//
+//go:embed seeds/*.sql
+var seedFiles embed.FS
+
+// Arbitrary key of the advisory lock preventing concurrent instances from migrating at the same time
+const migrationLockKey int64 = 7_245_108_931
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type AppliedMigration struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func GetAllMigrations() ([]Migration, error) {
+	migrations := []Migration{}
+	migrationsByVersion := map[int64]*Migration{}
+
+	entries, readDirError := fs.ReadDir(migrationFiles, "migrations")
+	if readDirError != nil {
+		return migrations, readDirError
+	}
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return migrations, fmt.Errorf("invalid migration file name %s", fileName)
+		}
+
+		versionString, name, found := strings.Cut(strings.TrimSuffix(fileName, fmt.Sprintf(".%s.sql", direction)), "_")
+		if !found {
+			return migrations, fmt.Errorf("invalid migration file name %s", fileName)
+		}
+		version, parseVersionError := strconv.ParseInt(versionString, 10, 64)
+		if parseVersionError != nil {
+			return migrations, fmt.Errorf("invalid migration version in %s", fileName)
+		}
+
+		content, readFileError := migrationFiles.ReadFile(path.Join("migrations", fileName))
+		if readFileError != nil {
+			return migrations, readFileError
+		}
+
+		migration, exists := migrationsByVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			migrationsByVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return []Migration{}, fmt.Errorf("migration %d is missing its up or down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func GetAllAppliedMigrations(ctx context.Context, db *pgxpool.Pool) ([]AppliedMigration, error) {
+	appliedMigrations := []AppliedMigration{}
+	if ensureError := ensureMigrationTable(ctx, db); ensureError != nil {
+		return appliedMigrations, ensureError
+	}
+
+	query := "SELECT version, name, applied_at FROM everytrack_backend.schema_migration ORDER BY version;"
+	rows, queryError := db.Query(ctx, query)
+	if queryError != nil {
+		return appliedMigrations, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var appliedMigration AppliedMigration
+		scanError := rows.Scan(&appliedMigration.Version, &appliedMigration.Name, &appliedMigration.AppliedAt)
+		if scanError != nil {
+			return appliedMigrations, scanError
+		}
+		appliedMigrations = append(appliedMigrations, appliedMigration)
+	}
+
+	return appliedMigrations, nil
+}
+
+// Returns the version of the latest applied migration, 0 if none has been applied
+func GetMigrationVersion(ctx context.Context, db *pgxpool.Pool) (int64, error) {
+	var version int64
+	if ensureError := ensureMigrationTable(ctx, db); ensureError != nil {
+		return version, ensureError
+	}
+
+	query := "SELECT COALESCE(max(version), 0) FROM everytrack_backend.schema_migration;"
+	queryError := db.QueryRow(ctx, query).Scan(&version)
+	if queryError != nil {
+		return version, queryError
+	}
+
+	return version, nil
+}
+
+// Returns the migrations not applied yet, without creating the migration table if it is missing
+func GetAllPendingMigrations(ctx context.Context, db *pgxpool.Pool) ([]Migration, error) {
+	pendingMigrations := []Migration{}
+	migrations, getMigrationsError := GetAllMigrations()
+	if getMigrationsError != nil {
+		return pendingMigrations, getMigrationsError
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	conn, acquireError := db.Acquire(ctx)
+	if acquireError != nil {
+		return pendingMigrations, acquireError
+	}
+
+	defer conn.Release()
+
+	applied, getAppliedError := getAppliedVersions(ctx, conn)
+	if getAppliedError != nil {
+		return pendingMigrations, getAppliedError
+	}
+
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pendingMigrations = append(pendingMigrations, migration)
+		}
+	}
+
+	return pendingMigrations, nil
+}
+
+// Apply all pending migrations, each in its own transaction, returns the migrations applied
+func MigrateUp(ctx context.Context, db *pgxpool.Pool) ([]Migration, error) {
+	appliedMigrations := []Migration{}
+	migrations, getMigrationsError := GetAllMigrations()
+	if getMigrationsError != nil {
+		return appliedMigrations, getMigrationsError
+	}
+
+	lockError := withMigrationLock(ctx, db, func(conn *pgxpool.Conn) error {
+		applied, getAppliedError := getAppliedVersions(ctx, conn)
+		if getAppliedError != nil {
+			return getAppliedError
+		}
+
+		for _, migration := range migrations {
+			if applied[migration.Version] {
+				continue
+			}
+			applyError := runMigration(ctx, conn, migration.Up, "INSERT INTO everytrack_backend.schema_migration (version, name) VALUES ($1, $2);", migration.Version, migration.Name)
+			if applyError != nil {
+				return fmt.Errorf("failed to apply migration %d_%s. %w", migration.Version, migration.Name, applyError)
+			}
+			appliedMigrations = append(appliedMigrations, migration)
+		}
+
+		return nil
+	})
+
+	return appliedMigrations, lockError
+}
+
+// Revert the latest applied migrations by the given number of steps, returns the migrations reverted
+func MigrateDown(ctx context.Context, db *pgxpool.Pool, steps int) ([]Migration, error) {
+	revertedMigrations := []Migration{}
+	migrations, getMigrationsError := GetAllMigrations()
+	if getMigrationsError != nil {
+		return revertedMigrations, getMigrationsError
+	}
+
+	lockError := withMigrationLock(ctx, db, func(conn *pgxpool.Conn) error {
+		applied, getAppliedError := getAppliedVersions(ctx, conn)
+		if getAppliedError != nil {
+			return getAppliedError
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(revertedMigrations) < steps; i-- {
+			migration := migrations[i]
+			if !applied[migration.Version] {
+				continue
+			}
+			revertError := runMigration(ctx, conn, migration.Down, "DELETE FROM everytrack_backend.schema_migration WHERE version = $1 AND name = $2;", migration.Version, migration.Name)
+			if revertError != nil {
+				return fmt.Errorf("failed to revert migration %d_%s. %w", migration.Version, migration.Name, revertError)
+			}
+			revertedMigrations = append(revertedMigrations, migration)
+		}
+
+		return nil
+	})
+
+	return revertedMigrations, lockError
+}
+
+// Insert reference data (currencies, countries, asset providers and stocks), safe to run repeatedly
+func Seed(ctx context.Context, db *pgxpool.Pool) error {
+	entries, readDirError := fs.ReadDir(seedFiles, "seeds")
+	if readDirError != nil {
+		return readDirError
+	}
+
+	return withMigrationLock(ctx, db, func(conn *pgxpool.Conn) error {
+		tx, beginTxError := conn.Begin(ctx)
+		if beginTxError != nil {
+			return beginTxError
+		}
+
+		defer tx.Rollback(ctx)
+
+		for _, entry := range entries {
+			content, readFileError := seedFiles.ReadFile(path.Join("seeds", entry.Name()))
+			if readFileError != nil {
+				return readFileError
+			}
+			if _, execError := tx.Exec(ctx, string(content)); execError != nil {
+				return fmt.Errorf("failed to run seed %s. %w", entry.Name(), execError)
+			}
+		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+func ensureMigrationTable(ctx context.Context, db *pgxpool.Pool) error {
+	query := `CREATE SCHEMA IF NOT EXISTS everytrack_backend;
+CREATE TABLE IF NOT EXISTS everytrack_backend.schema_migration (
+  version bigint PRIMARY KEY,
+  name text NOT NULL,
+  applied_at timestamptz NOT NULL DEFAULT now()
+);`
+	_, execError := db.Exec(ctx, query)
+	return execError
+}
+
+// Run the callback on a single connection holding the session-level migration advisory lock
+func withMigrationLock(ctx context.Context, db *pgxpool.Pool, callback func(conn *pgxpool.Conn) error) error {
+	if ensureError := ensureMigrationTable(ctx, db); ensureError != nil {
+		return ensureError
+	}
+
+	conn, acquireError := db.Acquire(ctx)
+	if acquireError != nil {
+		return acquireError
+	}
+
+	defer conn.Release()
+
+	if _, lockError := conn.Exec(ctx, "SELECT pg_advisory_lock($1);", migrationLockKey); lockError != nil {
+		return lockError
+	}
+
+	// Unlock even if ctx has been cancelled, otherwise the lock stays with the connection returned to pool
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockKey)
+
+	return callback(conn)
+}
+
+func getAppliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]bool, error) {
+	applied := map[int64]bool{}
+	rows, queryError := conn.Query(ctx, "SELECT version FROM everytrack_backend.schema_migration;")
+	if queryError != nil {
+		return applied, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		if scanError := rows.Scan(&version); scanError != nil {
+			return applied, scanError
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// Execute migration script and record it in schema_migration within the same transaction
+func runMigration(ctx context.Context, conn *pgxpool.Conn, script string, recordQuery string, version int64, name string) error {
+	tx, beginTxError := conn.Begin(ctx)
+	if beginTxError != nil {
+		return beginTxError
+	}
+
+	defer tx.Rollback(ctx)
+
+	// Script without arguments runs through simple protocol, which allows multiple statements
+	if _, execError := tx.Exec(ctx, script); execError != nil {
+		return execError
+	}
+	if _, recordError := tx.Exec(ctx, recordQuery, version, name); recordError != nil {
+		return recordError
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS everytrack_backend.stock;
+DROP TABLE IF EXISTS everytrack_backend.asset_provider_account_type;
+DROP TABLE IF EXISTS everytrack_backend.asset_provider;
+DROP TABLE IF EXISTS everytrack_backend.exchange_rate;
+DROP TABLE IF EXISTS everytrack_backend.country;
+DROP TABLE IF EXISTS everytrack_backend.currency;
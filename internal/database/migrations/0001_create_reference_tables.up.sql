@@ -0,0 +1,50 @@
+CREATE SCHEMA IF NOT EXISTS everytrack_backend;
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.currency (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  ticker text NOT NULL UNIQUE,
+  symbol text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.country (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name text NOT NULL,
+  code text NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.exchange_rate (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  base_currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  target_currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  rate numeric NOT NULL,
+  UNIQUE (base_currency_id, target_currency_id)
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.asset_provider (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name text NOT NULL,
+  icon text NOT NULL,
+  type text NOT NULL,
+  country_id uuid NOT NULL REFERENCES everytrack_backend.country (id),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (name, type, country_id)
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.asset_provider_account_type (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  asset_provider_id uuid NOT NULL REFERENCES everytrack_backend.asset_provider (id),
+  name text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.stock (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  country_id uuid NOT NULL REFERENCES everytrack_backend.country (id),
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  name text NOT NULL,
+  ticker text NOT NULL,
+  current_price numeric NOT NULL DEFAULT 0,
+  UNIQUE (ticker, country_id)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS everytrack_backend.future_payment;
+DROP TABLE IF EXISTS everytrack_backend.transaction;
+DROP TABLE IF EXISTS everytrack_backend.cash;
+DROP TABLE IF EXISTS everytrack_backend.account_stock;
+DROP TABLE IF EXISTS everytrack_backend.account;
+DROP TABLE IF EXISTS everytrack_backend.client;
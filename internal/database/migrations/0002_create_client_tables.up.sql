@@ -0,0 +1,69 @@
+CREATE TABLE IF NOT EXISTS everytrack_backend.client (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  email text NOT NULL UNIQUE,
+  username text NOT NULL,
+  password text NOT NULL,
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.account (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  asset_provider_account_type_id uuid NOT NULL UNIQUE REFERENCES everytrack_backend.asset_provider_account_type (id),
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  balance numeric NOT NULL DEFAULT 0,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.account_stock (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  account_id uuid NOT NULL REFERENCES everytrack_backend.account (id),
+  stock_id uuid NOT NULL REFERENCES everytrack_backend.stock (id),
+  unit numeric NOT NULL,
+  cost numeric NOT NULL,
+  UNIQUE (account_id, stock_id)
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.cash (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  amount numeric NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.transaction (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  account_id uuid REFERENCES everytrack_backend.account (id),
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  name text NOT NULL,
+  category text NOT NULL,
+  amount numeric NOT NULL,
+  income boolean NOT NULL,
+  remarks text,
+  executed_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS transaction_account_id_executed_at_idx ON everytrack_backend.transaction (account_id, executed_at);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.future_payment (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  account_id uuid NOT NULL REFERENCES everytrack_backend.account (id),
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  name text NOT NULL,
+  amount numeric NOT NULL,
+  income boolean NOT NULL,
+  rolling boolean NOT NULL,
+  category text NOT NULL,
+  frequency bigint,
+  remarks text,
+  scheduled_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
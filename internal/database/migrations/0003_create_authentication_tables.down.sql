@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS everytrack_backend.api_token;
+DROP TABLE IF EXISTS everytrack_backend.oauth_identity;
+DROP TABLE IF EXISTS everytrack_backend.login_attempt;
+DROP TABLE IF EXISTS everytrack_backend.recovery_code;
+DROP TABLE IF EXISTS everytrack_backend.client_totp;
+DROP TABLE IF EXISTS everytrack_backend.client_token;
+DROP TABLE IF EXISTS everytrack_backend.session;
+ALTER TABLE everytrack_backend.client DROP COLUMN IF EXISTS verified;
@@ -0,0 +1,78 @@
+ALTER TABLE everytrack_backend.client ADD COLUMN IF NOT EXISTS verified boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.session (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  family_id uuid NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  user_agent text NOT NULL,
+  ip_address text NOT NULL,
+  expires_at timestamptz NOT NULL,
+  revoked_at timestamptz,
+  last_used_at timestamptz NOT NULL DEFAULT now(),
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS session_client_id_idx ON everytrack_backend.session (client_id);
+CREATE INDEX IF NOT EXISTS session_family_id_idx ON everytrack_backend.session (family_id);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.client_token (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  purpose text NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  expires_at timestamptz NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.client_totp (
+  client_id uuid PRIMARY KEY REFERENCES everytrack_backend.client (id),
+  secret text NOT NULL,
+  last_used_step bigint NOT NULL DEFAULT 0,
+  confirmed_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.recovery_code (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  code_hash text NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.login_attempt (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid REFERENCES everytrack_backend.client (id),
+  email text NOT NULL,
+  ip_address text NOT NULL,
+  user_agent text NOT NULL,
+  success boolean NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS login_attempt_email_created_at_idx ON everytrack_backend.login_attempt (email, created_at);
+CREATE INDEX IF NOT EXISTS login_attempt_ip_address_created_at_idx ON everytrack_backend.login_attempt (ip_address, created_at);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.oauth_identity (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  provider text NOT NULL,
+  subject text NOT NULL,
+  email text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (provider, subject)
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.api_token (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  name text NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  scopes text[] NOT NULL,
+  last_used_at timestamptz,
+  expires_at timestamptz,
+  revoked_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS everytrack_backend.loan_repayment;
+DROP TABLE IF EXISTS everytrack_backend.loan_account;
+DROP TABLE IF EXISTS everytrack_backend.credit_statement;
+DROP TABLE IF EXISTS everytrack_backend.credit_account;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS everytrack_backend.credit_account (
+  account_id uuid PRIMARY KEY REFERENCES everytrack_backend.account (id),
+  credit_limit numeric NOT NULL,
+  statement_day integer NOT NULL,
+  due_day integer NOT NULL,
+  apr numeric NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Repayment is scheduled as a future payment, which is deleted once it has been paid
+CREATE TABLE IF NOT EXISTS everytrack_backend.credit_statement (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  account_id uuid NOT NULL REFERENCES everytrack_backend.account (id),
+  future_payment_id uuid REFERENCES everytrack_backend.future_payment (id) ON DELETE SET NULL,
+  balance numeric NOT NULL,
+  period_start timestamptz NOT NULL,
+  period_end timestamptz NOT NULL,
+  due_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (account_id, period_end)
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.loan_account (
+  account_id uuid PRIMARY KEY REFERENCES everytrack_backend.account (id),
+  principal numeric NOT NULL,
+  annual_rate numeric NOT NULL,
+  term_in_months integer NOT NULL,
+  start_date timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.loan_repayment (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  account_id uuid NOT NULL REFERENCES everytrack_backend.account (id),
+  amount numeric NOT NULL,
+  interest numeric NOT NULL,
+  principal numeric NOT NULL,
+  extra boolean NOT NULL DEFAULT false,
+  executed_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
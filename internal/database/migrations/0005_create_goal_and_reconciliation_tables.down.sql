@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS everytrack_backend.reconciliation;
+DROP TABLE IF EXISTS everytrack_backend.goal_account;
+DROP TABLE IF EXISTS everytrack_backend.goal;
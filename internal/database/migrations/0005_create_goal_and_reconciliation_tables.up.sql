@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS everytrack_backend.goal (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  currency_id uuid NOT NULL REFERENCES everytrack_backend.currency (id),
+  future_payment_id uuid REFERENCES everytrack_backend.future_payment (id) ON DELETE SET NULL,
+  name text NOT NULL,
+  target_amount numeric NOT NULL,
+  deadline timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.goal_account (
+  goal_id uuid NOT NULL REFERENCES everytrack_backend.goal (id),
+  account_id uuid NOT NULL REFERENCES everytrack_backend.account (id),
+  PRIMARY KEY (goal_id, account_id)
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.reconciliation (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  account_id uuid NOT NULL REFERENCES everytrack_backend.account (id),
+  adjustment_transaction_id uuid REFERENCES everytrack_backend.transaction (id) ON DELETE SET NULL,
+  statement_balance numeric NOT NULL,
+  recorded_balance numeric NOT NULL,
+  difference numeric NOT NULL,
+  status text NOT NULL,
+  statement_date timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
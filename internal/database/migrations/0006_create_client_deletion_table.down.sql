@@ -0,0 +1 @@
+DROP TABLE IF EXISTS everytrack_backend.client_deletion;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS everytrack_backend.client_deletion (
+  client_id uuid PRIMARY KEY REFERENCES everytrack_backend.client (id),
+  scheduled_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
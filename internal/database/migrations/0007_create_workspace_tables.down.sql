@@ -0,0 +1,8 @@
+ALTER TABLE everytrack_backend.goal DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE everytrack_backend.future_payment DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE everytrack_backend.transaction DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE everytrack_backend.cash DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE everytrack_backend.account DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS everytrack_backend.workspace_invitation;
+DROP TABLE IF EXISTS everytrack_backend.workspace_member;
+DROP TABLE IF EXISTS everytrack_backend.workspace;
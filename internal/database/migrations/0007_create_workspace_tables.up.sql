@@ -0,0 +1,74 @@
+CREATE TABLE IF NOT EXISTS everytrack_backend.workspace (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.workspace_member (
+  workspace_id uuid NOT NULL REFERENCES everytrack_backend.workspace (id),
+  client_id uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  role text NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (workspace_id, client_id)
+);
+
+CREATE INDEX IF NOT EXISTS workspace_member_client_id_idx ON everytrack_backend.workspace_member (client_id);
+
+CREATE TABLE IF NOT EXISTS everytrack_backend.workspace_invitation (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  workspace_id uuid NOT NULL REFERENCES everytrack_backend.workspace (id),
+  email text NOT NULL,
+  role text NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+  token_hash text NOT NULL UNIQUE,
+  invited_by uuid NOT NULL REFERENCES everytrack_backend.client (id),
+  expires_at timestamptz NOT NULL,
+  accepted_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE everytrack_backend.account ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES everytrack_backend.workspace (id);
+ALTER TABLE everytrack_backend.cash ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES everytrack_backend.workspace (id);
+ALTER TABLE everytrack_backend.transaction ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES everytrack_backend.workspace (id);
+ALTER TABLE everytrack_backend.future_payment ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES everytrack_backend.workspace (id);
+ALTER TABLE everytrack_backend.goal ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES everytrack_backend.workspace (id);
+
+-- Move records created before workspaces existed into a personal workspace of their client
+DO $$
+DECLARE
+  client_record record;
+  personal_workspace_id uuid;
+BEGIN
+  FOR client_record IN
+    SELECT c.id FROM everytrack_backend.client AS c
+    WHERE NOT EXISTS (SELECT 1 FROM everytrack_backend.workspace_member AS wm WHERE wm.client_id = c.id AND wm.role = 'owner')
+  LOOP
+    INSERT INTO everytrack_backend.workspace (name) VALUES ('Personal') RETURNING id INTO personal_workspace_id;
+    INSERT INTO everytrack_backend.workspace_member (workspace_id, client_id, role) VALUES (personal_workspace_id, client_record.id, 'owner');
+  END LOOP;
+
+  FOR client_record IN
+    SELECT DISTINCT ON (wm.client_id) wm.client_id AS id, wm.workspace_id
+    FROM everytrack_backend.workspace_member AS wm
+    WHERE wm.role = 'owner'
+    ORDER BY wm.client_id, wm.created_at
+  LOOP
+    UPDATE everytrack_backend.account SET workspace_id = client_record.workspace_id WHERE client_id = client_record.id AND workspace_id IS NULL;
+    UPDATE everytrack_backend.cash SET workspace_id = client_record.workspace_id WHERE client_id = client_record.id AND workspace_id IS NULL;
+    UPDATE everytrack_backend.transaction SET workspace_id = client_record.workspace_id WHERE client_id = client_record.id AND workspace_id IS NULL;
+    UPDATE everytrack_backend.future_payment SET workspace_id = client_record.workspace_id WHERE client_id = client_record.id AND workspace_id IS NULL;
+    UPDATE everytrack_backend.goal SET workspace_id = client_record.workspace_id WHERE client_id = client_record.id AND workspace_id IS NULL;
+  END LOOP;
+END $$;
+
+ALTER TABLE everytrack_backend.account ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE everytrack_backend.cash ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE everytrack_backend.transaction ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE everytrack_backend.future_payment ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE everytrack_backend.goal ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS account_workspace_id_idx ON everytrack_backend.account (workspace_id);
+CREATE INDEX IF NOT EXISTS cash_workspace_id_idx ON everytrack_backend.cash (workspace_id);
+CREATE INDEX IF NOT EXISTS transaction_workspace_id_idx ON everytrack_backend.transaction (workspace_id);
+CREATE INDEX IF NOT EXISTS future_payment_workspace_id_idx ON everytrack_backend.future_payment (workspace_id);
+CREATE INDEX IF NOT EXISTS goal_workspace_id_idx ON everytrack_backend.goal (workspace_id);
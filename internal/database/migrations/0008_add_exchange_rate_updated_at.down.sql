@@ -0,0 +1 @@
+ALTER TABLE everytrack_backend.exchange_rate DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1 @@
+ALTER TABLE everytrack_backend.exchange_rate ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
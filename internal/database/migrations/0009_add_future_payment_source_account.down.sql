@@ -0,0 +1,2 @@
+ALTER TABLE everytrack_backend.future_payment DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE everytrack_backend.future_payment DROP COLUMN IF EXISTS source_account_id;
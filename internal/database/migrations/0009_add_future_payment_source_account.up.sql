@@ -0,0 +1,2 @@
+ALTER TABLE everytrack_backend.future_payment ADD COLUMN IF NOT EXISTS source_account_id uuid REFERENCES everytrack_backend.account (id) ON DELETE CASCADE;
+ALTER TABLE everytrack_backend.future_payment ADD COLUMN IF NOT EXISTS ends_at timestamptz;
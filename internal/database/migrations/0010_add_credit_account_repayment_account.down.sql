@@ -0,0 +1 @@
+ALTER TABLE everytrack_backend.credit_account DROP COLUMN IF EXISTS repayment_account_id;
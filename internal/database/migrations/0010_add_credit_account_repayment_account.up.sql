@@ -0,0 +1 @@
+ALTER TABLE everytrack_backend.credit_account ADD COLUMN IF NOT EXISTS repayment_account_id uuid REFERENCES everytrack_backend.account (id) ON DELETE SET NULL;
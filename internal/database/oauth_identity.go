@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreateNewOauthIdentityParams struct {
+	ClientId string `json:"client_id"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+}
+
+func GetOauthIdentity(ctx context.Context, db *pgxpool.Pool, provider string, subject string) (OauthIdentity, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var oauthIdentity OauthIdentity
+	query := "SELECT id, client_id, provider, subject, email, created_at FROM everytrack_backend.oauth_identity WHERE provider = $1 AND subject = $2;"
+	queryError := db.QueryRow(ctx, query, provider, subject).Scan(
+		&oauthIdentity.Id,
+		&oauthIdentity.ClientId,
+		&oauthIdentity.Provider,
+		&oauthIdentity.Subject,
+		&oauthIdentity.Email,
+		&oauthIdentity.CreatedAt,
+	)
+
+	if queryError != nil {
+		return oauthIdentity, queryError
+	}
+
+	return oauthIdentity, nil
+}
+
+func CreateNewOauthIdentity(ctx context.Context, db *pgxpool.Pool, params CreateNewOauthIdentityParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO everytrack_backend.oauth_identity (client_id, provider, subject, email) VALUES ($1, $2, $3, $4);"
+	_, createError := db.Exec(ctx, query, params.ClientId, params.Provider, params.Subject, params.Email)
+
+	if createError != nil {
+		return false, createError
+	}
+
+	return true, nil
+}
+
+// Create a client signing up through an identity provider, verified straight away as the provider has verified the email
+func CreateNewClientWithOauthIdentity(ctx context.Context, db *pgxpool.Pool, clientParams CreateNewClientParams, identityParams CreateNewOauthIdentityParams) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var clientId string
+	createClientQuery := "INSERT INTO everytrack_backend.client (email, username, password, currency_id, verified) VALUES ($1, $2, $3, $4, true) RETURNING id;"
+	createClientError := tx.QueryRow(
+		ctx,
+		createClientQuery,
+		clientParams.Email,
+		clientParams.Username,
+		clientParams.Password,
+		clientParams.CurrencyId,
+	).Scan(&clientId)
+	if createClientError != nil {
+		return "", createClientError
+	}
+
+	createIdentityQuery := "INSERT INTO everytrack_backend.oauth_identity (client_id, provider, subject, email) VALUES ($1, $2, $3, $4);"
+	_, createIdentityError := tx.Exec(
+		ctx,
+		createIdentityQuery,
+		clientId,
+		identityParams.Provider,
+		identityParams.Subject,
+		identityParams.Email,
+	)
+	if createIdentityError != nil {
+		return "", createIdentityError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return clientId, nil
+}
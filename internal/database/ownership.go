@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/exp/slices"
+)
+
+// Error code of postgres for text that is not a valid representation of the column type, e.g. a malformed uuid
+const invalidTextRepresentationCode = "22P02"
+
+var ErrAccountNotInWorkspace = errors.New("account does not belong to the workspace")
+
+// Kinds of records whose ownership can be checked against a workspace
+const (
+	OwnedAccount       = "account"
+	OwnedAccountType   = "account_type"
+	OwnedStockHolding  = "stock_holding"
+	OwnedCash          = "cash"
+	OwnedTransaction   = "transaction"
+	OwnedFuturePayment = "future_payment"
+	OwnedGoal          = "goal"
+)
+
+// Count how many of the given ids belong to a workspace, records without workspace_id are resolved through their account
+var ownershipQueries = map[string]string{
+	OwnedAccount:     "SELECT count(DISTINCT id) FROM everytrack_backend.account WHERE id = ANY($1::uuid[]) AND workspace_id = $2;",
+	OwnedAccountType: "SELECT count(DISTINCT asset_provider_account_type_id) FROM everytrack_backend.account WHERE asset_provider_account_type_id = ANY($1::uuid[]) AND workspace_id = $2;",
+	OwnedStockHolding: `SELECT count(DISTINCT s.id) FROM everytrack_backend.account_stock AS s
+	INNER JOIN everytrack_backend.account AS a ON a.id = s.account_id
+	WHERE s.id = ANY($1::uuid[]) AND a.workspace_id = $2;`,
+	OwnedCash:          "SELECT count(DISTINCT id) FROM everytrack_backend.cash WHERE id = ANY($1::uuid[]) AND workspace_id = $2;",
+	OwnedTransaction:   "SELECT count(DISTINCT id) FROM everytrack_backend.transaction WHERE id = ANY($1::uuid[]) AND workspace_id = $2;",
+	OwnedFuturePayment: "SELECT count(DISTINCT id) FROM everytrack_backend.future_payment WHERE id = ANY($1::uuid[]) AND workspace_id = $2;",
+	OwnedGoal:          "SELECT count(DISTINCT id) FROM everytrack_backend.goal WHERE id = ANY($1::uuid[]) AND workspace_id = $2;",
+}
+
+// Check if every given record of a kind belongs to the workspace.
+// Malformed ids cannot belong to anyone, so they are reported as not owned instead of failing the query.
+func CheckWorkspaceOwnership(ctx context.Context, db *pgxpool.Pool, kind string, workspaceId string, ids ...string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	uniqueIds := deduplicateIds(ids)
+
+	var ownedCount int
+	queryError := db.QueryRow(ctx, ownershipQueries[kind], uniqueIds, workspaceId).Scan(&ownedCount)
+	if queryError != nil {
+		var pgError *pgconn.PgError
+		if errors.As(queryError, &pgError) && pgError.Code == invalidTextRepresentationCode {
+			return false, nil
+		}
+		return false, queryError
+	}
+
+	return ownedCount == len(uniqueIds), nil
+}
+
+// Check within a database transaction that every given account belongs to the workspace, so that a write cannot reach into another workspace
+func checkAccountsInWorkspace(ctx context.Context, tx pgx.Tx, workspaceId string, accountIds ...string) error {
+	uniqueIds := deduplicateIds(accountIds)
+
+	var ownedCount int
+	if queryError := tx.QueryRow(ctx, ownershipQueries[OwnedAccount], uniqueIds, workspaceId).Scan(&ownedCount); queryError != nil {
+		return queryError
+	}
+	if ownedCount != len(uniqueIds) {
+		return ErrAccountNotInWorkspace
+	}
+
+	return nil
+}
+
+func deduplicateIds(ids []string) []string {
+	uniqueIds := []string{}
+	for _, id := range ids {
+		if !slices.Contains(uniqueIds, id) {
+			uniqueIds = append(uniqueIds, id)
+		}
+	}
+
+	return uniqueIds
+}
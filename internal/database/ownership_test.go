@@ -0,0 +1,70 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Every kind has to be counted by its own ids and scoped to the workspace, otherwise a check could pass for records of another workspace
+func TestOwnershipQueriesAreScopedToWorkspace(t *testing.T) {
+	testCases := []struct {
+		kind     string
+		idColumn string
+	}{
+		{kind: OwnedAccount, idColumn: "id"},
+		{kind: OwnedAccountType, idColumn: "asset_provider_account_type_id"},
+		{kind: OwnedStockHolding, idColumn: "s.id"},
+		{kind: OwnedCash, idColumn: "id"},
+		{kind: OwnedTransaction, idColumn: "id"},
+		{kind: OwnedFuturePayment, idColumn: "id"},
+		{kind: OwnedGoal, idColumn: "id"},
+	}
+
+	if len(testCases) != len(ownershipQueries) {
+		t.Fatalf("expected a test case for every one of %d ownership queries, got %d", len(ownershipQueries), len(testCases))
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.kind, func(t *testing.T) {
+			query, found := ownershipQueries[testCase.kind]
+			if !found {
+				t.Fatalf("expected an ownership query for %s", testCase.kind)
+			}
+
+			// Duplicated ids are removed before the query, so counting distinct ids keeps the count comparable with them
+			for _, clause := range []string{
+				"count(DISTINCT " + testCase.idColumn + ")",
+				"WHERE " + testCase.idColumn + " = ANY($1::uuid[])",
+				"workspace_id = $2;",
+			} {
+				if !strings.Contains(query, clause) {
+					t.Errorf("expected query of %s to contain %q, got %s", testCase.kind, clause, query)
+				}
+			}
+			if strings.Contains(query, " OR ") {
+				t.Errorf("expected query of %s to match every condition, got %s", testCase.kind, query)
+			}
+		})
+	}
+}
+
+func TestDeduplicateIds(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ids      []string
+		expected []string
+	}{
+		{name: "no ids", ids: nil, expected: []string{}},
+		{name: "unique ids", ids: []string{"a", "b"}, expected: []string{"a", "b"}},
+		{name: "repeated ids", ids: []string{"a", "b", "a", "a"}, expected: []string{"a", "b"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if uniqueIds := deduplicateIds(testCase.ids); !reflect.DeepEqual(uniqueIds, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, uniqueIds)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReconciliationAdjustmentParams struct {
+	Name       string `json:"name"`
+	Income     bool   `json:"income"`
+	Amount     string `json:"amount"`
+	ClientId   string `json:"client_id"`
+	CurrencyId string `json:"currency_id"`
+}
+
+type CreateNewReconciliationParams struct {
+	AccountId        string                          `json:"account_id"`
+	StatementBalance string                          `json:"statement_balance"`
+	RecordedBalance  string                          `json:"recorded_balance"`
+	Difference       string                          `json:"difference"`
+	Status           string                          `json:"status"`
+	StatementDate    time.Time                       `json:"statement_date"`
+	Adjustment       *ReconciliationAdjustmentParams `json:"adjustment"`
+}
+
+func GetAllReconciliations(ctx context.Context, db *pgxpool.Pool, accountId string) ([]Reconciliation, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	reconciliations := []Reconciliation{}
+	query := `SELECT id, account_id, adjustment_transaction_id, statement_balance, recorded_balance, difference, status, statement_date, created_at
+	FROM everytrack_backend.reconciliation
+	WHERE account_id = $1
+	ORDER BY statement_date DESC, created_at DESC;`
+	rows, queryError := db.Query(ctx, query, accountId)
+	if queryError != nil {
+		return reconciliations, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var reconciliation Reconciliation
+		scanError := rows.Scan(
+			&reconciliation.Id,
+			&reconciliation.AccountId,
+			&reconciliation.AdjustmentTransactionId,
+			&reconciliation.StatementBalance,
+			&reconciliation.RecordedBalance,
+			&reconciliation.Difference,
+			&reconciliation.Status,
+			&reconciliation.StatementDate,
+			&reconciliation.CreatedAt,
+		)
+		if scanError != nil {
+			return reconciliations, scanError
+		}
+		reconciliations = append(reconciliations, reconciliation)
+	}
+
+	return reconciliations, nil
+}
+
+// Record a reconciliation, booking the adjustment transaction and applying it on the account balance together when the difference is accepted
+func CreateNewReconciliation(ctx context.Context, db *pgxpool.Pool, params CreateNewReconciliationParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var adjustmentTransactionId *string
+	if params.Adjustment != nil {
+		var id string
+		createAdjustmentQuery := `INSERT INTO everytrack_backend.transaction (client_id, workspace_id, account_id, currency_id, name, category, amount, income, executed_at)
+		VALUES ($1, (SELECT workspace_id FROM everytrack_backend.account WHERE id = $2), $2, $3, $4, 'reconciliation-adjustment', $5, $6, $7) RETURNING id;`
+		createAdjustmentError := tx.QueryRow(
+			ctx,
+			createAdjustmentQuery,
+			params.Adjustment.ClientId,
+			params.AccountId,
+			params.Adjustment.CurrencyId,
+			params.Adjustment.Name,
+			params.Adjustment.Amount,
+			params.Adjustment.Income,
+			params.StatementDate,
+		).Scan(&id)
+		if createAdjustmentError != nil {
+			return false, createAdjustmentError
+		}
+		adjustmentTransactionId = &id
+
+		if _, applyError := applyToAccountBalance(ctx, tx, params.AccountId, params.Adjustment.Amount, params.Adjustment.Income); applyError != nil {
+			return false, applyError
+		}
+	}
+
+	createReconciliationQuery := `INSERT INTO everytrack_backend.reconciliation (account_id, adjustment_transaction_id, statement_balance, recorded_balance, difference, status, statement_date)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	_, createReconciliationError := tx.Exec(
+		ctx,
+		createReconciliationQuery,
+		params.AccountId,
+		adjustmentTransactionId,
+		params.StatementBalance,
+		params.RecordedBalance,
+		params.Difference,
+		params.Status,
+		params.StatementDate,
+	)
+	if createReconciliationError != nil {
+		return false, createReconciliationError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
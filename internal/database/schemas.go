@@ -8,6 +8,7 @@ import (
 type Account struct {
 	Id                         string    `json:"id"`
 	ClientId                   string    `json:"client_id"`
+	WorkspaceId                string    `json:"workspace_id"`
 	AssetProviderAccountTypeId string    `json:"asset_provider_account_type_id"`
 	CurrencyId                 string    `json:"currency_id"`
 	Balance                    string    `json:"balance"`
@@ -23,6 +24,18 @@ type AccountStock struct {
 	Cost      string `json:"cost"`
 }
 
+type ApiToken struct {
+	Id         string       `json:"id"`
+	ClientId   string       `json:"client_id"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"token_hash"`
+	Scopes     []string     `json:"scopes"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
 type AssetProvider struct {
 	Id        string    `json:"id"`
 	Name      string    `json:"name"`
@@ -42,12 +55,13 @@ type AssetProviderAccountType struct {
 }
 
 type Cash struct {
-	Id         string    `json:"id"`
-	ClientId   string    `json:"client_id"`
-	CurrencyId string    `json:"currency_id"`
-	Amount     string    `json:"amount"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	Id          string    `json:"id"`
+	ClientId    string    `json:"client_id"`
+	WorkspaceId string    `json:"workspace_id"`
+	CurrencyId  string    `json:"currency_id"`
+	Amount      string    `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type Client struct {
@@ -56,10 +70,35 @@ type Client struct {
 	Username   string    `json:"username"`
 	Password   string    `json:"password"`
 	CurrencyId string    `json:"currency_id"`
+	Verified   bool      `json:"verified"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+type ClientDeletion struct {
+	ClientId    string    `json:"client_id"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ClientToken struct {
+	Id        string       `json:"id"`
+	ClientId  string       `json:"client_id"`
+	Purpose   string       `json:"purpose"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type ClientTotp struct {
+	ClientId     string       `json:"client_id"`
+	Secret       string       `json:"secret"`
+	LastUsedStep int64        `json:"last_used_step"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
 type Country struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
@@ -80,21 +119,23 @@ type ExchangeRate struct {
 }
 
 type Transaction struct {
-	Id         string         `json:"id"`
-	Name       string         `json:"name"`
-	Income     bool           `json:"income"`
-	ClientId   string         `json:"client_id"`
-	AccountId  sql.NullString `json:"account_id"`
-	CurrencyId string         `json:"currency_id"`
-	Category   string         `json:"category"`
-	Amount     string         `json:"amount"`
-	Remarks    sql.NullString `json:"remarks"`
-	ExecutedAt time.Time      `json:"executed_at"`
+	Id          string         `json:"id"`
+	Name        string         `json:"name"`
+	Income      bool           `json:"income"`
+	ClientId    string         `json:"client_id"`
+	WorkspaceId string         `json:"workspace_id"`
+	AccountId   sql.NullString `json:"account_id"`
+	CurrencyId  string         `json:"currency_id"`
+	Category    string         `json:"category"`
+	Amount      string         `json:"amount"`
+	Remarks     sql.NullString `json:"remarks"`
+	ExecutedAt  time.Time      `json:"executed_at"`
 }
 
 type FuturePayment struct {
 	Id          string         `json:"id"`
 	ClientId    string         `json:"client_id"`
+	WorkspaceId string         `json:"workspace_id"`
 	AccountId   string         `json:"account_id"`
 	CurrencyId  string         `json:"currency_id"`
 	Name        string         `json:"name"`
@@ -105,6 +146,10 @@ type FuturePayment struct {
 	Frequency   sql.NullInt64  `json:"frequency"`
 	Remarks     sql.NullString `json:"remarks"`
 	ScheduledAt time.Time      `json:"scheduled_at"`
+	// Account the amount is transferred from, only set for payments funding a goal
+	SourceAccountId sql.NullString `json:"source_account_id"`
+	// Rolling payment is not rescheduled past this time
+	EndsAt sql.NullTime `json:"ends_at"`
 }
 
 type Stock struct {
@@ -115,3 +160,133 @@ type Stock struct {
 	Ticker       string `json:"ticker"`
 	CurrentPrice string `json:"current_price"`
 }
+
+type CreditAccount struct {
+	AccountId          string         `json:"account_id"`
+	CreditLimit        string         `json:"credit_limit"`
+	StatementDay       int            `json:"statement_day"`
+	DueDay             int            `json:"due_day"`
+	Apr                string         `json:"apr"`
+	RepaymentAccountId sql.NullString `json:"repayment_account_id"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+type CreditStatement struct {
+	Id              string         `json:"id"`
+	AccountId       string         `json:"account_id"`
+	FuturePaymentId sql.NullString `json:"future_payment_id"`
+	Balance         string         `json:"balance"`
+	PeriodStart     time.Time      `json:"period_start"`
+	PeriodEnd       time.Time      `json:"period_end"`
+	DueAt           time.Time      `json:"due_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+type LoanAccount struct {
+	AccountId    string    `json:"account_id"`
+	Principal    string    `json:"principal"`
+	AnnualRate   string    `json:"annual_rate"`
+	TermInMonths int       `json:"term_in_months"`
+	StartDate    time.Time `json:"start_date"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type LoanRepayment struct {
+	Id         string    `json:"id"`
+	AccountId  string    `json:"account_id"`
+	Amount     string    `json:"amount"`
+	Interest   string    `json:"interest"`
+	Principal  string    `json:"principal"`
+	Extra      bool      `json:"extra"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+type Goal struct {
+	Id              string         `json:"id"`
+	ClientId        string         `json:"client_id"`
+	WorkspaceId     string         `json:"workspace_id"`
+	CurrencyId      string         `json:"currency_id"`
+	FuturePaymentId sql.NullString `json:"future_payment_id"`
+	Name            string         `json:"name"`
+	TargetAmount    string         `json:"target_amount"`
+	Deadline        time.Time      `json:"deadline"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type GoalAccount struct {
+	GoalId    string `json:"goal_id"`
+	AccountId string `json:"account_id"`
+}
+
+type LoginAttempt struct {
+	Id        string    `json:"id"`
+	ClientId  *string   `json:"client_id"`
+	Email     string    `json:"email"`
+	IpAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OauthIdentity struct {
+	Id        string    `json:"id"`
+	ClientId  string    `json:"client_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Reconciliation struct {
+	Id                      string         `json:"id"`
+	AccountId               string         `json:"account_id"`
+	AdjustmentTransactionId sql.NullString `json:"adjustment_transaction_id"`
+	StatementBalance        string         `json:"statement_balance"`
+	RecordedBalance         string         `json:"recorded_balance"`
+	Difference              string         `json:"difference"`
+	Status                  string         `json:"status"`
+	StatementDate           time.Time      `json:"statement_date"`
+	CreatedAt               time.Time      `json:"created_at"`
+}
+
+type Session struct {
+	Id         string       `json:"id"`
+	ClientId   string       `json:"client_id"`
+	FamilyId   string       `json:"family_id"`
+	TokenHash  string       `json:"token_hash"`
+	UserAgent  string       `json:"user_agent"`
+	IpAddress  string       `json:"ip_address"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	LastUsedAt time.Time    `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type Workspace struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceId string    `json:"workspace_id"`
+	ClientId    string    `json:"client_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WorkspaceInvitation struct {
+	Id          string       `json:"id"`
+	WorkspaceId string       `json:"workspace_id"`
+	Email       string       `json:"email"`
+	Role        string       `json:"role"`
+	TokenHash   string       `json:"token_hash"`
+	InvitedBy   string       `json:"invited_by"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	AcceptedAt  sql.NullTime `json:"accepted_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
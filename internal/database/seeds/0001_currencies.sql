@@ -0,0 +1,11 @@
+INSERT INTO everytrack_backend.currency (ticker, symbol) VALUES
+  ('HKD', 'HK$'),
+  ('USD', '$'),
+  ('GBP', '£'),
+  ('EUR', '€'),
+  ('JPY', '¥'),
+  ('CNY', 'CN¥'),
+  ('AUD', 'A$'),
+  ('CAD', 'C$'),
+  ('SGD', 'S$')
+ON CONFLICT (ticker) DO NOTHING;
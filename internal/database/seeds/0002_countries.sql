@@ -0,0 +1,10 @@
+INSERT INTO everytrack_backend.country (name, code) VALUES
+  ('Hong Kong', 'HK'),
+  ('United States', 'US'),
+  ('United Kingdom', 'GB'),
+  ('Japan', 'JP'),
+  ('China', 'CN'),
+  ('Australia', 'AU'),
+  ('Canada', 'CA'),
+  ('Singapore', 'SG')
+ON CONFLICT (code) DO NOTHING;
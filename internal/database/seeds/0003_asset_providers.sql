@@ -0,0 +1,29 @@
+INSERT INTO everytrack_backend.asset_provider (name, icon, type, country_id)
+SELECT provider.name, provider.icon, provider.type, c.id
+FROM (VALUES
+  ('HSBC', 'hsbc', 'savings', 'HK'),
+  ('Hang Seng Bank', 'hangseng', 'savings', 'HK'),
+  ('Standard Chartered', 'standardchartered', 'savings', 'HK'),
+  ('Futu', 'futu', 'broker', 'HK'),
+  ('Interactive Brokers', 'interactivebrokers', 'broker', 'US'),
+  ('Charles Schwab', 'schwab', 'broker', 'US'),
+  ('HSBC', 'hsbc', 'credit', 'HK'),
+  ('American Express', 'amex', 'credit', 'US'),
+  ('HSBC', 'hsbc', 'loan', 'HK'),
+  ('Barclays', 'barclays', 'savings', 'GB'),
+  ('Monzo', 'monzo', 'savings', 'GB'),
+  ('Trading 212', 'trading212', 'broker', 'GB')
+) AS provider (name, icon, type, country_code)
+INNER JOIN everytrack_backend.country AS c ON c.code = provider.country_code
+ON CONFLICT (name, type, country_id) DO NOTHING;
+
+-- Every seeded provider gets a default account type unless it has one already
+INSERT INTO everytrack_backend.asset_provider_account_type (asset_provider_id, name)
+SELECT ap.id, CASE ap.type
+  WHEN 'savings' THEN 'Savings Account'
+  WHEN 'broker' THEN 'Brokerage Account'
+  WHEN 'credit' THEN 'Credit Card'
+  ELSE 'Personal Loan'
+END
+FROM everytrack_backend.asset_provider AS ap
+WHERE NOT EXISTS (SELECT 1 FROM everytrack_backend.asset_provider_account_type AS apat WHERE apat.asset_provider_id = ap.id);
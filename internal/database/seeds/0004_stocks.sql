@@ -0,0 +1,16 @@
+INSERT INTO everytrack_backend.stock (country_id, currency_id, name, ticker)
+SELECT c.id, cur.id, stock.name, stock.ticker
+FROM (VALUES
+  ('Apple Inc.', 'AAPL', 'US', 'USD'),
+  ('Microsoft Corporation', 'MSFT', 'US', 'USD'),
+  ('Alphabet Inc.', 'GOOGL', 'US', 'USD'),
+  ('Amazon.com, Inc.', 'AMZN', 'US', 'USD'),
+  ('NVIDIA Corporation', 'NVDA', 'US', 'USD'),
+  ('Tesla, Inc.', 'TSLA', 'US', 'USD'),
+  ('Tencent Holdings Limited', '0700', 'HK', 'HKD'),
+  ('HSBC Holdings plc', '0005', 'HK', 'HKD')
+) AS stock (name, ticker, country_code, currency_ticker)
+INNER JOIN everytrack_backend.country AS c ON c.code = stock.country_code
+INNER JOIN everytrack_backend.currency AS cur ON cur.ticker = stock.currency_ticker
+ON CONFLICT (ticker, country_id) DO NOTHING;
+
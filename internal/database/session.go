@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrSessionAlreadyRotated = errors.New("session has been rotated already")
+
+type CreateNewSessionParams struct {
+	ClientId  string    `json:"client_id"`
+	FamilyId  *string   `json:"family_id"`
+	TokenHash string    `json:"token_hash"`
+	UserAgent string    `json:"user_agent"`
+	IpAddress string    `json:"ip_address"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const sessionColumns = "id, client_id, family_id, token_hash, user_agent, ip_address, expires_at, revoked_at, last_used_at, created_at"
+
+func scanSessions(ctx context.Context, db *pgxpool.Pool, query string, args ...any) ([]Session, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	sessions := []Session{}
+	rows, queryError := db.Query(ctx, query, args...)
+	if queryError != nil {
+		return sessions, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var session Session
+		scanError := rows.Scan(
+			&session.Id,
+			&session.ClientId,
+			&session.FamilyId,
+			&session.TokenHash,
+			&session.UserAgent,
+			&session.IpAddress,
+			&session.ExpiresAt,
+			&session.RevokedAt,
+			&session.LastUsedAt,
+			&session.CreatedAt,
+		)
+		if scanError != nil {
+			return sessions, scanError
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func GetSessionByTokenHash(ctx context.Context, db *pgxpool.Pool, tokenHash string) (Session, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var session Session
+	query := "SELECT " + sessionColumns + " FROM everytrack_backend.session WHERE token_hash = $1;"
+	queryError := db.QueryRow(ctx, query, tokenHash).Scan(
+		&session.Id,
+		&session.ClientId,
+		&session.FamilyId,
+		&session.TokenHash,
+		&session.UserAgent,
+		&session.IpAddress,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.LastUsedAt,
+		&session.CreatedAt,
+	)
+	if queryError != nil {
+		return session, queryError
+	}
+
+	return session, nil
+}
+
+// Get the latest unrevoked and unexpired session of every session family owned by client
+func GetAllActiveSessionsByClientId(ctx context.Context, db *pgxpool.Pool, clientId string) ([]Session, error) {
+	query := "SELECT " + sessionColumns + ` FROM everytrack_backend.session
+	WHERE client_id = $1 AND revoked_at IS NULL AND expires_at > now()
+	ORDER BY last_used_at DESC;`
+	return scanSessions(ctx, db, query, clientId)
+}
+
+func CreateNewSession(ctx context.Context, db *pgxpool.Pool, params CreateNewSessionParams) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var id string
+	query := `INSERT INTO everytrack_backend.session (client_id, family_id, token_hash, user_agent, ip_address, expires_at)
+	VALUES ($1, COALESCE($2, gen_random_uuid()), $3, $4, $5, $6) RETURNING id;`
+	queryError := db.QueryRow(
+		ctx,
+		query,
+		params.ClientId,
+		params.FamilyId,
+		params.TokenHash,
+		params.UserAgent,
+		params.IpAddress,
+		params.ExpiresAt,
+	).Scan(&id)
+
+	if queryError != nil {
+		return id, queryError
+	}
+
+	return id, nil
+}
+
+// Revoke the presented session and issue its successor in the same family within a single database transaction.
+// Returns ErrSessionAlreadyRotated if another request has rotated the session concurrently.
+func RotateSession(ctx context.Context, db *pgxpool.Pool, sessionId string, params CreateNewSessionParams) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	revokeQuery := "UPDATE everytrack_backend.session SET revoked_at = now(), last_used_at = now() WHERE id = $1 AND revoked_at IS NULL;"
+	result, revokeError := tx.Exec(ctx, revokeQuery, sessionId)
+	if revokeError != nil {
+		return "", revokeError
+	}
+	if result.RowsAffected() == 0 {
+		return "", ErrSessionAlreadyRotated
+	}
+
+	var id string
+	createQuery := `INSERT INTO everytrack_backend.session (client_id, family_id, token_hash, user_agent, ip_address, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;`
+	createError := tx.QueryRow(
+		ctx,
+		createQuery,
+		params.ClientId,
+		params.FamilyId,
+		params.TokenHash,
+		params.UserAgent,
+		params.IpAddress,
+		params.ExpiresAt,
+	).Scan(&id)
+	if createError != nil {
+		return "", createError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return id, nil
+}
+
+func RevokeSessionFamily(ctx context.Context, db *pgxpool.Pool, familyId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.session SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL;"
+	_, updateError := db.Exec(ctx, query, familyId)
+
+	if updateError != nil {
+		return false, updateError
+	}
+
+	return true, nil
+}
+
+func RevokeSessionFamilyBySessionId(ctx context.Context, db *pgxpool.Pool, sessionId string, clientId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE everytrack_backend.session SET revoked_at = now()
+	WHERE revoked_at IS NULL AND family_id = (SELECT family_id FROM everytrack_backend.session WHERE id = $1 AND client_id = $2);`
+	result, updateError := db.Exec(ctx, query, sessionId, clientId)
+
+	if updateError != nil {
+		return false, updateError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
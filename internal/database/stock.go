@@ -12,10 +12,13 @@ type UpdateStockPriceParams struct {
 	CurrentPrice string `json:"current_price"`
 }
 
-func GetAllStocks(db *pgxpool.Pool) ([]Stock, error) {
+func GetAllStocks(ctx context.Context, db *pgxpool.Pool) ([]Stock, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	var stocks []Stock
 	query := `SELECT id, country_id, currency_id, name, ticker, current_price FROM everytrack_backend.stock;`
-	rows, queryError := db.Query(context.Background(), query)
+	rows, queryError := db.Query(ctx, query)
 	if queryError != nil {
 		return []Stock{}, queryError
 	}
@@ -34,13 +37,16 @@ func GetAllStocks(db *pgxpool.Pool) ([]Stock, error) {
 	return stocks, nil
 }
 
-func GetAllStocksByCountryId(db *pgxpool.Pool, id string) ([]Stock, error) {
+func GetAllStocksByCountryId(ctx context.Context, db *pgxpool.Pool, id string) ([]Stock, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	stocks := []Stock{}
 	query := `SELECT s.id, country_id, currency_id, s.name, ticker, current_price
 	FROM everytrack_backend.stock as s
 	INNER JOIN everytrack_backend.country as c ON s.country_id = c.id
 	WHERE s.country_id = $1;`
-	rows, queryError := db.Query(context.Background(), query, id)
+	rows, queryError := db.Query(ctx, query, id)
 	if queryError != nil {
 		return []Stock{}, queryError
 	}
@@ -59,9 +65,12 @@ func GetAllStocksByCountryId(db *pgxpool.Pool, id string) ([]Stock, error) {
 	return stocks, nil
 }
 
-func UpdateStockPrice(db *pgxpool.Pool, params UpdateStockPriceParams) (bool, error) {
+func UpdateStockPrice(ctx context.Context, db *pgxpool.Pool, params UpdateStockPriceParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE everytrack_backend.stock SET current_price = $1 WHERE ticker = $2 AND country_id = $3;"
-	_, updateError := db.Exec(context.Background(), query, params.CurrentPrice, params.Ticker, params.CountryId)
+	_, updateError := db.Exec(ctx, query, params.CurrentPrice, params.Ticker, params.CountryId)
 
 	if updateError != nil {
 		return false, updateError
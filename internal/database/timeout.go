@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Deadline of every database function call, applied on top of the deadline of the context passed in
+var queryTimeout = 10 * time.Second
+
+// Set the deadline of every database function call, zero or negative disables it
+func SetQueryTimeout(timeout time.Duration) {
+	queryTimeout = timeout
+}
+
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, queryTimeout)
+}
@@ -8,15 +8,16 @@ import (
 )
 
 type CreateNewTransactionParams struct {
-	Name       string    `json:"name"`
-	Income     bool      `json:"income"`
-	Amount     string    `json:"amount"`
-	Remarks    *string   `json:"remarks"`
-	Category   string    `json:"category"`
-	ClientId   string    `json:"client_id"`
-	AccountId  string    `json:"account_id"`
-	CurrencyId string    `json:"currency_id"`
-	ExecutedAt time.Time `json:"executed_at"`
+	Name        string    `json:"name"`
+	Income      bool      `json:"income"`
+	Amount      string    `json:"amount"`
+	Remarks     *string   `json:"remarks"`
+	Category    string    `json:"category"`
+	ClientId    string    `json:"client_id"`
+	WorkspaceId string    `json:"workspace_id"`
+	AccountId   string    `json:"account_id"`
+	CurrencyId  string    `json:"currency_id"`
+	ExecutedAt  time.Time `json:"executed_at"`
 }
 
 type TransactionAndAccountBalance struct {
@@ -26,10 +27,89 @@ type TransactionAndAccountBalance struct {
 	AccountId string `json:"account_id"`
 }
 
-func GetAllTransactions(db *pgxpool.Pool, clientId string) ([]Transaction, error) {
+func GetAllTransactions(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	transactions := []Transaction{}
+	query := `SELECT id, name, income, account_id, currency_id, category, amount, remarks, executed_at FROM everytrack_backend.transaction WHERE workspace_id = $1;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return transactions, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction Transaction
+		scanError := rows.Scan(
+			&transaction.Id,
+			&transaction.Name,
+			&transaction.Income,
+			&transaction.AccountId,
+			&transaction.CurrencyId,
+			&transaction.Category,
+			&transaction.Amount,
+			&transaction.Remarks,
+			&transaction.ExecutedAt,
+		)
+		if scanError != nil {
+			return transactions, scanError
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+func GetAllTransactionsByAccountIdBetween(ctx context.Context, db *pgxpool.Pool, accountId string, start time.Time, end time.Time) ([]Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	transactions := []Transaction{}
+	query := `SELECT id, name, income, account_id, currency_id, category, amount, remarks, executed_at
+	FROM everytrack_backend.transaction
+	WHERE account_id = $1 AND executed_at >= $2 AND executed_at < $3
+	ORDER BY executed_at;`
+	rows, queryError := db.Query(ctx, query, accountId, start, end)
+	if queryError != nil {
+		return transactions, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction Transaction
+		scanError := rows.Scan(
+			&transaction.Id,
+			&transaction.Name,
+			&transaction.Income,
+			&transaction.AccountId,
+			&transaction.CurrencyId,
+			&transaction.Category,
+			&transaction.Amount,
+			&transaction.Remarks,
+			&transaction.ExecutedAt,
+		)
+		if scanError != nil {
+			return transactions, scanError
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+func GetAllTransactionsByAccountIdAfter(ctx context.Context, db *pgxpool.Pool, accountId string, after time.Time) ([]Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	transactions := []Transaction{}
-	query := `SELECT id, name, income, account_id, currency_id, category, amount, remarks, executed_at FROM everytrack_backend.transaction WHERE client_id = $1;`
-	rows, queryError := db.Query(context.Background(), query, clientId)
+	query := `SELECT id, name, income, account_id, currency_id, category, amount, remarks, executed_at
+	FROM everytrack_backend.transaction
+	WHERE account_id = $1 AND executed_at > $2
+	ORDER BY executed_at;`
+	rows, queryError := db.Query(ctx, query, accountId, after)
 	if queryError != nil {
 		return transactions, queryError
 	}
@@ -58,13 +138,54 @@ func GetAllTransactions(db *pgxpool.Pool, clientId string) ([]Transaction, error
 	return transactions, nil
 }
 
-func GetTransactionAndAccountBalanceById(db *pgxpool.Pool, transactionId string) (TransactionAndAccountBalance, error) {
+func GetAllTransactionsByAccountIdSince(ctx context.Context, db *pgxpool.Pool, accountId string, since time.Time) ([]Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	transactions := []Transaction{}
+	query := `SELECT id, name, income, account_id, currency_id, category, amount, remarks, executed_at
+	FROM everytrack_backend.transaction
+	WHERE account_id = $1 AND executed_at >= $2
+	ORDER BY executed_at;`
+	rows, queryError := db.Query(ctx, query, accountId, since)
+	if queryError != nil {
+		return transactions, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction Transaction
+		scanError := rows.Scan(
+			&transaction.Id,
+			&transaction.Name,
+			&transaction.Income,
+			&transaction.AccountId,
+			&transaction.CurrencyId,
+			&transaction.Category,
+			&transaction.Amount,
+			&transaction.Remarks,
+			&transaction.ExecutedAt,
+		)
+		if scanError != nil {
+			return transactions, scanError
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+func GetTransactionAndAccountBalanceById(ctx context.Context, db *pgxpool.Pool, transactionId string) (TransactionAndAccountBalance, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	result := TransactionAndAccountBalance{}
 	query := `SELECT amount, income, balance, account_id
 	FROM everytrack_backend.transaction as t
 	INNER JOIN everytrack_backend.account as a ON a.id = t.account_id
 	WHERE t.id = $1;`
-	queryError := db.QueryRow(context.Background(), query, transactionId).Scan(&result.Amount, &result.Income, &result.Balance, &result.AccountId)
+	queryError := db.QueryRow(ctx, query, transactionId).Scan(&result.Amount, &result.Income, &result.Balance, &result.AccountId)
 	if queryError != nil {
 		return result, queryError
 	}
@@ -72,12 +193,16 @@ func GetTransactionAndAccountBalanceById(db *pgxpool.Pool, transactionId string)
 	return result, nil
 }
 
-func CreateNewTransaction(db *pgxpool.Pool, params CreateNewTransactionParams) (bool, error) {
-	query := "INSERT INTO everytrack_backend.transaction (client_id, account_id, currency_id, name, category, amount, income, remarks, executed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);"
+func CreateNewTransaction(ctx context.Context, db *pgxpool.Pool, params CreateNewTransactionParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO everytrack_backend.transaction (client_id, workspace_id, account_id, currency_id, name, category, amount, income, remarks, executed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);"
 	_, createError := db.Exec(
-		context.Background(),
+		ctx,
 		query,
 		params.ClientId,
+		params.WorkspaceId,
 		params.AccountId,
 		params.CurrencyId,
 		params.Name,
@@ -95,9 +220,12 @@ func CreateNewTransaction(db *pgxpool.Pool, params CreateNewTransactionParams) (
 	return true, nil
 }
 
-func DeleteTransaction(db *pgxpool.Pool, transactionId string, clientId string) (bool, error) {
-	query := "DELETE FROM everytrack_backend.transaction WHERE id = $1 AND client_id = $2;"
-	_, deleteError := db.Exec(context.Background(), query, transactionId, clientId)
+func DeleteTransaction(ctx context.Context, db *pgxpool.Pool, transactionId string, workspaceId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM everytrack_backend.transaction WHERE id = $1 AND workspace_id = $2;"
+	_, deleteError := db.Exec(ctx, query, transactionId, workspaceId)
 
 	if deleteError != nil {
 		return false, deleteError
@@ -0,0 +1,422 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrWorkspaceWithoutOwner   = errors.New("workspace must have at least one owner")
+	ErrWorkspaceAlreadyMember  = errors.New("client is a member of the workspace already")
+	ErrWorkspaceInviteNotFound = errors.New("workspace invitation does not exist, expired or has been accepted")
+)
+
+// Name of the workspace created for every client to hold its own records
+const defaultWorkspaceName = "Personal"
+
+type WorkspaceMembership struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceMemberDetails struct {
+	ClientId  string    `json:"client_id"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateNewWorkspaceInvitationParams struct {
+	WorkspaceId string    `json:"workspace_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	TokenHash   string    `json:"token_hash"`
+	InvitedBy   string    `json:"invited_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func createWorkspace(ctx context.Context, tx pgx.Tx, name string, ownerId string) (string, error) {
+	var workspaceId string
+	createWorkspaceQuery := "INSERT INTO everytrack_backend.workspace (name) VALUES ($1) RETURNING id;"
+	if createWorkspaceError := tx.QueryRow(ctx, createWorkspaceQuery, name).Scan(&workspaceId); createWorkspaceError != nil {
+		return "", createWorkspaceError
+	}
+
+	createMemberQuery := "INSERT INTO everytrack_backend.workspace_member (workspace_id, client_id, role) VALUES ($1, $2, 'owner');"
+	if _, createMemberError := tx.Exec(ctx, createMemberQuery, workspaceId, ownerId); createMemberError != nil {
+		return "", createMemberError
+	}
+
+	return workspaceId, nil
+}
+
+// Return an error if the workspace would be left without any owner, which must be checked before committing membership changes
+func ensureWorkspaceOwner(ctx context.Context, tx pgx.Tx, workspaceId string) error {
+	var ownerCount int
+	query := "SELECT count(*) FROM everytrack_backend.workspace_member WHERE workspace_id = $1 AND role = 'owner';"
+	if queryError := tx.QueryRow(ctx, query, workspaceId).Scan(&ownerCount); queryError != nil {
+		return queryError
+	}
+	if ownerCount == 0 {
+		return ErrWorkspaceWithoutOwner
+	}
+
+	return nil
+}
+
+func GetAllWorkspacesByClientId(ctx context.Context, db *pgxpool.Pool, clientId string) ([]WorkspaceMembership, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	workspaces := []WorkspaceMembership{}
+	query := `SELECT w.id, w.name, wm.role, w.created_at
+	FROM everytrack_backend.workspace_member AS wm
+	INNER JOIN everytrack_backend.workspace AS w ON w.id = wm.workspace_id
+	WHERE wm.client_id = $1
+	ORDER BY wm.created_at;`
+	rows, queryError := db.Query(ctx, query, clientId)
+	if queryError != nil {
+		return workspaces, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var workspace WorkspaceMembership
+		scanError := rows.Scan(&workspace.Id, &workspace.Name, &workspace.Role, &workspace.CreatedAt)
+		if scanError != nil {
+			return workspaces, scanError
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
+
+func GetWorkspaceMember(ctx context.Context, db *pgxpool.Pool, workspaceId string, clientId string) (WorkspaceMember, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var member WorkspaceMember
+	query := "SELECT workspace_id, client_id, role, created_at FROM everytrack_backend.workspace_member WHERE workspace_id = $1 AND client_id = $2;"
+	queryError := db.QueryRow(ctx, query, workspaceId, clientId).Scan(&member.WorkspaceId, &member.ClientId, &member.Role, &member.CreatedAt)
+
+	if queryError != nil {
+		return member, queryError
+	}
+
+	return member, nil
+}
+
+// Get the membership of the first workspace client owns, creating a personal workspace for client if there is none yet
+func GetOrCreateDefaultWorkspaceMember(ctx context.Context, db *pgxpool.Pool, clientId string) (WorkspaceMember, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var member WorkspaceMember
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return member, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock client so that concurrent requests cannot create more than one personal workspace
+	lockClientQuery := "SELECT id FROM everytrack_backend.client WHERE id = $1 FOR UPDATE;"
+	if lockClientError := tx.QueryRow(ctx, lockClientQuery, clientId).Scan(&clientId); lockClientError != nil {
+		return member, lockClientError
+	}
+
+	getMemberQuery := `SELECT workspace_id, client_id, role, created_at FROM everytrack_backend.workspace_member
+	WHERE client_id = $1 AND role = 'owner' ORDER BY created_at LIMIT 1;`
+	getMemberError := tx.QueryRow(ctx, getMemberQuery, clientId).Scan(&member.WorkspaceId, &member.ClientId, &member.Role, &member.CreatedAt)
+	if getMemberError == nil {
+		return member, nil
+	}
+	if !errors.Is(getMemberError, pgx.ErrNoRows) {
+		return member, getMemberError
+	}
+
+	workspaceId, createWorkspaceError := createWorkspace(ctx, tx, defaultWorkspaceName, clientId)
+	if createWorkspaceError != nil {
+		return member, createWorkspaceError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return member, commitError
+	}
+
+	return WorkspaceMember{WorkspaceId: workspaceId, ClientId: clientId, Role: "owner", CreatedAt: time.Now()}, nil
+}
+
+func CreateNewWorkspace(ctx context.Context, db *pgxpool.Pool, name string, ownerId string) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	workspaceId, createWorkspaceError := createWorkspace(ctx, tx, name, ownerId)
+	if createWorkspaceError != nil {
+		return "", createWorkspaceError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return workspaceId, nil
+}
+
+func UpdateWorkspaceName(ctx context.Context, db *pgxpool.Pool, workspaceId string, name string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE everytrack_backend.workspace SET name = $1, updated_at = now() WHERE id = $2;"
+	_, updateError := db.Exec(ctx, query, name, workspaceId)
+
+	if updateError != nil {
+		return false, updateError
+	}
+
+	return true, nil
+}
+
+func GetAllWorkspaceMembers(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]WorkspaceMemberDetails, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	members := []WorkspaceMemberDetails{}
+	query := `SELECT wm.client_id, c.email, c.username, wm.role, wm.created_at
+	FROM everytrack_backend.workspace_member AS wm
+	INNER JOIN everytrack_backend.client AS c ON c.id = wm.client_id
+	WHERE wm.workspace_id = $1
+	ORDER BY wm.created_at;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return members, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var member WorkspaceMemberDetails
+		scanError := rows.Scan(&member.ClientId, &member.Email, &member.Username, &member.Role, &member.CreatedAt)
+		if scanError != nil {
+			return members, scanError
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// Change role of a member, returns ErrWorkspaceWithoutOwner if it would demote the last owner
+func UpdateWorkspaceMemberRole(ctx context.Context, db *pgxpool.Pool, workspaceId string, clientId string, role string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	query := "UPDATE everytrack_backend.workspace_member SET role = $1 WHERE workspace_id = $2 AND client_id = $3;"
+	result, updateError := tx.Exec(ctx, query, role, workspaceId, clientId)
+	if updateError != nil {
+		return false, updateError
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if ensureOwnerError := ensureWorkspaceOwner(ctx, tx, workspaceId); ensureOwnerError != nil {
+		return false, ensureOwnerError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+// Remove a member from workspace, returns ErrWorkspaceWithoutOwner if it would remove the last owner
+func DeleteWorkspaceMember(ctx context.Context, db *pgxpool.Pool, workspaceId string, clientId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	query := "DELETE FROM everytrack_backend.workspace_member WHERE workspace_id = $1 AND client_id = $2;"
+	result, deleteError := tx.Exec(ctx, query, workspaceId, clientId)
+	if deleteError != nil {
+		return false, deleteError
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if ensureOwnerError := ensureWorkspaceOwner(ctx, tx, workspaceId); ensureOwnerError != nil {
+		return false, ensureOwnerError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+func GetAllPendingWorkspaceInvitations(ctx context.Context, db *pgxpool.Pool, workspaceId string) ([]WorkspaceInvitation, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	invitations := []WorkspaceInvitation{}
+	query := `SELECT id, workspace_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+	FROM everytrack_backend.workspace_invitation
+	WHERE workspace_id = $1 AND accepted_at IS NULL AND expires_at > now()
+	ORDER BY created_at DESC;`
+	rows, queryError := db.Query(ctx, query, workspaceId)
+	if queryError != nil {
+		return invitations, queryError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var invitation WorkspaceInvitation
+		scanError := rows.Scan(
+			&invitation.Id,
+			&invitation.WorkspaceId,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.TokenHash,
+			&invitation.InvitedBy,
+			&invitation.ExpiresAt,
+			&invitation.AcceptedAt,
+			&invitation.CreatedAt,
+		)
+		if scanError != nil {
+			return invitations, scanError
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+// Invite an email into workspace, replacing any pending invitation of the same email so that only the latest link works
+func CreateNewWorkspaceInvitation(ctx context.Context, db *pgxpool.Pool, params CreateNewWorkspaceInvitationParams) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return false, beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var memberCount int
+	checkMemberQuery := `SELECT count(*) FROM everytrack_backend.workspace_member AS wm
+	INNER JOIN everytrack_backend.client AS c ON c.id = wm.client_id
+	WHERE wm.workspace_id = $1 AND lower(c.email) = lower($2);`
+	if checkMemberError := tx.QueryRow(ctx, checkMemberQuery, params.WorkspaceId, params.Email).Scan(&memberCount); checkMemberError != nil {
+		return false, checkMemberError
+	}
+	if memberCount > 0 {
+		return false, ErrWorkspaceAlreadyMember
+	}
+
+	voidQuery := "DELETE FROM everytrack_backend.workspace_invitation WHERE workspace_id = $1 AND lower(email) = lower($2) AND accepted_at IS NULL;"
+	if _, voidError := tx.Exec(ctx, voidQuery, params.WorkspaceId, params.Email); voidError != nil {
+		return false, voidError
+	}
+
+	createQuery := `INSERT INTO everytrack_backend.workspace_invitation (workspace_id, email, role, token_hash, invited_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6);`
+	_, createError := tx.Exec(
+		ctx,
+		createQuery,
+		params.WorkspaceId,
+		params.Email,
+		params.Role,
+		params.TokenHash,
+		params.InvitedBy,
+		params.ExpiresAt,
+	)
+	if createError != nil {
+		return false, createError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return false, commitError
+	}
+
+	return true, nil
+}
+
+func RevokeWorkspaceInvitation(ctx context.Context, db *pgxpool.Pool, invitationId string, workspaceId string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM everytrack_backend.workspace_invitation WHERE id = $1 AND workspace_id = $2 AND accepted_at IS NULL;"
+	result, deleteError := db.Exec(ctx, query, invitationId, workspaceId)
+
+	if deleteError != nil {
+		return false, deleteError
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// Consume an invitation addressed to the email of client and add client to its workspace with the invited role.
+// Returns ErrWorkspaceInviteNotFound if the token is unknown, expired, used or addressed to another email.
+func AcceptWorkspaceInvitation(ctx context.Context, db *pgxpool.Pool, tokenHash string, clientId string, email string) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, beginError := db.Begin(ctx)
+	if beginError != nil {
+		return "", beginError
+	}
+	defer tx.Rollback(ctx)
+
+	var workspaceId, role string
+	consumeQuery := `UPDATE everytrack_backend.workspace_invitation SET accepted_at = now()
+	WHERE token_hash = $1 AND lower(email) = lower($2) AND accepted_at IS NULL AND expires_at > now()
+	RETURNING workspace_id, role;`
+	consumeError := tx.QueryRow(ctx, consumeQuery, tokenHash, email).Scan(&workspaceId, &role)
+	if consumeError != nil {
+		if errors.Is(consumeError, pgx.ErrNoRows) {
+			return "", ErrWorkspaceInviteNotFound
+		}
+		return "", consumeError
+	}
+
+	createMemberQuery := `INSERT INTO everytrack_backend.workspace_member (workspace_id, client_id, role) VALUES ($1, $2, $3)
+	ON CONFLICT (workspace_id, client_id) DO NOTHING;`
+	if _, createMemberError := tx.Exec(ctx, createMemberQuery, workspaceId, clientId, role); createMemberError != nil {
+		return "", createMemberError
+	}
+
+	if commitError := tx.Commit(ctx); commitError != nil {
+		return "", commitError
+	}
+
+	return workspaceId, nil
+}
@@ -5,11 +5,13 @@ import (
 	"os"
 	"time"
 
+	"github.com/nighostchris/everytrack-backend/internal/redact"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-func New(logLevel string) *zap.Logger {
+// Logger writing JSON logs at logLevel, with sensitive values masked by redactor
+func New(logLevel string, redactor *redact.Redactor) *zap.Logger {
 	logTemplate := "{\"level\":\"%s\",\"timestamp\":\"%s\",\"function\":\"github.com/nighostchris/everytrack-backend/internal/logger.New\",\"message\":\"%s\"}\n"
 	config := zap.NewProductionConfig()
 	fmt.Printf(logTemplate, "info", time.Now().Format(time.RFC3339Nano), "initializing logger")
@@ -42,7 +44,7 @@ func New(logLevel string) *zap.Logger {
 	config.EncoderConfig = encoderConfig
 
 	// Create logger instance
-	zapLogger, buildLoggerError := config.Build()
+	zapLogger, buildLoggerError := config.Build(zap.WrapCore(redactor.Core))
 	if buildLoggerError != nil {
 		fmt.Printf(logTemplate, "error", time.Now().Format(time.RFC3339Nano), buildLoggerError.Error())
 		os.Exit(1)
@@ -0,0 +1,23 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Mailer for local development which writes every mail as a .eml file into a directory
+type FileMailer struct {
+	Directory string
+	From      string
+}
+
+func (fm *FileMailer) Send(mail Mail) error {
+	if makeDirectoryError := os.MkdirAll(fm.Directory, 0o755); makeDirectoryError != nil {
+		return makeDirectoryError
+	}
+
+	fileName := filepath.Join(fm.Directory, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+	return os.WriteFile(fileName, buildMessage(fm.From, mail), 0o644)
+}
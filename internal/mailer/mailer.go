@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"go.uber.org/zap"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+func New(env *config.Config, logger *zap.Logger) Mailer {
+	logger.Info(fmt.Sprintf("initializing %s mailer", env.Mailer))
+
+	switch env.Mailer {
+	case "smtp":
+		return &SmtpMailer{
+			Host:     env.SmtpHost,
+			Port:     env.SmtpPort,
+			Username: env.SmtpUsername,
+			Password: env.SmtpPassword,
+			From:     env.MailFrom,
+		}
+	case "file":
+		return &FileMailer{Directory: env.MailerFileDirectory, From: env.MailFrom}
+	case "log":
+		return &LogMailer{Logger: logger, From: env.MailFrom}
+	default:
+		logger.Error(fmt.Sprintf("mailer %q is not supported, every mail is going to fail", env.Mailer))
+		return &UnconfiguredMailer{}
+	}
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SmtpMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func buildMessage(from string, mail Mail) []byte {
+	headers := []string{
+		fmt.Sprintf("From: %s", from),
+		fmt.Sprintf("To: %s", mail.To),
+		fmt.Sprintf("Subject: %s", mail.Subject),
+		fmt.Sprintf("Date: %s", time.Now().Format(time.RFC1123Z)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + mail.Body)
+}
+
+func (sm *SmtpMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if len(sm.Username) > 0 {
+		auth = smtp.PlainAuth("", sm.Username, sm.Password, sm.Host)
+	}
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", sm.Host, sm.Port), auth, sm.From, []string{mail.To}, buildMessage(sm.From, mail))
+}
@@ -0,0 +1,12 @@
+package mailer
+
+import (
+	"errors"
+)
+
+// Mailer used when no mailer is configured, which fails every mail so that missing configuration shows up in error logs
+type UnconfiguredMailer struct{}
+
+func (um *UnconfiguredMailer) Send(mail Mail) error {
+	return errors.New("mailer is not configured, set MAILER to smtp / file / log")
+}
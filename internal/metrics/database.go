@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Collector reading connection pool stats and business figures from database whenever metrics are scraped
+type DatabaseCollector struct {
+	Db     *pgxpool.Pool
+	Logger *zap.Logger
+}
+
+var (
+	poolAcquiredConnsDesc      = newDatabaseDesc("pool_acquired_connections", "Number of connections currently acquired from pool.")
+	poolIdleConnsDesc          = newDatabaseDesc("pool_idle_connections", "Number of idle connections in pool.")
+	poolTotalConnsDesc         = newDatabaseDesc("pool_total_connections", "Number of connections in pool, constructing ones included.")
+	poolMaxConnsDesc           = newDatabaseDesc("pool_max_connections", "Maximum size of pool.")
+	poolAcquiresDesc           = newDatabaseDesc("pool_acquires_total", "Number of successful acquires from pool.")
+	poolAcquireDurationDesc    = newDatabaseDesc("pool_acquire_duration_seconds_total", "Total time spent on successful acquires from pool.")
+	poolEmptyAcquiresDesc      = newDatabaseDesc("pool_empty_acquires_total", "Number of successful acquires which had to wait for a connection.")
+	poolCanceledAcquiresDesc   = newDatabaseDesc("pool_canceled_acquires_total", "Number of acquires cancelled by their context.")
+	poolNewConnsDesc           = newDatabaseDesc("pool_new_connections_total", "Number of connections opened by pool.")
+	dueFuturePaymentsDesc      = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "due_future_payments"), "Number of future payments whose schedule has passed without being executed.", nil, nil)
+	exchangeRatesUpdatedAtDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "exchange_rates_last_updated_timestamp_seconds"), "Unix time exchange rates were last refreshed.", nil, nil)
+)
+
+func newDatabaseDesc(name string, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "database", name), help, nil, nil)
+}
+
+func (dc *DatabaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConnsDesc
+	ch <- poolIdleConnsDesc
+	ch <- poolTotalConnsDesc
+	ch <- poolMaxConnsDesc
+	ch <- poolAcquiresDesc
+	ch <- poolAcquireDurationDesc
+	ch <- poolEmptyAcquiresDesc
+	ch <- poolCanceledAcquiresDesc
+	ch <- poolNewConnsDesc
+	ch <- dueFuturePaymentsDesc
+	ch <- exchangeRatesUpdatedAtDesc
+}
+
+func (dc *DatabaseCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := dc.Db.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConnsDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConnsDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConnsDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConnsDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDurationDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquiresDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquiresDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolNewConnsDesc, prometheus.CounterValue, float64(stat.NewConnsCount()))
+
+	// Business figures are left out of the scrape when database cannot be queried, instead of reporting misleading zeros
+	ctx := context.Background()
+	dueFuturePayments, countError := database.CountDueFuturePayments(ctx, dc.Db)
+	if countError != nil {
+		dc.Logger.Error(fmt.Sprintf("failed to count due future payments in database. %s", countError.Error()))
+	} else {
+		ch <- prometheus.MustNewConstMetric(dueFuturePaymentsDesc, prometheus.GaugeValue, float64(dueFuturePayments))
+	}
+
+	lastUpdatedAt, getLastUpdatedAtError := database.GetLatestExchangeRateUpdate(ctx, dc.Db)
+	if getLastUpdatedAtError != nil {
+		dc.Logger.Error(fmt.Sprintf("failed to get latest exchange rate update from database. %s", getLastUpdatedAtError.Error()))
+	} else if lastUpdatedAt.Valid {
+		ch <- prometheus.MustNewConstMetric(exchangeRatesUpdatedAtDesc, prometheus.GaugeValue, float64(lastUpdatedAt.Time.UnixNano())/float64(time.Second))
+	}
+}
+
+// Expose pool stats and business figures of database on /metrics
+func RegisterDatabaseCollector(db *pgxpool.Pool, logger *zap.Logger) {
+	Registry.MustRegister(&DatabaseCollector{Db: db, Logger: logger})
+}
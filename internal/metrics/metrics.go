@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "everytrack"
+
+// Registry holding every metric exposed on /metrics, kept apart from the global default registry
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HttpRequestsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	HttpRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	CronJobRunsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cron_job_runs_total",
+		Help:      "Number of cron job rounds run, by job.",
+	}, []string{"job"})
+	CronJobFailuresTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cron_job_failures_total",
+		Help:      "Number of cron job rounds which ended with an error, by job.",
+	}, []string{"job"})
+	CronJobDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cron_job_duration_seconds",
+		Help:      "Time taken by a cron job round, by job.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900},
+	}, []string{"job"})
+
+	ExternalApiRequestsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "external_api_requests_total",
+		Help:      "Number of requests sent to external APIs, by api and outcome (success / error).",
+	}, []string{"api", "outcome"})
+	ExternalApiRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "external_api_request_duration_seconds",
+		Help:      "Latency of requests sent to external APIs, by api.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"api"})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Handler serving every metric in the registry in Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Record a request sent to an external API, which fails if it could not be sent or the API responds with an error status
+func ObserveExternalApiCall(api string, startedAt time.Time, response *http.Response, err error) {
+	ExternalApiRequestDuration.WithLabelValues(api).Observe(time.Since(startedAt).Seconds())
+
+	outcome := "success"
+	if err != nil || response.StatusCode >= http.StatusBadRequest {
+		outcome = "error"
+	}
+	ExternalApiRequestsTotal.WithLabelValues(api, outcome).Inc()
+}
+
+// Record a cron job round, failed if the job returns an error
+func ObserveCronJob(job string, startedAt time.Time, err error) {
+	CronJobRunsTotal.WithLabelValues(job).Inc()
+	CronJobDuration.WithLabelValues(job).Observe(time.Since(startedAt).Seconds())
+	if err != nil {
+		CronJobFailuresTotal.WithLabelValues(job).Inc()
+	}
+}
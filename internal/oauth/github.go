@@ -0,0 +1,72 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// GitHub speaks plain OAuth 2.0 instead of OpenID Connect, the identity is read from its REST API instead of an id token
+type GithubProvider struct {
+	BaseUrl      string
+	ApiUrl       string
+	ClientId     string
+	ClientSecret string
+}
+
+func (gp *GithubProvider) AuthCodeUrl(state string, nonce string, redirectUri string) (string, error) {
+	query := url.Values{}
+	query.Set("client_id", gp.ClientId)
+	query.Set("redirect_uri", redirectUri)
+	query.Set("scope", "read:user user:email")
+	query.Set("state", state)
+
+	return fmt.Sprintf("%s/login/oauth/authorize?%s", gp.BaseUrl, query.Encode()), nil
+}
+
+func (gp *GithubProvider) Exchange(code string, nonce string, redirectUri string) (Identity, error) {
+	var identity Identity
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	tokenEndpoint := fmt.Sprintf("%s/login/oauth/access_token", gp.BaseUrl)
+	if exchangeError := exchangeAuthorizationCode(tokenEndpoint, gp.ClientId, gp.ClientSecret, code, redirectUri, &tokenResponse); exchangeError != nil {
+		return identity, exchangeError
+	}
+	if len(tokenResponse.AccessToken) == 0 {
+		return identity, fmt.Errorf("failed to exchange authorization code. %s", tokenResponse.Error)
+	}
+
+	var user struct {
+		Id int64 `json:"id"`
+	}
+	if getUserError := getJson(fmt.Sprintf("%s/user", gp.ApiUrl), tokenResponse.AccessToken, &user); getUserError != nil {
+		return identity, getUserError
+	}
+	if user.Id == 0 {
+		return identity, errors.New("github user does not have an id")
+	}
+
+	// Email on the user profile may be hidden, the primary email together with its verification status has to be fetched separately
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if getEmailsError := getJson(fmt.Sprintf("%s/user/emails", gp.ApiUrl), tokenResponse.AccessToken, &emails); getEmailsError != nil {
+		return identity, getEmailsError
+	}
+
+	identity.Subject = fmt.Sprintf("%d", user.Id)
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = strings.ToLower(email.Email)
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,113 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"go.uber.org/zap"
+)
+
+// Identity of the end user asserted by the identity provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type Provider interface {
+	// URL of the provider consent page which the browser is redirected to
+	AuthCodeUrl(state string, nonce string, redirectUri string) (string, error)
+	// Exchange authorization code returned to the callback for the identity of the end user
+	Exchange(code string, nonce string, redirectUri string) (Identity, error)
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Initialize every provider with client credentials configured, keyed by the provider name used in routes
+func New(env *config.Config, logger *zap.Logger) map[string]Provider {
+	providers := map[string]Provider{}
+
+	if len(env.GoogleClientId) > 0 {
+		logger.Info("initializing google oauth provider")
+		providers["google"] = &OidcProvider{
+			Issuer:       env.GoogleIssuer,
+			ClientId:     env.GoogleClientId,
+			ClientSecret: env.GoogleClientSecret,
+		}
+	}
+	if len(env.GithubClientId) > 0 {
+		logger.Info("initializing github oauth provider")
+		providers["github"] = &GithubProvider{
+			BaseUrl:      strings.TrimSuffix(env.GithubBaseUrl, "/"),
+			ApiUrl:       strings.TrimSuffix(env.GithubApiUrl, "/"),
+			ClientId:     env.GithubClientId,
+			ClientSecret: env.GithubClientSecret,
+		}
+	}
+	if len(env.OidcIssuer) > 0 {
+		logger.Info(fmt.Sprintf("initializing %s oauth provider with issuer %s", env.OidcProviderName, env.OidcIssuer))
+		providers[env.OidcProviderName] = &OidcProvider{
+			Issuer:       env.OidcIssuer,
+			ClientId:     env.OidcClientId,
+			ClientSecret: env.OidcClientSecret,
+		}
+	}
+
+	return providers
+}
+
+// Exchange authorization code at token endpoint, with the response decoded into result
+func exchangeAuthorizationCode(tokenEndpoint string, clientId string, clientSecret string, code string, redirectUri string, result interface{}) error {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectUri)
+	form.Set("client_id", clientId)
+	form.Set("client_secret", clientSecret)
+
+	request, newRequestError := http.NewRequest(http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if newRequestError != nil {
+		return newRequestError
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	return doJsonRequest(request, result)
+}
+
+func getJson(endpoint string, accessToken string, result interface{}) error {
+	request, newRequestError := http.NewRequest(http.MethodGet, endpoint, nil)
+	if newRequestError != nil {
+		return newRequestError
+	}
+	request.Header.Set("Accept", "application/json")
+	if len(accessToken) > 0 {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	}
+
+	return doJsonRequest(request, result)
+}
+
+func doJsonRequest(request *http.Request, result interface{}) error {
+	response, requestError := httpClient.Do(request)
+	if requestError != nil {
+		return requestError
+	}
+	defer response.Body.Close()
+
+	body, readError := io.ReadAll(response.Body)
+	if readError != nil {
+		return readError
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", response.StatusCode, request.URL.String())
+	}
+
+	return json.Unmarshal(body, result)
+}
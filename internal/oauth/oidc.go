@@ -0,0 +1,207 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// OpenID Connect provider configured through the discovery document of its issuer
+type OidcProvider struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+
+	mutex     sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]interface{}
+}
+
+func (op *OidcProvider) getDiscoveryDocument() (*discoveryDocument, error) {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	if op.discovery != nil {
+		return op.discovery, nil
+	}
+
+	document := new(discoveryDocument)
+	discoveryUrl := fmt.Sprintf("%s/.well-known/openid-configuration", strings.TrimSuffix(op.Issuer, "/"))
+	if fetchError := getJson(discoveryUrl, "", document); fetchError != nil {
+		return nil, fetchError
+	}
+	if document.Issuer != op.Issuer {
+		return nil, fmt.Errorf("discovery document issuer %s does not match configured issuer %s", document.Issuer, op.Issuer)
+	}
+	op.discovery = document
+
+	return op.discovery, nil
+}
+
+// Get the signing key by key id, refetching the key set once when the key is unknown as the issuer may have rotated keys
+func (op *OidcProvider) getSigningKey(kid string) (interface{}, error) {
+	op.mutex.Lock()
+	key, found := op.keys[kid]
+	op.mutex.Unlock()
+	if found {
+		return key, nil
+	}
+
+	document, discoveryError := op.getDiscoveryDocument()
+	if discoveryError != nil {
+		return nil, discoveryError
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if fetchError := getJson(document.JwksUri, "", &keySet); fetchError != nil {
+		return nil, fetchError
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range keySet.Keys {
+		publicKey, parseError := parseJsonWebKey(jwk)
+		if parseError != nil {
+			continue
+		}
+		keys[jwk.Kid] = publicKey
+	}
+
+	op.mutex.Lock()
+	op.keys = keys
+	op.mutex.Unlock()
+
+	if key, found := keys[kid]; found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("signing key %s not found in key set of %s", kid, op.Issuer)
+}
+
+func parseJsonWebKey(jwk jsonWebKey) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, decodeNError := base64.RawURLEncoding.DecodeString(jwk.N)
+		if decodeNError != nil {
+			return nil, decodeNError
+		}
+		e, decodeEError := base64.RawURLEncoding.DecodeString(jwk.E)
+		if decodeEError != nil {
+			return nil, decodeEError
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, decodeXError := base64.RawURLEncoding.DecodeString(jwk.X)
+		if decodeXError != nil {
+			return nil, decodeXError
+		}
+		y, decodeYError := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if decodeYError != nil {
+			return nil, decodeYError
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+}
+
+func (op *OidcProvider) AuthCodeUrl(state string, nonce string, redirectUri string) (string, error) {
+	document, discoveryError := op.getDiscoveryDocument()
+	if discoveryError != nil {
+		return "", discoveryError
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", op.ClientId)
+	query.Set("redirect_uri", redirectUri)
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+
+	return fmt.Sprintf("%s?%s", document.AuthorizationEndpoint, query.Encode()), nil
+}
+
+func (op *OidcProvider) Exchange(code string, nonce string, redirectUri string) (Identity, error) {
+	var identity Identity
+
+	document, discoveryError := op.getDiscoveryDocument()
+	if discoveryError != nil {
+		return identity, discoveryError
+	}
+
+	var tokenResponse struct {
+		IdToken string `json:"id_token"`
+	}
+	if exchangeError := exchangeAuthorizationCode(document.TokenEndpoint, op.ClientId, op.ClientSecret, code, redirectUri, &tokenResponse); exchangeError != nil {
+		return identity, exchangeError
+	}
+	if len(tokenResponse.IdToken) == 0 {
+		return identity, errors.New("token response does not contain id token")
+	}
+
+	// Verify id token signature, issuer, audience and expiry
+	claims := new(idTokenClaims)
+	_, parseError := jwt.ParseWithClaims(
+		tokenResponse.IdToken,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return op.getSigningKey(kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(document.Issuer),
+		jwt.WithAudience(op.ClientId),
+	)
+	if parseError != nil {
+		return identity, parseError
+	}
+	if claims.ExpiresAt == nil {
+		return identity, errors.New("id token does not have expiry")
+	}
+	if claims.Nonce != nonce {
+		return identity, errors.New("nonce in id token does not match")
+	}
+
+	identity.Subject = claims.Subject
+	identity.Email = strings.ToLower(claims.Email)
+	identity.EmailVerified = claims.EmailVerified
+
+	return identity, nil
+}
@@ -0,0 +1,49 @@
+package redact
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core redacting message and fields of every log entry before it is written by the wrapped core
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// Wrap core of a logger, to be used with zap.WrapCore
+func (r *Redactor) Core(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core, redactor: r}
+}
+
+func (rc *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: rc.Core.With(rc.redactor.zapFields(fields)), redactor: rc.redactor}
+}
+
+func (rc *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(entry.Level) {
+		return checked.AddCore(entry, rc)
+	}
+	return checked
+}
+
+func (rc *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = rc.redactor.Message(entry.Message)
+	return rc.Core.Write(entry, rc.redactor.zapFields(fields))
+}
+
+// Mask fields with sensitive keys entirely and sensitive values inside string fields
+func (r *Redactor) zapFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for index, field := range fields {
+		switch {
+		case r.IsSensitive(field.Key):
+			redacted[index] = zap.String(field.Key, Placeholder)
+		case field.Type == zapcore.StringType:
+			redacted[index] = zap.String(field.Key, r.Message(field.String))
+		default:
+			redacted[index] = field
+		}
+	}
+	return redacted
+}
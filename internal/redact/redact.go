@@ -0,0 +1,138 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+)
+
+// Placeholder replacing every redacted value
+const Placeholder = "[REDACTED]"
+
+// Field names holding amounts of money, only redacted when LOG_REDACT_AMOUNTS is enabled
+var amountFields = []string{"amount", "balance", "price", "limit"}
+
+var (
+	jwtRegex      = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	apiTokenRegex = regexp.MustCompile(regexp.QuoteMeta(utils.ApiTokenPrefix) + `[A-Za-z0-9_-]+`)
+	bearerRegex   = regexp.MustCompile(`(?i)(bearer)\s+[^\s",]+`)
+	emailRegex    = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	// Key value pairs in formatted structs, query strings and JSON, e.g. Password:"secret", token=abc or "token":"abc"
+	keyValueRegex = regexp.MustCompile(`("?)([A-Za-z][A-Za-z0-9_.-]*)("?\s*[:=]\s*)("(?:[^"\\]|\\.)*"|[^\s,&})\]]+)`)
+)
+
+// Redactor masks sensitive values in request bodies, headers and log messages before they are logged.
+// A field is sensitive if its name, ignoring case and separators, ends with one of the configured rules, e.g. password covers newPassword.
+type Redactor struct {
+	fields []string
+	emails bool
+}
+
+func New(env *config.Config) *Redactor {
+	fields := []string{}
+	for _, field := range env.LogRedactFields {
+		if normalised := normaliseFieldName(field); len(normalised) > 0 {
+			fields = append(fields, normalised)
+		}
+	}
+	if env.LogRedactAmounts {
+		fields = append(fields, amountFields...)
+	}
+
+	return &Redactor{fields: fields, emails: env.LogRedactEmails}
+}
+
+// Lower case field name without separators, so that accessToken, access_token and Access-Token are treated the same
+func normaliseFieldName(name string) string {
+	return strings.NewReplacer("_", "", "-", "", ".", "", " ", "").Replace(strings.ToLower(name))
+}
+
+func (r *Redactor) IsSensitive(name string) bool {
+	normalised := normaliseFieldName(name)
+	for _, field := range r.fields {
+		if strings.HasSuffix(normalised, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask tokens, emails and values of sensitive keys found in free text
+func (r *Redactor) Message(message string) string {
+	message = jwtRegex.ReplaceAllString(message, Placeholder)
+	message = apiTokenRegex.ReplaceAllString(message, Placeholder)
+	message = bearerRegex.ReplaceAllString(message, "$1 "+Placeholder)
+	message = keyValueRegex.ReplaceAllStringFunc(message, func(pair string) string {
+		groups := keyValueRegex.FindStringSubmatch(pair)
+		if !r.IsSensitive(groups[2]) {
+			return pair
+		}
+		value := Placeholder
+		if strings.HasPrefix(groups[4], `"`) {
+			value = `"` + Placeholder + `"`
+		}
+		return groups[1] + groups[2] + groups[3] + value
+	})
+	if r.emails {
+		// Keep first letter and domain, which is usually enough to tell clients apart while debugging
+		message = emailRegex.ReplaceAllString(message, "$1***@$2")
+	}
+	return message
+}
+
+// Compact JSON body with values of sensitive fields masked, bodies which are not valid JSON are treated as free text
+func (r *Redactor) Json(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if decodeError := decoder.Decode(&data); decodeError != nil {
+		return r.Message(strings.Join(strings.Fields(string(body)), " "))
+	}
+
+	redacted, encodeError := json.Marshal(r.value(data))
+	if encodeError != nil {
+		return Placeholder
+	}
+	return string(redacted)
+}
+
+func (r *Redactor) value(data interface{}) interface{} {
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			if r.IsSensitive(key) {
+				typed[key] = Placeholder
+			} else {
+				typed[key] = r.value(value)
+			}
+		}
+		return typed
+	case []interface{}:
+		for index, value := range typed {
+			typed[index] = r.value(value)
+		}
+		return typed
+	case string:
+		return r.Message(typed)
+	default:
+		return typed
+	}
+}
+
+// Request headers with values of sensitive ones, e.g. Authorization and Cookie, masked
+func (r *Redactor) Headers(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for name, values := range header {
+		if r.IsSensitive(name) {
+			headers[name] = Placeholder
+		} else {
+			headers[name] = r.Message(strings.Join(values, ", "))
+		}
+	}
+	return headers
+}
@@ -0,0 +1,1518 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/shopspring/decimal"
+	"golang.org/x/exp/slices"
+)
+
+// Error code of postgres for unique constraint violation, returned by the in-memory store to behave like the database
+const uniqueViolationCode = "23505"
+
+// In-memory store holding records in insertion order, queries mirror the postgres ones in the database package.
+// Records the repositories cannot create themselves, e.g. asset providers and stocks, are added with the Add methods.
+type MemoryStore struct {
+	mu               sync.RWMutex
+	assetProviders   []database.AssetProvider
+	accountTypes     []database.AssetProviderAccountType
+	accounts         []database.Account
+	cash             []database.Cash
+	creditAccounts   []database.CreditAccount
+	creditStatements []database.CreditStatement
+	loanAccounts     []database.LoanAccount
+	loanRepayments   []database.LoanRepayment
+	reconciliations  []database.Reconciliation
+	stocks           []database.Stock
+	stockHoldings    []database.AccountStock
+	exchangeRates    []database.ExchangeRate
+	transactions     []database.Transaction
+	futurePayments   []database.FuturePayment
+	goals            []database.Goal
+	goalAccounts     []database.GoalAccount
+	clients          []database.Client
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) AddAssetProvider(provider database.AssetProvider) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(provider.Id) == 0 {
+		provider.Id = newMemoryId()
+	}
+	s.assetProviders = append(s.assetProviders, provider)
+
+	return provider.Id
+}
+
+func (s *MemoryStore) AddStock(stock database.Stock) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(stock.Id) == 0 {
+		stock.Id = newMemoryId()
+	}
+	s.stocks = append(s.stocks, stock)
+
+	return stock.Id
+}
+
+func (s *MemoryStore) AddClient(client database.Client) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(client.Id) == 0 {
+		client.Id = newMemoryId()
+	}
+	s.clients = append(s.clients, client)
+
+	return client.Id
+}
+
+func (s *MemoryStore) findAccount(accountId string) (int, bool) {
+	index := slices.IndexFunc(s.accounts, func(account database.Account) bool { return account.Id == accountId })
+	return index, index >= 0
+}
+
+func (s *MemoryStore) inWorkspace(workspaceId string, accountIds ...string) bool {
+	return ownsAll(accountIds, func(id string) bool {
+		index, found := s.findAccount(id)
+		return found && s.accounts[index].WorkspaceId == workspaceId
+	})
+}
+
+func (s *MemoryStore) createTransaction(params database.CreateNewTransactionParams) string {
+	id := newMemoryId()
+	s.transactions = append(s.transactions, database.Transaction{
+		Id:          id,
+		Name:        params.Name,
+		Income:      params.Income,
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		AccountId:   sql.NullString{String: params.AccountId, Valid: len(params.AccountId) > 0},
+		CurrencyId:  params.CurrencyId,
+		Category:    params.Category,
+		Amount:      params.Amount,
+		Remarks:     nullString(params.Remarks),
+		ExecutedAt:  params.ExecutedAt,
+	})
+
+	return id
+}
+
+// New balance of an account after applying an amount on it, truncated to cents like the postgres query
+func (s *MemoryStore) balanceAfter(accountId string, amount string, income bool) (string, error) {
+	index, found := s.findAccount(accountId)
+	if !found {
+		return "", pgx.ErrNoRows
+	}
+	balance, parseBalanceError := decimal.NewFromString(s.accounts[index].Balance)
+	if parseBalanceError != nil {
+		return "", parseBalanceError
+	}
+	change, parseAmountError := decimal.NewFromString(amount)
+	if parseAmountError != nil {
+		return "", parseAmountError
+	}
+	if !income {
+		change = change.Neg()
+	}
+
+	return balance.Add(change).Truncate(2).String(), nil
+}
+
+func (s *MemoryStore) setBalance(accountId string, balance string) {
+	if index, found := s.findAccount(accountId); found {
+		s.accounts[index].Balance = balance
+		s.accounts[index].UpdatedAt = time.Now()
+	}
+}
+
+func (s *MemoryStore) accountSummary(account database.Account) (database.AccountSummary, bool) {
+	accountTypeIndex := slices.IndexFunc(s.accountTypes, func(accountType database.AssetProviderAccountType) bool {
+		return accountType.Id == account.AssetProviderAccountTypeId
+	})
+	if accountTypeIndex < 0 {
+		return database.AccountSummary{}, false
+	}
+	accountType := s.accountTypes[accountTypeIndex]
+
+	return database.AccountSummary{
+		Id:              account.Id,
+		Name:            accountType.Name,
+		Balance:         account.Balance,
+		CurrencyId:      account.CurrencyId,
+		AccountTypeId:   accountType.Id,
+		AssetProviderId: accountType.AssetProviderId,
+	}, true
+}
+
+func (s *MemoryStore) providerType(assetProviderId string) string {
+	index := slices.IndexFunc(s.assetProviders, func(provider database.AssetProvider) bool { return provider.Id == assetProviderId })
+	if index < 0 {
+		return ""
+	}
+	return s.assetProviders[index].Type
+}
+
+// Random uuid v4, so ids look the same as the ones generated by postgres
+func newMemoryId() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	bytes[6] = (bytes[6] & 0x0f) | 0x40
+	bytes[8] = (bytes[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", bytes[0:4], bytes[4:6], bytes[6:8], bytes[8:10], bytes[10:])
+}
+
+// Check if every distinct id satisfies the ownership predicate
+func ownsAll(ids []string, isOwned func(id string) bool) bool {
+	for _, id := range ids {
+		if !isOwned(id) {
+			return false
+		}
+	}
+	return true
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+func nullInt64(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+type MemoryAccountRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryAccountRepository) GetAllAccountSummaryByType(ctx context.Context, providerType string, workspaceId string) ([]database.AccountSummary, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	accountSummary := []database.AccountSummary{}
+	for _, account := range r.Store.accounts {
+		summary, found := r.Store.accountSummary(account)
+		if found && account.WorkspaceId == workspaceId && r.Store.providerType(summary.AssetProviderId) == providerType {
+			accountSummary = append(accountSummary, summary)
+		}
+	}
+
+	return accountSummary, nil
+}
+
+func (r *MemoryAccountRepository) GetAllAccountSummaryByWorkspaceId(ctx context.Context, workspaceId string) ([]database.AccountSummary, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	accountSummary := []database.AccountSummary{}
+	for _, account := range r.Store.accounts {
+		summary, found := r.Store.accountSummary(account)
+		if found && account.WorkspaceId == workspaceId {
+			accountSummary = append(accountSummary, summary)
+		}
+	}
+
+	return accountSummary, nil
+}
+
+func (r *MemoryAccountRepository) GetAccountBalance(ctx context.Context, accountId string) (string, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	index, found := r.Store.findAccount(accountId)
+	if !found {
+		return "", pgx.ErrNoRows
+	}
+
+	return r.Store.accounts[index].Balance, nil
+}
+
+func (r *MemoryAccountRepository) GetAccountSummary(ctx context.Context, accountId string) (database.AccountSummary, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	index, found := r.Store.findAccount(accountId)
+	if !found {
+		return database.AccountSummary{}, pgx.ErrNoRows
+	}
+	summary, found := r.Store.accountSummary(r.Store.accounts[index])
+	if !found {
+		return database.AccountSummary{}, pgx.ErrNoRows
+	}
+
+	return summary, nil
+}
+
+func (r *MemoryAccountRepository) CheckExistingAccount(ctx context.Context, params database.CheckExistingAccountParams) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	for _, account := range r.Store.accounts {
+		summary, found := r.Store.accountSummary(account)
+		if found && summary.Name == params.Name && account.WorkspaceId == params.WorkspaceId && summary.AssetProviderId == params.AssetProviderId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *MemoryAccountRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, accountIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(accountIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.accounts, func(account database.Account) bool {
+			return account.Id == id && account.WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryAccountRepository) CheckAccountTypeWorkspaceOwnership(ctx context.Context, workspaceId string, accountTypeIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(accountTypeIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.accounts, func(account database.Account) bool {
+			return account.AssetProviderAccountTypeId == id && account.WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryAccountRepository) CreateNewAccount(ctx context.Context, params database.CreateNewAccountParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	now := time.Now()
+	accountType := database.AssetProviderAccountType{
+		Id:              newMemoryId(),
+		AssetProviderId: params.AssetProviderId,
+		Name:            params.Name,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	r.Store.accountTypes = append(r.Store.accountTypes, accountType)
+	r.Store.accounts = append(r.Store.accounts, database.Account{
+		Id:                         newMemoryId(),
+		ClientId:                   params.ClientId,
+		WorkspaceId:                params.WorkspaceId,
+		AssetProviderAccountTypeId: accountType.Id,
+		CurrencyId:                 params.CurrencyId,
+		Balance:                    "0",
+		CreatedAt:                  now,
+		UpdatedAt:                  now,
+	})
+
+	return true, nil
+}
+
+func (r *MemoryAccountRepository) UpdateAccount(ctx context.Context, params database.UpdateAccountParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, account := range r.Store.accounts {
+		if account.AssetProviderAccountTypeId == params.AccountTypeId && account.WorkspaceId == params.WorkspaceId {
+			r.Store.accounts[index].Balance = params.Balance
+			r.Store.accounts[index].CurrencyId = params.CurrencyId
+			r.Store.accounts[index].UpdatedAt = time.Now()
+		}
+	}
+
+	return true, nil
+}
+
+func (r *MemoryAccountRepository) UpdateAccountBalance(ctx context.Context, balance string, accountId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.setBalance(accountId, balance)
+
+	return true, nil
+}
+
+func (r *MemoryAccountRepository) DeleteAccount(ctx context.Context, accountId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	index, found := r.Store.findAccount(accountId)
+	if !found {
+		return false, pgx.ErrNoRows
+	}
+	accountTypeId := r.Store.accounts[index].AssetProviderAccountTypeId
+
+	r.Store.stockHoldings = slices.DeleteFunc(r.Store.stockHoldings, func(holding database.AccountStock) bool { return holding.AccountId == accountId })
+	r.Store.accounts = slices.Delete(r.Store.accounts, index, index+1)
+	r.Store.accountTypes = slices.DeleteFunc(r.Store.accountTypes, func(accountType database.AssetProviderAccountType) bool {
+		return accountType.Id == accountTypeId
+	})
+
+	return true, nil
+}
+
+type MemoryCashRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryCashRepository) GetAllCash(ctx context.Context, workspaceId string) ([]database.Cash, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	cashRecords := []database.Cash{}
+	for _, cashRecord := range r.Store.cash {
+		if cashRecord.WorkspaceId == workspaceId {
+			cashRecords = append(cashRecords, cashRecord)
+		}
+	}
+
+	return cashRecords, nil
+}
+
+func (r *MemoryCashRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, cashIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(cashIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.cash, func(cashRecord database.Cash) bool {
+			return cashRecord.Id == id && cashRecord.WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryCashRepository) CreateNewCashRecord(ctx context.Context, params database.CreateNewCashRecordParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	now := time.Now()
+	r.Store.cash = append(r.Store.cash, database.Cash{
+		Id:          newMemoryId(),
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		CurrencyId:  params.CurrencyId,
+		Amount:      params.Amount,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+
+	return true, nil
+}
+
+func (r *MemoryCashRepository) UpdateCashRecord(ctx context.Context, params database.UpdateCashRecordParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, cashRecord := range r.Store.cash {
+		if cashRecord.Id == params.Id && cashRecord.WorkspaceId == params.WorkspaceId {
+			r.Store.cash[index].Amount = params.Amount
+			r.Store.cash[index].CurrencyId = params.CurrencyId
+			r.Store.cash[index].UpdatedAt = time.Now()
+		}
+	}
+
+	return true, nil
+}
+
+func (r *MemoryCashRepository) DeleteCashRecord(ctx context.Context, cashId string, workspaceId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.cash = slices.DeleteFunc(r.Store.cash, func(cashRecord database.Cash) bool {
+		return cashRecord.Id == cashId && cashRecord.WorkspaceId == workspaceId
+	})
+
+	return true, nil
+}
+
+type MemoryClientRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryClientRepository) CreateNewClient(ctx context.Context, params database.CreateNewClientParams) (string, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	if slices.ContainsFunc(r.Store.clients, func(client database.Client) bool { return client.Email == params.Email }) {
+		return "", &pgconn.PgError{Code: uniqueViolationCode, Message: "duplicate key value violates unique constraint \"client_email_key\""}
+	}
+
+	now := time.Now()
+	client := database.Client{
+		Id:         newMemoryId(),
+		Email:      params.Email,
+		Username:   params.Username,
+		Password:   params.Password,
+		CurrencyId: params.CurrencyId,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	r.Store.clients = append(r.Store.clients, client)
+
+	return client.Id, nil
+}
+
+func (r *MemoryClientRepository) GetClientByEmail(ctx context.Context, email string) (database.Client, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	index := slices.IndexFunc(r.Store.clients, func(client database.Client) bool { return client.Email == email })
+	if index < 0 {
+		return database.Client{}, pgx.ErrNoRows
+	}
+
+	return r.Store.clients[index], nil
+}
+
+func (r *MemoryClientRepository) GetClientById(ctx context.Context, id string) (database.Client, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	index := slices.IndexFunc(r.Store.clients, func(client database.Client) bool { return client.Id == id })
+	if index < 0 {
+		return database.Client{}, pgx.ErrNoRows
+	}
+
+	return r.Store.clients[index], nil
+}
+
+func (r *MemoryClientRepository) UpdateClientSettings(ctx context.Context, params database.UpdateClientSettingsParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, client := range r.Store.clients {
+		if client.Id == params.ClientId {
+			r.Store.clients[index].Username = params.Username
+			r.Store.clients[index].CurrencyId = params.CurrencyId
+			r.Store.clients[index].UpdatedAt = time.Now()
+		}
+	}
+
+	return true, nil
+}
+
+type MemoryCreditAccountRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryCreditAccountRepository) GetAllCreditAccounts(ctx context.Context) ([]database.CreditAccountDetails, error) {
+	return r.creditAccountDetails(func(account database.Account) bool { return true }), nil
+}
+
+func (r *MemoryCreditAccountRepository) GetAllCreditAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.CreditAccountDetails, error) {
+	return r.creditAccountDetails(func(account database.Account) bool { return account.WorkspaceId == workspaceId }), nil
+}
+
+func (r *MemoryCreditAccountRepository) UpsertCreditAccount(ctx context.Context, params database.UpsertCreditAccountParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	now := time.Now()
+	creditAccount := database.CreditAccount{
+		AccountId:          params.AccountId,
+		CreditLimit:        params.CreditLimit,
+		StatementDay:       params.StatementDay,
+		DueDay:             params.DueDay,
+		Apr:                params.Apr,
+		RepaymentAccountId: nullString(params.RepaymentAccountId),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	index := slices.IndexFunc(r.Store.creditAccounts, func(existing database.CreditAccount) bool { return existing.AccountId == params.AccountId })
+	if index < 0 {
+		r.Store.creditAccounts = append(r.Store.creditAccounts, creditAccount)
+	} else {
+		creditAccount.CreatedAt = r.Store.creditAccounts[index].CreatedAt
+		r.Store.creditAccounts[index] = creditAccount
+	}
+
+	return true, nil
+}
+
+func (r *MemoryCreditAccountRepository) GetAllCreditStatements(ctx context.Context, accountId string) ([]database.CreditStatement, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	statements := []database.CreditStatement{}
+	for _, statement := range r.Store.creditStatements {
+		if statement.AccountId == accountId {
+			statements = append(statements, statement)
+		}
+	}
+	sort.SliceStable(statements, func(i, j int) bool { return statements[i].PeriodEnd.After(statements[j].PeriodEnd) })
+
+	return statements, nil
+}
+
+func (r *MemoryCreditAccountRepository) CheckExistingCreditStatement(ctx context.Context, accountId string, periodEnd time.Time) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return slices.ContainsFunc(r.Store.creditStatements, func(statement database.CreditStatement) bool {
+		return statement.AccountId == accountId && statement.PeriodEnd.Equal(periodEnd)
+	}), nil
+}
+
+// Credit accounts joined with their account and account type, the same as the postgres query
+func (r *MemoryCreditAccountRepository) creditAccountDetails(filter func(account database.Account) bool) []database.CreditAccountDetails {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	creditAccounts := []database.CreditAccountDetails{}
+	for _, creditAccount := range r.Store.creditAccounts {
+		index, found := r.Store.findAccount(creditAccount.AccountId)
+		if !found || !filter(r.Store.accounts[index]) {
+			continue
+		}
+		account := r.Store.accounts[index]
+		summary, found := r.Store.accountSummary(account)
+		if !found {
+			continue
+		}
+		creditAccounts = append(creditAccounts, database.CreditAccountDetails{
+			Id:                 account.Id,
+			Name:               summary.Name,
+			Balance:            account.Balance,
+			ClientId:           account.ClientId,
+			WorkspaceId:        account.WorkspaceId,
+			CurrencyId:         account.CurrencyId,
+			CreditLimit:        creditAccount.CreditLimit,
+			StatementDay:       creditAccount.StatementDay,
+			DueDay:             creditAccount.DueDay,
+			Apr:                creditAccount.Apr,
+			RepaymentAccountId: creditAccount.RepaymentAccountId,
+		})
+	}
+
+	return creditAccounts
+}
+
+type MemoryExchangeRateRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryExchangeRateRepository) GetAllExchangeRates(ctx context.Context) ([]database.ExchangeRate, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return slices.Clone(r.Store.exchangeRates), nil
+}
+
+func (r *MemoryExchangeRateRepository) UpdateExchangeRate(ctx context.Context, params database.UpdateExchangeRateParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	index := slices.IndexFunc(r.Store.exchangeRates, func(exchangeRate database.ExchangeRate) bool {
+		return exchangeRate.BaseCurrencyId == params.BaseCurrencyId && exchangeRate.TargetCurrencyId == params.TargetCurrencyId
+	})
+	if index >= 0 {
+		r.Store.exchangeRates[index].Rate = params.Rate
+	} else {
+		r.Store.exchangeRates = append(r.Store.exchangeRates, database.ExchangeRate{
+			Id:               newMemoryId(),
+			BaseCurrencyId:   params.BaseCurrencyId,
+			TargetCurrencyId: params.TargetCurrencyId,
+			Rate:             params.Rate,
+		})
+	}
+
+	return true, nil
+}
+
+type MemoryFuturePaymentRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryFuturePaymentRepository) GetAllFuturePayments(ctx context.Context) ([]database.FuturePayment, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return slices.Clone(r.Store.futurePayments), nil
+}
+
+func (r *MemoryFuturePaymentRepository) GetAllFuturePaymentsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.FuturePayment, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	futurePayments := []database.FuturePayment{}
+	for _, futurePayment := range r.Store.futurePayments {
+		if futurePayment.WorkspaceId == workspaceId {
+			futurePayments = append(futurePayments, futurePayment)
+		}
+	}
+
+	return futurePayments, nil
+}
+
+func (r *MemoryFuturePaymentRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, futurePaymentIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(futurePaymentIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.futurePayments, func(futurePayment database.FuturePayment) bool {
+			return futurePayment.Id == id && futurePayment.WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryFuturePaymentRepository) CreateNewFuturePayment(ctx context.Context, params database.CreateNewFuturePaymentParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.futurePayments = append(r.Store.futurePayments, database.FuturePayment{
+		Id:          newMemoryId(),
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		AccountId:   params.AccountId,
+		CurrencyId:  params.CurrencyId,
+		Name:        params.Name,
+		Amount:      params.Amount,
+		Income:      params.Income,
+		Rolling:     params.Rolling,
+		Category:    params.Category,
+		Frequency:   nullInt64(params.Frequency),
+		Remarks:     nullString(params.Remarks),
+		ScheduledAt: params.ScheduledAt,
+	})
+
+	return true, nil
+}
+
+func (r *MemoryFuturePaymentRepository) UpdateFuturePayment(ctx context.Context, params database.UpdateFuturePaymentParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, futurePayment := range r.Store.futurePayments {
+		if futurePayment.Id == params.Id && futurePayment.WorkspaceId == params.WorkspaceId {
+			futurePayment.Name = params.Name
+			futurePayment.Income = params.Income
+			futurePayment.Amount = params.Amount
+			futurePayment.Remarks = nullString(params.Remarks)
+			futurePayment.Rolling = params.Rolling
+			futurePayment.Category = params.Category
+			futurePayment.Frequency = nullInt64(params.Frequency)
+			futurePayment.AccountId = params.AccountId
+			futurePayment.CurrencyId = params.CurrencyId
+			futurePayment.ScheduledAt = params.ScheduledAt
+			r.Store.futurePayments[index] = futurePayment
+		}
+	}
+
+	return true, nil
+}
+
+func (r *MemoryFuturePaymentRepository) UpdateFuturePaymentSchedule(ctx context.Context, scheduledAt time.Time, id string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, futurePayment := range r.Store.futurePayments {
+		if futurePayment.Id == id {
+			r.Store.futurePayments[index].ScheduledAt = scheduledAt
+		}
+	}
+
+	return true, nil
+}
+
+func (r *MemoryFuturePaymentRepository) DeleteFuturePayment(ctx context.Context, futurePaymentId string, workspaceId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.futurePayments = slices.DeleteFunc(r.Store.futurePayments, func(futurePayment database.FuturePayment) bool {
+		return futurePayment.Id == futurePaymentId && futurePayment.WorkspaceId == workspaceId
+	})
+
+	return true, nil
+}
+
+type MemoryGoalRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryGoalRepository) GetAllGoalsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.Goal, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	goals := []database.Goal{}
+	for _, goal := range r.Store.goals {
+		if goal.WorkspaceId == workspaceId {
+			goals = append(goals, goal)
+		}
+	}
+	sort.SliceStable(goals, func(i, j int) bool { return goals[i].Deadline.Before(goals[j].Deadline) })
+
+	return goals, nil
+}
+
+func (r *MemoryGoalRepository) GetAllGoalAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.GoalAccount, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	goalAccounts := []database.GoalAccount{}
+	for _, goalAccount := range r.Store.goalAccounts {
+		if slices.ContainsFunc(r.Store.goals, func(goal database.Goal) bool {
+			return goal.Id == goalAccount.GoalId && goal.WorkspaceId == workspaceId
+		}) {
+			goalAccounts = append(goalAccounts, goalAccount)
+		}
+	}
+
+	return goalAccounts, nil
+}
+
+func (r *MemoryGoalRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, goalIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(goalIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.goals, func(goal database.Goal) bool {
+			return goal.Id == id && goal.WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryGoalRepository) CreateNewGoal(ctx context.Context, params database.CreateNewGoalParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	futurePaymentId, createFundingError := r.Store.createGoalFunding(params.ClientId, params.WorkspaceId, params.Funding)
+	if createFundingError != nil {
+		return false, createFundingError
+	}
+
+	now := time.Now()
+	goal := database.Goal{
+		Id:              newMemoryId(),
+		ClientId:        params.ClientId,
+		WorkspaceId:     params.WorkspaceId,
+		CurrencyId:      params.CurrencyId,
+		FuturePaymentId: futurePaymentId,
+		Name:            params.Name,
+		TargetAmount:    params.TargetAmount,
+		Deadline:        params.Deadline,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	r.Store.goals = append(r.Store.goals, goal)
+	r.Store.linkGoalAccounts(goal.Id, params.AccountIds)
+
+	return true, nil
+}
+
+func (r *MemoryGoalRepository) UpdateGoal(ctx context.Context, params database.UpdateGoalParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	index := slices.IndexFunc(r.Store.goals, func(goal database.Goal) bool {
+		return goal.Id == params.Id && goal.WorkspaceId == params.WorkspaceId
+	})
+	if index < 0 {
+		return false, pgx.ErrNoRows
+	}
+
+	futurePaymentId, createFundingError := r.Store.createGoalFunding(params.ClientId, params.WorkspaceId, params.Funding)
+	if createFundingError != nil {
+		return false, createFundingError
+	}
+
+	goal := r.Store.goals[index]
+	r.Store.deleteGoalFunding(goal.FuturePaymentId)
+	goal.CurrencyId = params.CurrencyId
+	goal.FuturePaymentId = futurePaymentId
+	goal.Name = params.Name
+	goal.TargetAmount = params.TargetAmount
+	goal.Deadline = params.Deadline
+	goal.UpdatedAt = time.Now()
+	r.Store.goals[index] = goal
+	r.Store.unlinkGoalAccounts(goal.Id)
+	r.Store.linkGoalAccounts(goal.Id, params.AccountIds)
+
+	return true, nil
+}
+
+func (r *MemoryGoalRepository) DeleteGoal(ctx context.Context, goalId string, workspaceId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	index := slices.IndexFunc(r.Store.goals, func(goal database.Goal) bool {
+		return goal.Id == goalId && goal.WorkspaceId == workspaceId
+	})
+	if index < 0 {
+		return false, pgx.ErrNoRows
+	}
+
+	goal := r.Store.goals[index]
+	r.Store.unlinkGoalAccounts(goal.Id)
+	r.Store.goals = slices.Delete(r.Store.goals, index, index+1)
+	r.Store.deleteGoalFunding(goal.FuturePaymentId)
+
+	return true, nil
+}
+
+func (s *MemoryStore) createGoalFunding(clientId string, workspaceId string, funding *database.GoalFundingParams) (sql.NullString, error) {
+	if funding == nil {
+		return sql.NullString{}, nil
+	}
+	if !s.inWorkspace(workspaceId, funding.AccountId, funding.SourceAccountId) {
+		return sql.NullString{}, database.ErrAccountNotInWorkspace
+	}
+
+	futurePayment := database.FuturePayment{
+		Id:              newMemoryId(),
+		ClientId:        clientId,
+		WorkspaceId:     workspaceId,
+		AccountId:       funding.AccountId,
+		CurrencyId:      funding.CurrencyId,
+		Name:            funding.Name,
+		Amount:          funding.Amount,
+		Income:          true,
+		Rolling:         true,
+		Category:        "savings-goal",
+		Frequency:       sql.NullInt64{Int64: funding.Frequency, Valid: true},
+		ScheduledAt:     funding.ScheduledAt,
+		SourceAccountId: sql.NullString{String: funding.SourceAccountId, Valid: true},
+		EndsAt:          sql.NullTime{Time: funding.EndsAt, Valid: true},
+	}
+	s.futurePayments = append(s.futurePayments, futurePayment)
+
+	return sql.NullString{String: futurePayment.Id, Valid: true}, nil
+}
+
+// Mirror of the goal check run by database.ExecuteFuturePayment, false if the payment does not fund any goal
+func (s *MemoryStore) isFundedGoalReached(futurePaymentId string) (bool, error) {
+	index := slices.IndexFunc(s.goals, func(goal database.Goal) bool {
+		return goal.FuturePaymentId.Valid && goal.FuturePaymentId.String == futurePaymentId
+	})
+	if index < 0 {
+		return false, nil
+	}
+	goal := s.goals[index]
+	targetAmount, parseTargetAmountError := decimal.NewFromString(goal.TargetAmount)
+	if parseTargetAmountError != nil {
+		return false, parseTargetAmountError
+	}
+
+	currentAmount := decimal.Zero
+	for _, goalAccount := range s.goalAccounts {
+		accountIndex, found := s.findAccount(goalAccount.AccountId)
+		if goalAccount.GoalId != goal.Id || !found {
+			continue
+		}
+		account := s.accounts[accountIndex]
+		balance, parseBalanceError := decimal.NewFromString(account.Balance)
+		if parseBalanceError != nil {
+			return false, parseBalanceError
+		}
+		rate := decimal.NewFromInt(1)
+		if account.CurrencyId != goal.CurrencyId {
+			rateIndex := slices.IndexFunc(s.exchangeRates, func(exchangeRate database.ExchangeRate) bool {
+				return exchangeRate.BaseCurrencyId == account.CurrencyId && exchangeRate.TargetCurrencyId == goal.CurrencyId
+			})
+			if rateIndex < 0 {
+				return false, fmt.Errorf("missing exchange rate from %s to %s", account.CurrencyId, goal.CurrencyId)
+			}
+			var parseRateError error
+			if rate, parseRateError = decimal.NewFromString(s.exchangeRates[rateIndex].Rate); parseRateError != nil {
+				return false, parseRateError
+			}
+		}
+		currentAmount = currentAmount.Add(balance.Mul(rate))
+	}
+
+	return currentAmount.GreaterThanOrEqual(targetAmount), nil
+}
+
+func (s *MemoryStore) deleteGoalFunding(futurePaymentId sql.NullString) {
+	if !futurePaymentId.Valid {
+		return
+	}
+	s.futurePayments = slices.DeleteFunc(s.futurePayments, func(futurePayment database.FuturePayment) bool {
+		return futurePayment.Id == futurePaymentId.String
+	})
+}
+
+func (s *MemoryStore) linkGoalAccounts(goalId string, accountIds []string) {
+	for _, accountId := range accountIds {
+		s.goalAccounts = append(s.goalAccounts, database.GoalAccount{GoalId: goalId, AccountId: accountId})
+	}
+}
+
+func (s *MemoryStore) unlinkGoalAccounts(goalId string) {
+	s.goalAccounts = slices.DeleteFunc(s.goalAccounts, func(goalAccount database.GoalAccount) bool {
+		return goalAccount.GoalId == goalId
+	})
+}
+
+// Ledger changes hold the store lock throughout and compute every new balance before writing, so they apply atomically
+type MemoryLedgerRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryLedgerRepository) RecordTransaction(ctx context.Context, params database.CreateNewTransactionParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	balance, applyError := r.Store.balanceAfter(params.AccountId, params.Amount, params.Income)
+	if applyError != nil {
+		return false, applyError
+	}
+	r.Store.createTransaction(params)
+	r.Store.setBalance(params.AccountId, balance)
+
+	return true, nil
+}
+
+func (r *MemoryLedgerRepository) RecordTransfer(ctx context.Context, source database.CreateNewTransactionParams, target database.CreateNewTransactionParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	sourceBalance, applySourceError := r.Store.balanceAfter(source.AccountId, source.Amount, source.Income)
+	if applySourceError != nil {
+		return false, applySourceError
+	}
+	targetBalance, applyTargetError := r.Store.balanceAfter(target.AccountId, target.Amount, target.Income)
+	if applyTargetError != nil {
+		return false, applyTargetError
+	}
+	r.Store.createTransaction(source)
+	r.Store.createTransaction(target)
+	r.Store.setBalance(source.AccountId, sourceBalance)
+	r.Store.setBalance(target.AccountId, targetBalance)
+
+	return true, nil
+}
+
+func (r *MemoryLedgerRepository) DeleteTransaction(ctx context.Context, transactionId string, workspaceId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	index := slices.IndexFunc(r.Store.transactions, func(transaction database.Transaction) bool {
+		return transaction.Id == transactionId && transaction.WorkspaceId == workspaceId
+	})
+	if index < 0 {
+		return false, nil
+	}
+	transaction := r.Store.transactions[index]
+
+	// Reverting an income is the same as spending the amount, and vice versa
+	balance, applyError := r.Store.balanceAfter(transaction.AccountId.String, transaction.Amount, !transaction.Income)
+	if applyError != nil {
+		return false, applyError
+	}
+	r.Store.transactions = slices.Delete(r.Store.transactions, index, index+1)
+	r.Store.setBalance(transaction.AccountId.String, balance)
+
+	return true, nil
+}
+
+func (r *MemoryLedgerRepository) ExecuteFuturePayment(ctx context.Context, payment database.FuturePayment, nextScheduledAt *time.Time) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	index := slices.IndexFunc(r.Store.futurePayments, func(futurePayment database.FuturePayment) bool {
+		return futurePayment.Id == payment.Id && futurePayment.ScheduledAt.Equal(payment.ScheduledAt)
+	})
+	if index < 0 {
+		return false, nil
+	}
+	if payment.SourceAccountId.Valid {
+		isGoalReached, checkGoalError := r.Store.isFundedGoalReached(payment.Id)
+		if checkGoalError != nil {
+			return false, checkGoalError
+		}
+		if isGoalReached {
+			r.Store.futurePayments = slices.Delete(r.Store.futurePayments, index, index+1)
+			return false, nil
+		}
+	}
+
+	balance, applyError := r.Store.balanceAfter(payment.AccountId, payment.Amount, payment.Income)
+	if applyError != nil {
+		return false, applyError
+	}
+	var sourceBalance string
+	if payment.SourceAccountId.Valid {
+		var applySourceError error
+		sourceBalance, applySourceError = r.Store.balanceAfter(payment.SourceAccountId.String, payment.Amount, !payment.Income)
+		if applySourceError != nil {
+			return false, applySourceError
+		}
+	}
+	if nextScheduledAt != nil {
+		r.Store.futurePayments[index].ScheduledAt = *nextScheduledAt
+	} else {
+		r.Store.futurePayments = slices.Delete(r.Store.futurePayments, index, index+1)
+	}
+	r.Store.setBalance(payment.AccountId, balance)
+	if payment.SourceAccountId.Valid {
+		r.Store.setBalance(payment.SourceAccountId.String, sourceBalance)
+	}
+
+	return true, nil
+}
+
+func (r *MemoryLedgerRepository) UpsertLoanAccount(ctx context.Context, params database.UpsertLoanAccountParams, drawdown *database.CreateNewTransactionParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	now := time.Now()
+	loanAccount := database.LoanAccount{
+		AccountId:    params.AccountId,
+		Principal:    params.Principal,
+		AnnualRate:   params.AnnualRate,
+		TermInMonths: params.TermInMonths,
+		StartDate:    params.StartDate,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	index := slices.IndexFunc(r.Store.loanAccounts, func(existing database.LoanAccount) bool { return existing.AccountId == params.AccountId })
+	if index >= 0 {
+		loanAccount.CreatedAt = r.Store.loanAccounts[index].CreatedAt
+		r.Store.loanAccounts[index] = loanAccount
+		return true, nil
+	}
+
+	// Drawdown is only booked when the loan details are inserted
+	if drawdown != nil {
+		balance, applyError := r.Store.balanceAfter(drawdown.AccountId, drawdown.Amount, drawdown.Income)
+		if applyError != nil {
+			return false, applyError
+		}
+		r.Store.createTransaction(*drawdown)
+		r.Store.setBalance(drawdown.AccountId, balance)
+	}
+	r.Store.loanAccounts = append(r.Store.loanAccounts, loanAccount)
+
+	return true, nil
+}
+
+func (r *MemoryLedgerRepository) RecordLoanRepayment(ctx context.Context, params database.CreateNewLoanRepaymentParams) (string, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	balance, applyError := r.Store.balanceAfter(params.AccountId, params.Principal, true)
+	if applyError != nil {
+		return "", applyError
+	}
+	if params.HasInterest {
+		r.Store.createTransaction(database.CreateNewTransactionParams{
+			Name:        params.Name + " interest",
+			Income:      false,
+			Amount:      params.Interest,
+			Category:    "loan-interest",
+			ClientId:    params.ClientId,
+			WorkspaceId: params.WorkspaceId,
+			AccountId:   params.AccountId,
+			CurrencyId:  params.CurrencyId,
+			ExecutedAt:  params.ExecutedAt,
+		})
+	}
+	r.Store.createTransaction(database.CreateNewTransactionParams{
+		Name:        params.Name,
+		Income:      true,
+		Amount:      params.Amount,
+		Category:    "loan-repayment",
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		AccountId:   params.AccountId,
+		CurrencyId:  params.CurrencyId,
+		ExecutedAt:  params.ExecutedAt,
+	})
+	r.Store.setBalance(params.AccountId, balance)
+	r.Store.loanRepayments = append(r.Store.loanRepayments, database.LoanRepayment{
+		Id:         newMemoryId(),
+		AccountId:  params.AccountId,
+		Amount:     params.Amount,
+		Interest:   params.Interest,
+		Principal:  params.Principal,
+		Extra:      params.Extra,
+		ExecutedAt: params.ExecutedAt,
+	})
+
+	return balance, nil
+}
+
+func (r *MemoryLedgerRepository) RecordReconciliation(ctx context.Context, params database.CreateNewReconciliationParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	var adjustmentTransactionId sql.NullString
+	if params.Adjustment != nil {
+		index, found := r.Store.findAccount(params.AccountId)
+		if !found {
+			return false, pgx.ErrNoRows
+		}
+		balance, applyError := r.Store.balanceAfter(params.AccountId, params.Adjustment.Amount, params.Adjustment.Income)
+		if applyError != nil {
+			return false, applyError
+		}
+		id := r.Store.createTransaction(database.CreateNewTransactionParams{
+			Name:        params.Adjustment.Name,
+			Income:      params.Adjustment.Income,
+			Amount:      params.Adjustment.Amount,
+			Category:    "reconciliation-adjustment",
+			ClientId:    params.Adjustment.ClientId,
+			WorkspaceId: r.Store.accounts[index].WorkspaceId,
+			AccountId:   params.AccountId,
+			CurrencyId:  params.Adjustment.CurrencyId,
+			ExecutedAt:  params.StatementDate,
+		})
+		r.Store.setBalance(params.AccountId, balance)
+		adjustmentTransactionId = sql.NullString{String: id, Valid: true}
+	}
+	r.Store.reconciliations = append(r.Store.reconciliations, database.Reconciliation{
+		Id:                      newMemoryId(),
+		AccountId:               params.AccountId,
+		AdjustmentTransactionId: adjustmentTransactionId,
+		StatementBalance:        params.StatementBalance,
+		RecordedBalance:         params.RecordedBalance,
+		Difference:              params.Difference,
+		Status:                  params.Status,
+		StatementDate:           params.StatementDate,
+		CreatedAt:               time.Now(),
+	})
+
+	return true, nil
+}
+
+func (r *MemoryLedgerRepository) RecordCreditStatement(ctx context.Context, params database.CreateNewCreditStatementParams, withRepayment bool) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	var futurePaymentId sql.NullString
+	if withRepayment {
+		if !r.Store.inWorkspace(params.WorkspaceId, params.AccountId, params.RepaymentAccountId) {
+			return false, database.ErrAccountNotInWorkspace
+		}
+		futurePayment := database.FuturePayment{
+			Id:              newMemoryId(),
+			ClientId:        params.ClientId,
+			WorkspaceId:     params.WorkspaceId,
+			AccountId:       params.AccountId,
+			CurrencyId:      params.CurrencyId,
+			Name:            params.Name,
+			Amount:          params.Balance,
+			Income:          true,
+			Rolling:         false,
+			Category:        "credit-repayment",
+			ScheduledAt:     params.DueAt,
+			SourceAccountId: sql.NullString{String: params.RepaymentAccountId, Valid: true},
+		}
+		r.Store.futurePayments = append(r.Store.futurePayments, futurePayment)
+		futurePaymentId = sql.NullString{String: futurePayment.Id, Valid: true}
+	}
+	r.Store.creditStatements = append(r.Store.creditStatements, database.CreditStatement{
+		Id:              newMemoryId(),
+		AccountId:       params.AccountId,
+		FuturePaymentId: futurePaymentId,
+		Balance:         params.Balance,
+		PeriodStart:     params.PeriodStart,
+		PeriodEnd:       params.PeriodEnd,
+		DueAt:           params.DueAt,
+		CreatedAt:       time.Now(),
+	})
+
+	return true, nil
+}
+
+type MemoryLoanAccountRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryLoanAccountRepository) GetAllLoanAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.LoanAccountDetails, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	loanAccounts := []database.LoanAccountDetails{}
+	for _, loanAccount := range r.Store.loanAccounts {
+		index, found := r.Store.findAccount(loanAccount.AccountId)
+		if !found || r.Store.accounts[index].WorkspaceId != workspaceId {
+			continue
+		}
+		account := r.Store.accounts[index]
+		summary, found := r.Store.accountSummary(account)
+		if !found {
+			continue
+		}
+		loanAccounts = append(loanAccounts, database.LoanAccountDetails{
+			Id:           account.Id,
+			Name:         summary.Name,
+			Balance:      account.Balance,
+			ClientId:     account.ClientId,
+			WorkspaceId:  account.WorkspaceId,
+			CurrencyId:   account.CurrencyId,
+			Principal:    loanAccount.Principal,
+			AnnualRate:   loanAccount.AnnualRate,
+			TermInMonths: loanAccount.TermInMonths,
+			StartDate:    loanAccount.StartDate,
+		})
+	}
+
+	return loanAccounts, nil
+}
+
+func (r *MemoryLoanAccountRepository) GetAllLoanRepayments(ctx context.Context, accountId string) ([]database.LoanRepayment, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	repayments := []database.LoanRepayment{}
+	for _, repayment := range r.Store.loanRepayments {
+		if repayment.AccountId == accountId {
+			repayments = append(repayments, repayment)
+		}
+	}
+	sort.SliceStable(repayments, func(i, j int) bool { return repayments[i].ExecutedAt.After(repayments[j].ExecutedAt) })
+
+	return repayments, nil
+}
+
+type MemoryReconciliationRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryReconciliationRepository) GetAllReconciliations(ctx context.Context, accountId string) ([]database.Reconciliation, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	reconciliations := []database.Reconciliation{}
+	for _, reconciliation := range r.Store.reconciliations {
+		if reconciliation.AccountId == accountId {
+			reconciliations = append(reconciliations, reconciliation)
+		}
+	}
+	sort.SliceStable(reconciliations, func(i, j int) bool {
+		if !reconciliations[i].StatementDate.Equal(reconciliations[j].StatementDate) {
+			return reconciliations[i].StatementDate.After(reconciliations[j].StatementDate)
+		}
+		return reconciliations[i].CreatedAt.After(reconciliations[j].CreatedAt)
+	})
+
+	return reconciliations, nil
+}
+
+type MemoryStockRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryStockRepository) GetAllStocks(ctx context.Context) ([]database.Stock, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return slices.Clone(r.Store.stocks), nil
+}
+
+func (r *MemoryStockRepository) GetAllStocksByCountryId(ctx context.Context, id string) ([]database.Stock, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	stocks := []database.Stock{}
+	for _, stock := range r.Store.stocks {
+		if stock.CountryId == id {
+			stocks = append(stocks, stock)
+		}
+	}
+
+	return stocks, nil
+}
+
+func (r *MemoryStockRepository) UpdateStockPrice(ctx context.Context, params database.UpdateStockPriceParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, stock := range r.Store.stocks {
+		if stock.Ticker == params.Ticker && stock.CountryId == params.CountryId {
+			r.Store.stocks[index].CurrentPrice = params.CurrentPrice
+		}
+	}
+
+	return true, nil
+}
+
+func (r *MemoryStockRepository) GetAllStockHoldings(ctx context.Context, workspaceId string) ([]database.AccountStock, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	accountStocks := []database.AccountStock{}
+	for _, holding := range r.Store.stockHoldings {
+		index, found := r.Store.findAccount(holding.AccountId)
+		if found && r.Store.accounts[index].WorkspaceId == workspaceId {
+			accountStocks = append(accountStocks, holding)
+		}
+	}
+
+	return accountStocks, nil
+}
+
+func (r *MemoryStockRepository) CheckStockHoldingWorkspaceOwnership(ctx context.Context, workspaceId string, accountStockIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(accountStockIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.stockHoldings, func(holding database.AccountStock) bool {
+			index, found := r.Store.findAccount(holding.AccountId)
+			return holding.Id == id && found && r.Store.accounts[index].WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryStockRepository) CreateNewStockHolding(ctx context.Context, params database.CreateNewStockHoldingParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	if slices.ContainsFunc(r.Store.stockHoldings, func(holding database.AccountStock) bool {
+		return holding.AccountId == params.AccountId && holding.StockId == params.StockId
+	}) {
+		return false, &pgconn.PgError{Code: uniqueViolationCode, Message: "duplicate key value violates unique constraint \"account_stock_account_id_stock_id_key\""}
+	}
+
+	r.Store.stockHoldings = append(r.Store.stockHoldings, database.AccountStock{
+		Id:        newMemoryId(),
+		AccountId: params.AccountId,
+		StockId:   params.StockId,
+		Unit:      params.Unit,
+		Cost:      params.Cost,
+	})
+
+	return true, nil
+}
+
+func (r *MemoryStockRepository) UpdateStockHoldingCost(ctx context.Context, params database.UpdateStockHoldingCostParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	for index, holding := range r.Store.stockHoldings {
+		if holding.StockId == params.StockId && holding.AccountId == params.AccountId {
+			r.Store.stockHoldings[index].Cost = params.Cost
+			r.Store.stockHoldings[index].Unit = params.Unit
+		}
+	}
+
+	return true, nil
+}
+
+func (r *MemoryStockRepository) DeleteStockHolding(ctx context.Context, accountStockId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.stockHoldings = slices.DeleteFunc(r.Store.stockHoldings, func(holding database.AccountStock) bool { return holding.Id == accountStockId })
+
+	return true, nil
+}
+
+type MemoryTransactionRepository struct {
+	Store *MemoryStore
+}
+
+func (r *MemoryTransactionRepository) GetAllTransactions(ctx context.Context, workspaceId string) ([]database.Transaction, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	transactions := []database.Transaction{}
+	for _, transaction := range r.Store.transactions {
+		if transaction.WorkspaceId == workspaceId {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+func (r *MemoryTransactionRepository) GetAllTransactionsByAccountIdBetween(ctx context.Context, accountId string, start time.Time, end time.Time) ([]database.Transaction, error) {
+	return r.filterByAccountId(accountId, func(executedAt time.Time) bool {
+		return !executedAt.Before(start) && executedAt.Before(end)
+	}), nil
+}
+
+func (r *MemoryTransactionRepository) GetAllTransactionsByAccountIdAfter(ctx context.Context, accountId string, after time.Time) ([]database.Transaction, error) {
+	return r.filterByAccountId(accountId, func(executedAt time.Time) bool { return executedAt.After(after) }), nil
+}
+
+func (r *MemoryTransactionRepository) GetAllTransactionsByAccountIdSince(ctx context.Context, accountId string, since time.Time) ([]database.Transaction, error) {
+	return r.filterByAccountId(accountId, func(executedAt time.Time) bool { return !executedAt.Before(since) }), nil
+}
+
+func (r *MemoryTransactionRepository) GetTransactionAndAccountBalanceById(ctx context.Context, transactionId string) (database.TransactionAndAccountBalance, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	index := slices.IndexFunc(r.Store.transactions, func(transaction database.Transaction) bool { return transaction.Id == transactionId })
+	if index < 0 {
+		return database.TransactionAndAccountBalance{}, pgx.ErrNoRows
+	}
+	transaction := r.Store.transactions[index]
+	accountIndex, found := r.Store.findAccount(transaction.AccountId.String)
+	if !transaction.AccountId.Valid || !found {
+		return database.TransactionAndAccountBalance{}, pgx.ErrNoRows
+	}
+
+	return database.TransactionAndAccountBalance{
+		Income:    transaction.Income,
+		Amount:    transaction.Amount,
+		Balance:   r.Store.accounts[accountIndex].Balance,
+		AccountId: transaction.AccountId.String,
+	}, nil
+}
+
+func (r *MemoryTransactionRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, transactionIds ...string) (bool, error) {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	return ownsAll(transactionIds, func(id string) bool {
+		return slices.ContainsFunc(r.Store.transactions, func(transaction database.Transaction) bool {
+			return transaction.Id == id && transaction.WorkspaceId == workspaceId
+		})
+	}), nil
+}
+
+func (r *MemoryTransactionRepository) CreateNewTransaction(ctx context.Context, params database.CreateNewTransactionParams) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.createTransaction(params)
+
+	return true, nil
+}
+
+func (r *MemoryTransactionRepository) DeleteTransaction(ctx context.Context, transactionId string, workspaceId string) (bool, error) {
+	r.Store.mu.Lock()
+	defer r.Store.mu.Unlock()
+
+	r.Store.transactions = slices.DeleteFunc(r.Store.transactions, func(transaction database.Transaction) bool {
+		return transaction.Id == transactionId && transaction.WorkspaceId == workspaceId
+	})
+
+	return true, nil
+}
+
+// Transactions of an account whose execution time passes the filter, ordered by execution time like the postgres queries
+func (r *MemoryTransactionRepository) filterByAccountId(accountId string, filter func(executedAt time.Time) bool) []database.Transaction {
+	r.Store.mu.RLock()
+	defer r.Store.mu.RUnlock()
+
+	transactions := []database.Transaction{}
+	for _, transaction := range r.Store.transactions {
+		if transaction.AccountId.Valid && transaction.AccountId.String == accountId && filter(transaction.ExecutedAt) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].ExecutedAt.Before(transactions[j].ExecutedAt) })
+
+	return transactions
+}
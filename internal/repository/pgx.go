@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+)
+
+type PgxAccountRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxAccountRepository) GetAllAccountSummaryByType(ctx context.Context, providerType string, workspaceId string) ([]database.AccountSummary, error) {
+	return database.GetAllAccountSummaryByType(ctx, r.Db, providerType, workspaceId)
+}
+
+func (r *PgxAccountRepository) GetAllAccountSummaryByWorkspaceId(ctx context.Context, workspaceId string) ([]database.AccountSummary, error) {
+	return database.GetAllAccountSummaryByWorkspaceId(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxAccountRepository) GetAccountBalance(ctx context.Context, accountId string) (string, error) {
+	return database.GetAccountBalance(ctx, r.Db, accountId)
+}
+
+func (r *PgxAccountRepository) GetAccountSummary(ctx context.Context, accountId string) (database.AccountSummary, error) {
+	return database.GetAccountSummary(ctx, r.Db, accountId)
+}
+
+func (r *PgxAccountRepository) CheckExistingAccount(ctx context.Context, params database.CheckExistingAccountParams) (bool, error) {
+	return database.CheckExistingAccount(ctx, r.Db, params)
+}
+
+func (r *PgxAccountRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, accountIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedAccount, workspaceId, accountIds...)
+}
+
+func (r *PgxAccountRepository) CheckAccountTypeWorkspaceOwnership(ctx context.Context, workspaceId string, accountTypeIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedAccountType, workspaceId, accountTypeIds...)
+}
+
+func (r *PgxAccountRepository) CreateNewAccount(ctx context.Context, params database.CreateNewAccountParams) (bool, error) {
+	return database.CreateNewAccount(ctx, r.Db, params)
+}
+
+func (r *PgxAccountRepository) UpdateAccount(ctx context.Context, params database.UpdateAccountParams) (bool, error) {
+	return database.UpdateAccount(ctx, r.Db, params)
+}
+
+func (r *PgxAccountRepository) UpdateAccountBalance(ctx context.Context, balance string, accountId string) (bool, error) {
+	return database.UpdateAccountBalance(ctx, r.Db, balance, accountId)
+}
+
+func (r *PgxAccountRepository) DeleteAccount(ctx context.Context, accountId string) (bool, error) {
+	return database.DeleteAccount(ctx, r.Db, accountId)
+}
+
+type PgxCashRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxCashRepository) GetAllCash(ctx context.Context, workspaceId string) ([]database.Cash, error) {
+	return database.GetAllCash(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxCashRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, cashIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedCash, workspaceId, cashIds...)
+}
+
+func (r *PgxCashRepository) CreateNewCashRecord(ctx context.Context, params database.CreateNewCashRecordParams) (bool, error) {
+	return database.CreateNewCashRecord(ctx, r.Db, params)
+}
+
+func (r *PgxCashRepository) UpdateCashRecord(ctx context.Context, params database.UpdateCashRecordParams) (bool, error) {
+	return database.UpdateCashRecord(ctx, r.Db, params)
+}
+
+func (r *PgxCashRepository) DeleteCashRecord(ctx context.Context, cashId string, workspaceId string) (bool, error) {
+	return database.DeleteCashRecord(ctx, r.Db, cashId, workspaceId)
+}
+
+type PgxClientRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxClientRepository) CreateNewClient(ctx context.Context, params database.CreateNewClientParams) (string, error) {
+	return database.CreateNewClient(ctx, r.Db, params)
+}
+
+func (r *PgxClientRepository) GetClientByEmail(ctx context.Context, email string) (database.Client, error) {
+	return database.GetClientByEmail(ctx, r.Db, email)
+}
+
+func (r *PgxClientRepository) GetClientById(ctx context.Context, id string) (database.Client, error) {
+	return database.GetClientById(ctx, r.Db, id)
+}
+
+func (r *PgxClientRepository) UpdateClientSettings(ctx context.Context, params database.UpdateClientSettingsParams) (bool, error) {
+	return database.UpdateClientSettings(ctx, r.Db, params)
+}
+
+type PgxCreditAccountRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxCreditAccountRepository) GetAllCreditAccounts(ctx context.Context) ([]database.CreditAccountDetails, error) {
+	return database.GetAllCreditAccounts(ctx, r.Db)
+}
+
+func (r *PgxCreditAccountRepository) GetAllCreditAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.CreditAccountDetails, error) {
+	return database.GetAllCreditAccountsByWorkspaceId(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxCreditAccountRepository) UpsertCreditAccount(ctx context.Context, params database.UpsertCreditAccountParams) (bool, error) {
+	return database.UpsertCreditAccount(ctx, r.Db, params)
+}
+
+func (r *PgxCreditAccountRepository) GetAllCreditStatements(ctx context.Context, accountId string) ([]database.CreditStatement, error) {
+	return database.GetAllCreditStatements(ctx, r.Db, accountId)
+}
+
+func (r *PgxCreditAccountRepository) CheckExistingCreditStatement(ctx context.Context, accountId string, periodEnd time.Time) (bool, error) {
+	return database.CheckExistingCreditStatement(ctx, r.Db, accountId, periodEnd)
+}
+
+type PgxExchangeRateRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxExchangeRateRepository) GetAllExchangeRates(ctx context.Context) ([]database.ExchangeRate, error) {
+	return database.GetAllExchangeRates(ctx, r.Db)
+}
+
+func (r *PgxExchangeRateRepository) UpdateExchangeRate(ctx context.Context, params database.UpdateExchangeRateParams) (bool, error) {
+	return database.UpdateExchangeRate(ctx, r.Db, params)
+}
+
+type PgxFuturePaymentRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxFuturePaymentRepository) GetAllFuturePayments(ctx context.Context) ([]database.FuturePayment, error) {
+	return database.GetAllFuturePayments(ctx, r.Db)
+}
+
+func (r *PgxFuturePaymentRepository) GetAllFuturePaymentsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.FuturePayment, error) {
+	return database.GetAllFuturePaymentsByWorkspaceId(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxFuturePaymentRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, futurePaymentIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedFuturePayment, workspaceId, futurePaymentIds...)
+}
+
+func (r *PgxFuturePaymentRepository) CreateNewFuturePayment(ctx context.Context, params database.CreateNewFuturePaymentParams) (bool, error) {
+	return database.CreateNewFuturePayment(ctx, r.Db, params)
+}
+
+func (r *PgxFuturePaymentRepository) UpdateFuturePayment(ctx context.Context, params database.UpdateFuturePaymentParams) (bool, error) {
+	return database.UpdateFuturePayment(ctx, r.Db, params)
+}
+
+func (r *PgxFuturePaymentRepository) UpdateFuturePaymentSchedule(ctx context.Context, scheduledAt time.Time, id string) (bool, error) {
+	return database.UpdateFuturePaymentSchedule(ctx, r.Db, scheduledAt, id)
+}
+
+func (r *PgxFuturePaymentRepository) DeleteFuturePayment(ctx context.Context, futurePaymentId string, workspaceId string) (bool, error) {
+	return database.DeleteFuturePayment(ctx, r.Db, futurePaymentId, workspaceId)
+}
+
+type PgxGoalRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxGoalRepository) GetAllGoalsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.Goal, error) {
+	return database.GetAllGoalsByWorkspaceId(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxGoalRepository) GetAllGoalAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.GoalAccount, error) {
+	return database.GetAllGoalAccountsByWorkspaceId(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxGoalRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, goalIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedGoal, workspaceId, goalIds...)
+}
+
+func (r *PgxGoalRepository) CreateNewGoal(ctx context.Context, params database.CreateNewGoalParams) (bool, error) {
+	return database.CreateNewGoal(ctx, r.Db, params)
+}
+
+func (r *PgxGoalRepository) UpdateGoal(ctx context.Context, params database.UpdateGoalParams) (bool, error) {
+	return database.UpdateGoal(ctx, r.Db, params)
+}
+
+func (r *PgxGoalRepository) DeleteGoal(ctx context.Context, goalId string, workspaceId string) (bool, error) {
+	return database.DeleteGoal(ctx, r.Db, goalId, workspaceId)
+}
+
+type PgxLedgerRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxLedgerRepository) RecordTransaction(ctx context.Context, params database.CreateNewTransactionParams) (bool, error) {
+	return database.RecordTransaction(ctx, r.Db, params)
+}
+
+func (r *PgxLedgerRepository) RecordTransfer(ctx context.Context, source database.CreateNewTransactionParams, target database.CreateNewTransactionParams) (bool, error) {
+	return database.RecordTransfer(ctx, r.Db, source, target)
+}
+
+func (r *PgxLedgerRepository) DeleteTransaction(ctx context.Context, transactionId string, workspaceId string) (bool, error) {
+	return database.DeleteTransactionAndRevertBalance(ctx, r.Db, transactionId, workspaceId)
+}
+
+func (r *PgxLedgerRepository) ExecuteFuturePayment(ctx context.Context, payment database.FuturePayment, nextScheduledAt *time.Time) (bool, error) {
+	return database.ExecuteFuturePayment(ctx, r.Db, payment, nextScheduledAt)
+}
+
+func (r *PgxLedgerRepository) UpsertLoanAccount(ctx context.Context, params database.UpsertLoanAccountParams, drawdown *database.CreateNewTransactionParams) (bool, error) {
+	return database.UpsertLoanAccount(ctx, r.Db, params, drawdown)
+}
+
+func (r *PgxLedgerRepository) RecordLoanRepayment(ctx context.Context, params database.CreateNewLoanRepaymentParams) (string, error) {
+	return database.CreateNewLoanRepayment(ctx, r.Db, params)
+}
+
+func (r *PgxLedgerRepository) RecordReconciliation(ctx context.Context, params database.CreateNewReconciliationParams) (bool, error) {
+	return database.CreateNewReconciliation(ctx, r.Db, params)
+}
+
+func (r *PgxLedgerRepository) RecordCreditStatement(ctx context.Context, params database.CreateNewCreditStatementParams, withRepayment bool) (bool, error) {
+	return database.CreateNewCreditStatement(ctx, r.Db, params, withRepayment)
+}
+
+type PgxLoanAccountRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxLoanAccountRepository) GetAllLoanAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.LoanAccountDetails, error) {
+	return database.GetAllLoanAccountsByWorkspaceId(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxLoanAccountRepository) GetAllLoanRepayments(ctx context.Context, accountId string) ([]database.LoanRepayment, error) {
+	return database.GetAllLoanRepayments(ctx, r.Db, accountId)
+}
+
+type PgxReconciliationRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxReconciliationRepository) GetAllReconciliations(ctx context.Context, accountId string) ([]database.Reconciliation, error) {
+	return database.GetAllReconciliations(ctx, r.Db, accountId)
+}
+
+type PgxStockRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxStockRepository) GetAllStocks(ctx context.Context) ([]database.Stock, error) {
+	return database.GetAllStocks(ctx, r.Db)
+}
+
+func (r *PgxStockRepository) GetAllStocksByCountryId(ctx context.Context, id string) ([]database.Stock, error) {
+	return database.GetAllStocksByCountryId(ctx, r.Db, id)
+}
+
+func (r *PgxStockRepository) UpdateStockPrice(ctx context.Context, params database.UpdateStockPriceParams) (bool, error) {
+	return database.UpdateStockPrice(ctx, r.Db, params)
+}
+
+func (r *PgxStockRepository) GetAllStockHoldings(ctx context.Context, workspaceId string) ([]database.AccountStock, error) {
+	return database.GetAllStockHoldings(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxStockRepository) CheckStockHoldingWorkspaceOwnership(ctx context.Context, workspaceId string, accountStockIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedStockHolding, workspaceId, accountStockIds...)
+}
+
+func (r *PgxStockRepository) CreateNewStockHolding(ctx context.Context, params database.CreateNewStockHoldingParams) (bool, error) {
+	return database.CreateNewStockHolding(ctx, r.Db, params)
+}
+
+func (r *PgxStockRepository) UpdateStockHoldingCost(ctx context.Context, params database.UpdateStockHoldingCostParams) (bool, error) {
+	return database.UpdateStockHoldingCost(ctx, r.Db, params)
+}
+
+func (r *PgxStockRepository) DeleteStockHolding(ctx context.Context, accountStockId string) (bool, error) {
+	return database.DeleteStockHolding(ctx, r.Db, accountStockId)
+}
+
+type PgxTransactionRepository struct {
+	Db *pgxpool.Pool
+}
+
+func (r *PgxTransactionRepository) GetAllTransactions(ctx context.Context, workspaceId string) ([]database.Transaction, error) {
+	return database.GetAllTransactions(ctx, r.Db, workspaceId)
+}
+
+func (r *PgxTransactionRepository) GetAllTransactionsByAccountIdBetween(ctx context.Context, accountId string, start time.Time, end time.Time) ([]database.Transaction, error) {
+	return database.GetAllTransactionsByAccountIdBetween(ctx, r.Db, accountId, start, end)
+}
+
+func (r *PgxTransactionRepository) GetAllTransactionsByAccountIdAfter(ctx context.Context, accountId string, after time.Time) ([]database.Transaction, error) {
+	return database.GetAllTransactionsByAccountIdAfter(ctx, r.Db, accountId, after)
+}
+
+func (r *PgxTransactionRepository) GetAllTransactionsByAccountIdSince(ctx context.Context, accountId string, since time.Time) ([]database.Transaction, error) {
+	return database.GetAllTransactionsByAccountIdSince(ctx, r.Db, accountId, since)
+}
+
+func (r *PgxTransactionRepository) GetTransactionAndAccountBalanceById(ctx context.Context, transactionId string) (database.TransactionAndAccountBalance, error) {
+	return database.GetTransactionAndAccountBalanceById(ctx, r.Db, transactionId)
+}
+
+func (r *PgxTransactionRepository) CheckWorkspaceOwnership(ctx context.Context, workspaceId string, transactionIds ...string) (bool, error) {
+	return database.CheckWorkspaceOwnership(ctx, r.Db, database.OwnedTransaction, workspaceId, transactionIds...)
+}
+
+func (r *PgxTransactionRepository) CreateNewTransaction(ctx context.Context, params database.CreateNewTransactionParams) (bool, error) {
+	return database.CreateNewTransaction(ctx, r.Db, params)
+}
+
+func (r *PgxTransactionRepository) DeleteTransaction(ctx context.Context, transactionId string, workspaceId string) (bool, error) {
+	return database.DeleteTransaction(ctx, r.Db, transactionId, workspaceId)
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nighostchris/everytrack-backend/internal/database"
+)
+
+type AccountRepository interface {
+	GetAllAccountSummaryByType(ctx context.Context, providerType string, workspaceId string) ([]database.AccountSummary, error)
+	GetAllAccountSummaryByWorkspaceId(ctx context.Context, workspaceId string) ([]database.AccountSummary, error)
+	GetAccountBalance(ctx context.Context, accountId string) (string, error)
+	GetAccountSummary(ctx context.Context, accountId string) (database.AccountSummary, error)
+	CheckExistingAccount(ctx context.Context, params database.CheckExistingAccountParams) (bool, error)
+	CheckWorkspaceOwnership(ctx context.Context, workspaceId string, accountIds ...string) (bool, error)
+	CheckAccountTypeWorkspaceOwnership(ctx context.Context, workspaceId string, accountTypeIds ...string) (bool, error)
+	CreateNewAccount(ctx context.Context, params database.CreateNewAccountParams) (bool, error)
+	UpdateAccount(ctx context.Context, params database.UpdateAccountParams) (bool, error)
+	UpdateAccountBalance(ctx context.Context, balance string, accountId string) (bool, error)
+	DeleteAccount(ctx context.Context, accountId string) (bool, error)
+}
+
+type CashRepository interface {
+	GetAllCash(ctx context.Context, workspaceId string) ([]database.Cash, error)
+	CheckWorkspaceOwnership(ctx context.Context, workspaceId string, cashIds ...string) (bool, error)
+	CreateNewCashRecord(ctx context.Context, params database.CreateNewCashRecordParams) (bool, error)
+	UpdateCashRecord(ctx context.Context, params database.UpdateCashRecordParams) (bool, error)
+	DeleteCashRecord(ctx context.Context, cashId string, workspaceId string) (bool, error)
+}
+
+type ClientRepository interface {
+	CreateNewClient(ctx context.Context, params database.CreateNewClientParams) (string, error)
+	GetClientByEmail(ctx context.Context, email string) (database.Client, error)
+	GetClientById(ctx context.Context, id string) (database.Client, error)
+	UpdateClientSettings(ctx context.Context, params database.UpdateClientSettingsParams) (bool, error)
+}
+
+type CreditAccountRepository interface {
+	GetAllCreditAccounts(ctx context.Context) ([]database.CreditAccountDetails, error)
+	GetAllCreditAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.CreditAccountDetails, error)
+	UpsertCreditAccount(ctx context.Context, params database.UpsertCreditAccountParams) (bool, error)
+	GetAllCreditStatements(ctx context.Context, accountId string) ([]database.CreditStatement, error)
+	CheckExistingCreditStatement(ctx context.Context, accountId string, periodEnd time.Time) (bool, error)
+}
+
+type ExchangeRateRepository interface {
+	GetAllExchangeRates(ctx context.Context) ([]database.ExchangeRate, error)
+	UpdateExchangeRate(ctx context.Context, params database.UpdateExchangeRateParams) (bool, error)
+}
+
+type FuturePaymentRepository interface {
+	GetAllFuturePayments(ctx context.Context) ([]database.FuturePayment, error)
+	GetAllFuturePaymentsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.FuturePayment, error)
+	CheckWorkspaceOwnership(ctx context.Context, workspaceId string, futurePaymentIds ...string) (bool, error)
+	CreateNewFuturePayment(ctx context.Context, params database.CreateNewFuturePaymentParams) (bool, error)
+	UpdateFuturePayment(ctx context.Context, params database.UpdateFuturePaymentParams) (bool, error)
+	UpdateFuturePaymentSchedule(ctx context.Context, scheduledAt time.Time, id string) (bool, error)
+	DeleteFuturePayment(ctx context.Context, futurePaymentId string, workspaceId string) (bool, error)
+}
+
+type GoalRepository interface {
+	GetAllGoalsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.Goal, error)
+	GetAllGoalAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.GoalAccount, error)
+	CheckWorkspaceOwnership(ctx context.Context, workspaceId string, goalIds ...string) (bool, error)
+	CreateNewGoal(ctx context.Context, params database.CreateNewGoalParams) (bool, error)
+	UpdateGoal(ctx context.Context, params database.UpdateGoalParams) (bool, error)
+	DeleteGoal(ctx context.Context, goalId string, workspaceId string) (bool, error)
+}
+
+type LoanAccountRepository interface {
+	GetAllLoanAccountsByWorkspaceId(ctx context.Context, workspaceId string) ([]database.LoanAccountDetails, error)
+	GetAllLoanRepayments(ctx context.Context, accountId string) ([]database.LoanRepayment, error)
+}
+
+type ReconciliationRepository interface {
+	GetAllReconciliations(ctx context.Context, accountId string) ([]database.Reconciliation, error)
+}
+
+type StockRepository interface {
+	GetAllStocks(ctx context.Context) ([]database.Stock, error)
+	GetAllStocksByCountryId(ctx context.Context, id string) ([]database.Stock, error)
+	UpdateStockPrice(ctx context.Context, params database.UpdateStockPriceParams) (bool, error)
+	GetAllStockHoldings(ctx context.Context, workspaceId string) ([]database.AccountStock, error)
+	CheckStockHoldingWorkspaceOwnership(ctx context.Context, workspaceId string, accountStockIds ...string) (bool, error)
+	CreateNewStockHolding(ctx context.Context, params database.CreateNewStockHoldingParams) (bool, error)
+	UpdateStockHoldingCost(ctx context.Context, params database.UpdateStockHoldingCostParams) (bool, error)
+	DeleteStockHolding(ctx context.Context, accountStockId string) (bool, error)
+}
+
+type TransactionRepository interface {
+	GetAllTransactions(ctx context.Context, workspaceId string) ([]database.Transaction, error)
+	GetAllTransactionsByAccountIdBetween(ctx context.Context, accountId string, start time.Time, end time.Time) ([]database.Transaction, error)
+	GetAllTransactionsByAccountIdAfter(ctx context.Context, accountId string, after time.Time) ([]database.Transaction, error)
+	GetAllTransactionsByAccountIdSince(ctx context.Context, accountId string, since time.Time) ([]database.Transaction, error)
+	GetTransactionAndAccountBalanceById(ctx context.Context, transactionId string) (database.TransactionAndAccountBalance, error)
+	CheckWorkspaceOwnership(ctx context.Context, workspaceId string, transactionIds ...string) (bool, error)
+	CreateNewTransaction(ctx context.Context, params database.CreateNewTransactionParams) (bool, error)
+	DeleteTransaction(ctx context.Context, transactionId string, workspaceId string) (bool, error)
+}
+
+// Changes of account balances together with the records causing them, each of which is applied atomically
+type LedgerRepository interface {
+	RecordTransaction(ctx context.Context, params database.CreateNewTransactionParams) (bool, error)
+	RecordTransfer(ctx context.Context, source database.CreateNewTransactionParams, target database.CreateNewTransactionParams) (bool, error)
+	DeleteTransaction(ctx context.Context, transactionId string, workspaceId string) (bool, error)
+	ExecuteFuturePayment(ctx context.Context, payment database.FuturePayment, nextScheduledAt *time.Time) (bool, error)
+	UpsertLoanAccount(ctx context.Context, params database.UpsertLoanAccountParams, drawdown *database.CreateNewTransactionParams) (bool, error)
+	RecordLoanRepayment(ctx context.Context, params database.CreateNewLoanRepaymentParams) (string, error)
+	RecordReconciliation(ctx context.Context, params database.CreateNewReconciliationParams) (bool, error)
+	RecordCreditStatement(ctx context.Context, params database.CreateNewCreditStatementParams, withRepayment bool) (bool, error)
+}
+
+// Repositories of every aggregate, shared by the handlers they are injected into
+type Repositories struct {
+	Accounts        AccountRepository
+	Cash            CashRepository
+	Clients         ClientRepository
+	CreditAccounts  CreditAccountRepository
+	ExchangeRates   ExchangeRateRepository
+	FuturePayments  FuturePaymentRepository
+	Goals           GoalRepository
+	Ledger          LedgerRepository
+	LoanAccounts    LoanAccountRepository
+	Reconciliations ReconciliationRepository
+	Stocks          StockRepository
+	Transactions    TransactionRepository
+}
+
+// Repositories backed by postgres through the database package
+func NewPgxRepositories(db *pgxpool.Pool) *Repositories {
+	return &Repositories{
+		Accounts:        &PgxAccountRepository{Db: db},
+		Cash:            &PgxCashRepository{Db: db},
+		Clients:         &PgxClientRepository{Db: db},
+		CreditAccounts:  &PgxCreditAccountRepository{Db: db},
+		ExchangeRates:   &PgxExchangeRateRepository{Db: db},
+		FuturePayments:  &PgxFuturePaymentRepository{Db: db},
+		Goals:           &PgxGoalRepository{Db: db},
+		Ledger:          &PgxLedgerRepository{Db: db},
+		LoanAccounts:    &PgxLoanAccountRepository{Db: db},
+		Reconciliations: &PgxReconciliationRepository{Db: db},
+		Stocks:          &PgxStockRepository{Db: db},
+		Transactions:    &PgxTransactionRepository{Db: db},
+	}
+}
+
+// Repositories sharing a single in-memory store, for tests and local experiments without postgres
+func NewMemoryRepositories(store *MemoryStore) *Repositories {
+	return &Repositories{
+		Accounts:        &MemoryAccountRepository{Store: store},
+		Cash:            &MemoryCashRepository{Store: store},
+		Clients:         &MemoryClientRepository{Store: store},
+		CreditAccounts:  &MemoryCreditAccountRepository{Store: store},
+		ExchangeRates:   &MemoryExchangeRateRepository{Store: store},
+		FuturePayments:  &MemoryFuturePaymentRepository{Store: store},
+		Goals:           &MemoryGoalRepository{Store: store},
+		Ledger:          &MemoryLedgerRepository{Store: store},
+		LoanAccounts:    &MemoryLoanAccountRepository{Store: store},
+		Reconciliations: &MemoryReconciliationRepository{Store: store},
+		Stocks:          &MemoryStockRepository{Store: store},
+		Transactions:    &MemoryTransactionRepository{Store: store},
+	}
+}
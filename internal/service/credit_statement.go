@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type CreditStatement struct {
+	Balance decimal.Decimal `json:"balance"`
+	// Without a repayment account the statement is only a reminder, as paying it off needs money leaving another account
+	WithRepayment bool `json:"with_repayment"`
+}
+
+// Close the statement of the last closed period of a credit account and schedule repayment of the statement balance
+// from the repayment account on due date. Nil is returned if the statement has been closed already.
+func (ls *LedgerService) CloseCreditStatement(ctx context.Context, creditAccount database.CreditAccountDetails, at time.Time) (*CreditStatement, error) {
+	closedPeriod := utils.CalculateLastClosedStatementPeriod(creditAccount.StatementDay, at)
+
+	statementExists, checkExistingStatementError := ls.CreditAccounts.CheckExistingCreditStatement(ctx, creditAccount.Id, closedPeriod.End)
+	if checkExistingStatementError != nil {
+		return nil, internal("failed to check existing statement", checkExistingStatementError)
+	}
+	if statementExists {
+		return nil, nil
+	}
+
+	// Calculate statement balance from the account balance at closing date, so that unpaid amounts of previous statements are carried over
+	currentBalance, parseBalanceError := decimal.NewFromString(creditAccount.Balance)
+	if parseBalanceError != nil {
+		return nil, internal("failed to parse balance into decimal", parseBalanceError)
+	}
+	laterTransactions, getTransactionsError := ls.Transactions.GetAllTransactionsByAccountIdSince(ctx, creditAccount.Id, closedPeriod.End)
+	if getTransactionsError != nil {
+		return nil, internal("failed to get transactions after statement period", getTransactionsError)
+	}
+	statementBalance, calculateBalanceError := utils.CalculateClosingStatementBalance(currentBalance, laterTransactions)
+	if calculateBalanceError != nil {
+		return nil, internal("failed to calculate statement balance", calculateBalanceError)
+	}
+
+	statement := CreditStatement{
+		Balance:       statementBalance.Truncate(2),
+		WithRepayment: statementBalance.IsPositive() && creditAccount.RepaymentAccountId.Valid,
+	}
+	_, recordError := ls.Ledger.RecordCreditStatement(
+		ctx,
+		database.CreateNewCreditStatementParams{
+			Name:               fmt.Sprintf("%s statement repayment", creditAccount.Name),
+			Balance:            statement.Balance.String(),
+			ClientId:           creditAccount.ClientId,
+			WorkspaceId:        creditAccount.WorkspaceId,
+			AccountId:          creditAccount.Id,
+			RepaymentAccountId: creditAccount.RepaymentAccountId.String,
+			CurrencyId:         creditAccount.CurrencyId,
+			PeriodStart:        closedPeriod.Start,
+			PeriodEnd:          closedPeriod.End,
+			DueAt:              utils.CalculateStatementDueDate(creditAccount.DueDay, closedPeriod.End),
+		},
+		statement.WithRepayment,
+	)
+	if recordError != nil {
+		return nil, internal("failed to record credit statement", recordError)
+	}
+
+	return &statement, nil
+}
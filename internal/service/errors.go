@@ -0,0 +1,32 @@
+package service
+
+import "github.com/nighostchris/everytrack-backend/internal/apperror"
+
+// Error returned by every service operation, shared with handlers so that transports render it the same way
+type Error = apperror.Error
+
+// Category of a service error, transports map it into their own status, e.g. HTTP status code
+type ErrorCode = apperror.Code
+
+const (
+	CodeInvalidArgument = apperror.CodeInvalidArgument
+	CodeNotFound        = apperror.CodeNotFound
+	CodeConflict        = apperror.CodeConflict
+	CodeInternal        = apperror.CodeInternal
+)
+
+func invalidArgument(message string) *Error {
+	return apperror.InvalidArgument(message)
+}
+
+func invalidField(field string, rule string, message string) *Error {
+	return apperror.InvalidField(field, rule, message)
+}
+
+func notFound(message string) *Error {
+	return apperror.NotFound(message)
+}
+
+func internal(message string, err error) *Error {
+	return apperror.Wrap(message, err)
+}
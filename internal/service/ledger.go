@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/repository"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+// Operations changing account balances, each one keeps the balance in line with the transaction records
+type LedgerService struct {
+	Accounts        repository.AccountRepository
+	CreditAccounts  repository.CreditAccountRepository
+	Ledger          repository.LedgerRepository
+	LoanAccounts    repository.LoanAccountRepository
+	Reconciliations repository.ReconciliationRepository
+	Transactions    repository.TransactionRepository
+}
+
+type RecordTransactionParams struct {
+	Name        string    `json:"name"`
+	Income      bool      `json:"income"`
+	Amount      string    `json:"amount"`
+	Remarks     *string   `json:"remarks"`
+	Category    string    `json:"category"`
+	ClientId    string    `json:"client_id"`
+	WorkspaceId string    `json:"workspace_id"`
+	AccountId   string    `json:"account_id"`
+	CurrencyId  string    `json:"currency_id"`
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+type TransferParams struct {
+	Amount          string `json:"amount"`
+	ClientId        string `json:"client_id"`
+	WorkspaceId     string `json:"workspace_id"`
+	SourceAccountId string `json:"source_account_id"`
+	TargetAccountId string `json:"target_account_id"`
+}
+
+func NewLedgerService(repositories *repository.Repositories) *LedgerService {
+	return &LedgerService{
+		Accounts:        repositories.Accounts,
+		CreditAccounts:  repositories.CreditAccounts,
+		Ledger:          repositories.Ledger,
+		LoanAccounts:    repositories.LoanAccounts,
+		Reconciliations: repositories.Reconciliations,
+		Transactions:    repositories.Transactions,
+	}
+}
+
+// Create a transaction record and apply its amount on the account balance
+func (ls *LedgerService) RecordTransaction(ctx context.Context, params RecordTransactionParams) error {
+	if _, parseAmountError := decimal.NewFromString(params.Amount); parseAmountError != nil {
+		return invalidField("amount", "decimal", "must be a decimal number")
+	}
+	if ownershipError := ls.checkAccountOwnership(ctx, params.WorkspaceId, params.AccountId); ownershipError != nil {
+		return ownershipError
+	}
+
+	_, recordError := ls.Ledger.RecordTransaction(ctx, database.CreateNewTransactionParams{
+		Name:        params.Name,
+		Income:      params.Income,
+		Amount:      params.Amount,
+		Remarks:     params.Remarks,
+		Category:    params.Category,
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		AccountId:   params.AccountId,
+		CurrencyId:  params.CurrencyId,
+		ExecutedAt:  params.ExecutedAt,
+	})
+	if recordError != nil {
+		return internal("failed to record transaction", recordError)
+	}
+
+	return nil
+}
+
+// Revert the amount of a transaction on its account balance and delete the record
+func (ls *LedgerService) DeleteTransaction(ctx context.Context, workspaceId string, transactionId string) error {
+	isOwned, checkOwnershipError := ls.Transactions.CheckWorkspaceOwnership(ctx, workspaceId, transactionId)
+	if checkOwnershipError != nil {
+		return internal("failed to check ownership of transaction", checkOwnershipError)
+	}
+	if !isOwned {
+		return notFound("Transaction not found.")
+	}
+
+	// Deleted concurrently by another request after the ownership check
+	isDeleted, deleteError := ls.Ledger.DeleteTransaction(ctx, transactionId, workspaceId)
+	if deleteError != nil {
+		return internal("failed to delete transaction", deleteError)
+	}
+	if !isDeleted {
+		return notFound("Transaction not found.")
+	}
+
+	return nil
+}
+
+// Move an amount between two accounts of the same workspace, recording a transaction on each side
+func (ls *LedgerService) Transfer(ctx context.Context, params TransferParams) error {
+	if _, parseAmountError := decimal.NewFromString(params.Amount); parseAmountError != nil {
+		return invalidField("amount", "decimal", "must be a decimal number")
+	}
+	if params.SourceAccountId == params.TargetAccountId {
+		return invalidArgument("Cannot transfer to the same account.")
+	}
+	if ownershipError := ls.checkAccountOwnership(ctx, params.WorkspaceId, params.SourceAccountId, params.TargetAccountId); ownershipError != nil {
+		return ownershipError
+	}
+
+	sourceAccountSummary, getSourceAccountSummaryError := ls.Accounts.GetAccountSummary(ctx, params.SourceAccountId)
+	if getSourceAccountSummaryError != nil {
+		return internal("failed to get source account summary", getSourceAccountSummaryError)
+	}
+	targetAccountSummary, getTargetAccountSummaryError := ls.Accounts.GetAccountSummary(ctx, params.TargetAccountId)
+	if getTargetAccountSummaryError != nil {
+		return internal("failed to get target account summary", getTargetAccountSummaryError)
+	}
+
+	executedAt := time.Now().Truncate(24 * time.Hour)
+	_, transferError := ls.Ledger.RecordTransfer(
+		ctx,
+		database.CreateNewTransactionParams{
+			Income:      false,
+			Name:        fmt.Sprintf("Transfer to %s", targetAccountSummary.Name),
+			Amount:      params.Amount,
+			ClientId:    params.ClientId,
+			WorkspaceId: params.WorkspaceId,
+			Category:    "bank-transfer",
+			AccountId:   params.SourceAccountId,
+			CurrencyId:  sourceAccountSummary.CurrencyId,
+			ExecutedAt:  executedAt,
+		},
+		database.CreateNewTransactionParams{
+			Income:      true,
+			Name:        fmt.Sprintf("Received from %s", sourceAccountSummary.Name),
+			Amount:      params.Amount,
+			ClientId:    params.ClientId,
+			WorkspaceId: params.WorkspaceId,
+			Category:    "bank-transfer",
+			AccountId:   params.TargetAccountId,
+			CurrencyId:  targetAccountSummary.CurrencyId,
+			ExecutedAt:  executedAt,
+		},
+	)
+	if transferError != nil {
+		return internal("failed to record transfer", transferError)
+	}
+
+	return nil
+}
+
+// Apply a due future payment on its account balance, then move a rolling payment to its next schedule or delete a one-off payment.
+// Funding of a goal which has reached its target is deleted without being applied.
+func (ls *LedgerService) ExecuteFuturePayment(ctx context.Context, payment database.FuturePayment) error {
+	if _, parseAmountError := decimal.NewFromString(payment.Amount); parseAmountError != nil {
+		return internal("failed to parse payment amount into decimal", parseAmountError)
+	}
+
+	var nextScheduledAt *time.Time
+	if payment.Rolling {
+		paymentFrequency := utils.CalculateActualPaymentFrequency(int(payment.Frequency.Int64))
+		nextScheduledDate := payment.ScheduledAt.AddDate(paymentFrequency.Years, paymentFrequency.Months, paymentFrequency.Days)
+		// Payment scheduled past its end date is deleted after this execution instead
+		if !payment.EndsAt.Valid || !nextScheduledDate.After(payment.EndsAt.Time) {
+			nextScheduledAt = &nextScheduledDate
+		}
+	}
+
+	// Payment executed by an overlapping run already is skipped rather than applied twice, and so is the funding of a reached goal
+	if _, executeError := ls.Ledger.ExecuteFuturePayment(ctx, payment, nextScheduledAt); executeError != nil {
+		return internal("failed to execute future payment", executeError)
+	}
+
+	return nil
+}
+
+func (ls *LedgerService) checkAccountOwnership(ctx context.Context, workspaceId string, accountIds ...string) error {
+	isOwned, checkOwnershipError := ls.Accounts.CheckWorkspaceOwnership(ctx, workspaceId, accountIds...)
+	if checkOwnershipError != nil {
+		return internal("failed to check ownership of account", checkOwnershipError)
+	}
+	if !isOwned {
+		return notFound("Account not found.")
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type UpdateLoanAccountParams struct {
+	ClientId     string    `json:"client_id"`
+	WorkspaceId  string    `json:"workspace_id"`
+	AccountId    string    `json:"account_id"`
+	Principal    string    `json:"principal"`
+	AnnualRate   string    `json:"annual_rate"`
+	TermInMonths int       `json:"term_in_months"`
+	StartDate    time.Time `json:"start_date"`
+}
+
+type RepayLoanParams struct {
+	ClientId    string    `json:"client_id"`
+	WorkspaceId string    `json:"workspace_id"`
+	AccountId   string    `json:"account_id"`
+	Amount      string    `json:"amount"`
+	Extra       bool      `json:"extra"`
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+type LoanRepayment struct {
+	Interest  string `json:"interest"`
+	Principal string `json:"principal"`
+	Balance   string `json:"balance"`
+}
+
+// Create or update loan details of an account, booking the drawdown for a newly configured loan that has no balance yet
+func (ls *LedgerService) UpdateLoanAccount(ctx context.Context, params UpdateLoanAccountParams) error {
+	principal, parsePrincipalError := decimal.NewFromString(params.Principal)
+	if parsePrincipalError != nil || !principal.IsPositive() {
+		return invalidField("principal", "format", "has an invalid value")
+	}
+	if annualRate, parseAnnualRateError := decimal.NewFromString(params.AnnualRate); parseAnnualRateError != nil || annualRate.IsNegative() {
+		return invalidField("annualRate", "format", "has an invalid value")
+	}
+
+	ownedAccounts, getOwnedAccountsError := ls.Accounts.GetAllAccountSummaryByType(ctx, "loan", params.WorkspaceId)
+	if getOwnedAccountsError != nil {
+		return internal("failed to get all owned loan accounts", getOwnedAccountsError)
+	}
+	var ownedAccount *database.AccountSummary
+	for index := range ownedAccounts {
+		if ownedAccounts[index].Id == params.AccountId {
+			ownedAccount = &ownedAccounts[index]
+		}
+	}
+	if ownedAccount == nil {
+		return notFound("Account not found.")
+	}
+
+	balance, parseBalanceError := decimal.NewFromString(ownedAccount.Balance)
+	if parseBalanceError != nil {
+		return internal("failed to parse balance into decimal", parseBalanceError)
+	}
+
+	var drawdown *database.CreateNewTransactionParams
+	if balance.IsZero() {
+		drawdown = &database.CreateNewTransactionParams{
+			Income:      false,
+			Name:        fmt.Sprintf("%s drawdown", ownedAccount.Name),
+			Amount:      principal.String(),
+			ClientId:    params.ClientId,
+			WorkspaceId: params.WorkspaceId,
+			Category:    "loan-drawdown",
+			AccountId:   params.AccountId,
+			CurrencyId:  ownedAccount.CurrencyId,
+			ExecutedAt:  params.StartDate,
+		}
+	}
+
+	_, upsertError := ls.Ledger.UpsertLoanAccount(
+		ctx,
+		database.UpsertLoanAccountParams{
+			AccountId:    params.AccountId,
+			Principal:    params.Principal,
+			AnnualRate:   params.AnnualRate,
+			TermInMonths: params.TermInMonths,
+			StartDate:    params.StartDate,
+		},
+		drawdown,
+	)
+	if upsertError != nil {
+		return internal("failed to update loan account", upsertError)
+	}
+
+	return nil
+}
+
+// Split a repayment into interest and principal, extra repayments go fully into principal, and apply the principal on the loan balance
+func (ls *LedgerService) RepayLoan(ctx context.Context, params RepayLoanParams) (LoanRepayment, error) {
+	repayment := LoanRepayment{}
+
+	amount, parseAmountError := decimal.NewFromString(params.Amount)
+	if parseAmountError != nil || !amount.IsPositive() {
+		return repayment, invalidField("amount", "format", "has an invalid value")
+	}
+
+	loanAccounts, getLoanAccountsError := ls.LoanAccounts.GetAllLoanAccountsByWorkspaceId(ctx, params.WorkspaceId)
+	if getLoanAccountsError != nil {
+		return repayment, internal("failed to get all loan accounts", getLoanAccountsError)
+	}
+	var loanAccount *database.LoanAccountDetails
+	for index := range loanAccounts {
+		if loanAccounts[index].Id == params.AccountId {
+			loanAccount = &loanAccounts[index]
+		}
+	}
+	if loanAccount == nil {
+		return repayment, notFound("Loan account not found.")
+	}
+
+	// Outstanding amount of a loan account is the negated balance since the drawdown is deducted from balance
+	balance, parseBalanceError := decimal.NewFromString(loanAccount.Balance)
+	if parseBalanceError != nil {
+		return repayment, internal("failed to parse balance into decimal", parseBalanceError)
+	}
+	annualRate, parseAnnualRateError := decimal.NewFromString(loanAccount.AnnualRate)
+	if parseAnnualRateError != nil {
+		return repayment, internal("failed to parse annual rate into decimal", parseAnnualRateError)
+	}
+	outstanding := decimal.Max(balance.Neg(), decimal.Zero)
+
+	interest := decimal.Zero
+	if !params.Extra {
+		interest = decimal.Min(utils.CalculateMonthlyInterest(outstanding, annualRate), amount)
+	}
+	principal := amount.Sub(interest)
+	if principal.GreaterThan(outstanding) {
+		return repayment, invalidArgument("Repayment amount exceeds outstanding balance.")
+	}
+
+	newBalance, recordError := ls.Ledger.RecordLoanRepayment(ctx, database.CreateNewLoanRepaymentParams{
+		Name:        fmt.Sprintf("%s repayment", loanAccount.Name),
+		Amount:      amount.String(),
+		Interest:    interest.String(),
+		HasInterest: !interest.IsZero(),
+		Principal:   principal.String(),
+		Extra:       params.Extra,
+		ClientId:    params.ClientId,
+		WorkspaceId: params.WorkspaceId,
+		AccountId:   loanAccount.Id,
+		CurrencyId:  loanAccount.CurrencyId,
+		ExecutedAt:  params.ExecutedAt,
+	})
+	if recordError != nil {
+		return repayment, internal("failed to record loan repayment", recordError)
+	}
+
+	repayment.Interest = interest.String()
+	repayment.Principal = principal.String()
+	repayment.Balance = newBalance
+	return repayment, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type ReconcileParams struct {
+	ClientId         string    `json:"client_id"`
+	WorkspaceId      string    `json:"workspace_id"`
+	AccountId        string    `json:"account_id"`
+	StatementBalance string    `json:"statement_balance"`
+	StatementDate    time.Time `json:"statement_date"`
+	Adjust           bool      `json:"adjust"`
+}
+
+type UnmatchedTransaction struct {
+	Transaction database.Transaction `json:"transaction"`
+	// A transaction matching the difference is likely missing from or duplicated on the statement
+	Suggested bool `json:"suggested"`
+}
+
+type Reconciliation struct {
+	Status          string                 `json:"status"`
+	RecordedBalance string                 `json:"recorded_balance"`
+	Difference      string                 `json:"difference"`
+	Unmatched       []UnmatchedTransaction `json:"unmatched"`
+}
+
+// Compare the recorded balance of an account at statement date against the statement balance,
+// then either book the difference as an adjustment or list the transactions which could explain it
+func (ls *LedgerService) Reconcile(ctx context.Context, params ReconcileParams) (Reconciliation, error) {
+	result := Reconciliation{Unmatched: []UnmatchedTransaction{}}
+
+	statementBalance, parseStatementBalanceError := decimal.NewFromString(params.StatementBalance)
+	if parseStatementBalanceError != nil {
+		return result, invalidField("statementBalance", "format", "has an invalid value")
+	}
+	if params.StatementDate.After(time.Now()) {
+		return result, invalidField("statementDate", "format", "has an invalid value")
+	}
+
+	ownedAccounts, getOwnedAccountsError := ls.Accounts.GetAllAccountSummaryByWorkspaceId(ctx, params.WorkspaceId)
+	if getOwnedAccountsError != nil {
+		return result, internal("failed to get all owned accounts", getOwnedAccountsError)
+	}
+	var account *database.AccountSummary
+	for index := range ownedAccounts {
+		if ownedAccounts[index].Id == params.AccountId {
+			account = &ownedAccounts[index]
+		}
+	}
+	if account == nil {
+		return result, notFound("Account not found.")
+	}
+	currentBalance, parseBalanceError := decimal.NewFromString(account.Balance)
+	if parseBalanceError != nil {
+		return result, internal("failed to parse balance into decimal", parseBalanceError)
+	}
+
+	// Rewind the current balance by transactions executed after statement date to get the recorded balance at that time
+	laterTransactions, getLaterTransactionsError := ls.Transactions.GetAllTransactionsByAccountIdAfter(ctx, account.Id, params.StatementDate)
+	if getLaterTransactionsError != nil {
+		return result, internal("failed to get transactions after statement date", getLaterTransactionsError)
+	}
+	laterNetAmount, calculateLaterNetAmountError := utils.CalculateNetTransactionAmount(laterTransactions)
+	if calculateLaterNetAmountError != nil {
+		return result, internal("failed to calculate net amount of later transactions", calculateLaterNetAmountError)
+	}
+	recordedBalance := currentBalance.Sub(laterNetAmount)
+	difference := statementBalance.Sub(recordedBalance)
+
+	reconciliationParams := database.CreateNewReconciliationParams{
+		AccountId:        account.Id,
+		StatementBalance: statementBalance.String(),
+		RecordedBalance:  recordedBalance.String(),
+		Difference:       difference.String(),
+		Status:           "balanced",
+		StatementDate:    params.StatementDate,
+	}
+	if !difference.IsZero() && params.Adjust {
+		reconciliationParams.Status = "adjusted"
+		reconciliationParams.Adjustment = &database.ReconciliationAdjustmentParams{
+			Name:       "Reconciliation adjustment",
+			Income:     difference.IsPositive(),
+			Amount:     difference.Abs().String(),
+			ClientId:   params.ClientId,
+			CurrencyId: account.CurrencyId,
+		}
+	} else if !difference.IsZero() {
+		reconciliationParams.Status = "unresolved"
+	}
+
+	// List transactions not covered by previous settled reconciliation so that the user can look for the difference
+	if reconciliationParams.Status == "unresolved" {
+		reconciliations, getReconciliationsError := ls.Reconciliations.GetAllReconciliations(ctx, account.Id)
+		if getReconciliationsError != nil {
+			return result, internal("failed to get all reconciliations", getReconciliationsError)
+		}
+		lastSettledAt := time.Unix(0, 0)
+		for _, reconciliation := range reconciliations {
+			if reconciliation.Status != "unresolved" && reconciliation.StatementDate.Before(params.StatementDate) {
+				lastSettledAt = reconciliation.StatementDate
+				break
+			}
+		}
+
+		unsettledTransactions, getUnsettledTransactionsError := ls.Transactions.GetAllTransactionsByAccountIdAfter(ctx, account.Id, lastSettledAt)
+		if getUnsettledTransactionsError != nil {
+			return result, internal("failed to get unsettled transactions", getUnsettledTransactionsError)
+		}
+		for _, transaction := range unsettledTransactions {
+			if transaction.ExecutedAt.After(params.StatementDate) {
+				continue
+			}
+			amount, parseAmountError := decimal.NewFromString(transaction.Amount)
+			if parseAmountError != nil {
+				return result, internal("failed to parse transaction amount into decimal", parseAmountError)
+			}
+			result.Unmatched = append(result.Unmatched, UnmatchedTransaction{Transaction: transaction, Suggested: amount.Equal(difference.Abs())})
+		}
+	}
+
+	if _, recordError := ls.Ledger.RecordReconciliation(ctx, reconciliationParams); recordError != nil {
+		return result, internal("failed to record reconciliation", recordError)
+	}
+
+	result.Status = reconciliationParams.Status
+	result.RecordedBalance = recordedBalance.String()
+	result.Difference = difference.String()
+	return result, nil
+}
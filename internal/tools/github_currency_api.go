@@ -1,14 +1,18 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/metrics"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
 )
 
@@ -17,16 +21,18 @@ type GithubCurrencyApi struct {
 	Logger *zap.Logger
 }
 
-func (gca *GithubCurrencyApi) FetchLatestExchangeRates() {
-	gca.Logger.Info("starts")
+func (gca *GithubCurrencyApi) FetchLatestExchangeRates(ctx context.Context) error {
+	logger := gca.Logger.With(tracing.LogFields(ctx)...)
+	logger.Info("starts")
 
 	// Get all supported currencies in database
-	currencies, getCurrenciesError := database.GetAllCurrencies(gca.Db)
+	currencies, getCurrenciesError := database.GetAllCurrencies(ctx, gca.Db)
 	if getCurrenciesError != nil {
-		gca.Logger.Error(fmt.Sprintf("failed to get currencies from database. %s", getCurrenciesError.Error()))
-		return
+		logger.Error(fmt.Sprintf("failed to get currencies from database. %s", getCurrenciesError.Error()))
+		return getCurrenciesError
 	}
 
+	failedUpdates := 0
 	for index, currency := range currencies {
 		currencyTicker := strings.ToLower(currency.Ticker)
 		interestedCurrencies := []database.Currency{}
@@ -35,42 +41,50 @@ func (gca *GithubCurrencyApi) FetchLatestExchangeRates() {
 				interestedCurrencies = append(interestedCurrencies, c)
 			}
 		}
-		gca.Logger.Info(fmt.Sprintf("going to fetch github currency api for currency %s", currencyTicker))
+		logger.Info(fmt.Sprintf("going to fetch github currency api for currency %s", currencyTicker))
 
 		// Try to fetch github currency api
-		rawResponse, fetchError := http.Get(fmt.Sprintf("https://cdn.jsdelivr.net/gh/fawazahmed0/currency-api@1/latest/currencies/%s.json", currencyTicker))
+		fetchStartedAt := time.Now()
+		rawResponse, fetchError := otelhttp.Get(ctx, fmt.Sprintf("https://cdn.jsdelivr.net/gh/fawazahmed0/currency-api@1/latest/currencies/%s.json", currencyTicker))
+		metrics.ObserveExternalApiCall("github_currency_api", fetchStartedAt, rawResponse, fetchError)
 		if fetchError != nil {
-			gca.Logger.Error(fmt.Sprintf("failed to fetch github currency api. %s", fetchError.Error()))
-			return
+			logger.Error(fmt.Sprintf("failed to fetch github currency api. %s", fetchError.Error()))
+			return fetchError
 		}
 
 		// Convert api response into byte array
 		response, parseRawResponseError := io.ReadAll(rawResponse.Body)
 		if parseRawResponseError != nil {
-			gca.Logger.Error(fmt.Sprintf("failed to parse github currency api response. %s", parseRawResponseError.Error()))
-			return
+			logger.Error(fmt.Sprintf("failed to parse github currency api response. %s", parseRawResponseError.Error()))
+			return parseRawResponseError
 		}
 
 		// Convert api response byte array into consumable json
 		var data map[string]interface{}
 		convertJsonError := json.Unmarshal(response, &data)
 		if convertJsonError != nil {
-			gca.Logger.Error(fmt.Sprintf("failed to convert github currency api response into json. %s", convertJsonError.Error()))
-			return
+			logger.Error(fmt.Sprintf("failed to convert github currency api response into json. %s", convertJsonError.Error()))
+			return convertJsonError
 		}
 
 		// Insert exchange rate into database
 		fetchedRates := data[currencyTicker].(map[string]interface{})
 		for _, interestedCurrency := range interestedCurrencies {
-			gca.Logger.Info(fmt.Sprintf("updating exchange rate %s:%s in database", currency.Ticker, interestedCurrency.Ticker))
-			_, updateError := database.UpdateExchangeRate(gca.Db, database.UpdateExchangeRateParams{
+			logger.Info(fmt.Sprintf("updating exchange rate %s:%s in database", currency.Ticker, interestedCurrency.Ticker))
+			_, updateError := database.UpdateExchangeRate(ctx, gca.Db, database.UpdateExchangeRateParams{
 				BaseCurrencyId:   currency.Id,
 				TargetCurrencyId: interestedCurrency.Id,
 				Rate:             fmt.Sprintf("%.8f", fetchedRates[strings.ToLower(interestedCurrency.Ticker)].(float64)),
 			})
 			if updateError != nil {
-				gca.Logger.Error(fmt.Sprintf("failed to update exchange rate %s:%s in database. %s", currency.Ticker, interestedCurrency.Ticker, updateError.Error()))
+				logger.Error(fmt.Sprintf("failed to update exchange rate %s:%s in database. %s", currency.Ticker, interestedCurrency.Ticker, updateError.Error()))
+				failedUpdates++
 			}
 		}
 	}
+
+	if failedUpdates > 0 {
+		return fmt.Errorf("failed to update %d exchange rates", failedUpdates)
+	}
+	return nil
 }
@@ -1,16 +1,20 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nighostchris/everytrack-backend/internal/config"
 	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/nighostchris/everytrack-backend/internal/metrics"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
 )
 
@@ -45,21 +49,22 @@ type TwelveDataFinancialDataApi struct {
 	Env    *config.Config
 }
 
-func (tdfda *TwelveDataFinancialDataApi) FetchLatestUSStockPrice() {
-	tdfda.Logger.Info("starts")
+func (tdfda *TwelveDataFinancialDataApi) FetchLatestUSStockPrice(ctx context.Context) error {
+	logger := tdfda.Logger.With(tracing.LogFields(ctx)...)
+	logger.Info("starts")
 
 	// Get US country id from database
-	country, getCountryError := database.GetCountryByCode(tdfda.Db, "US")
+	country, getCountryError := database.GetCountryByCode(ctx, tdfda.Db, "US")
 	if getCountryError != nil {
-		tdfda.Logger.Error(fmt.Sprintf("failed to get country details for US from database. %s", getCountryError.Error()))
-		return
+		logger.Error(fmt.Sprintf("failed to get country details for US from database. %s", getCountryError.Error()))
+		return getCountryError
 	}
 
 	// Get all supported US stocks in database
-	stocks, getAllStocksError := database.GetAllStocksByCountryId(tdfda.Db, country.Id)
+	stocks, getAllStocksError := database.GetAllStocksByCountryId(ctx, tdfda.Db, country.Id)
 	if getAllStocksError != nil {
-		tdfda.Logger.Error(fmt.Sprintf("failed to get US stocks from database. %s", getAllStocksError.Error()))
-		return
+		logger.Error(fmt.Sprintf("failed to get US stocks from database. %s", getAllStocksError.Error()))
+		return getAllStocksError
 	}
 
 	// Construct symbols list to embed in API call URL
@@ -68,54 +73,63 @@ func (tdfda *TwelveDataFinancialDataApi) FetchLatestUSStockPrice() {
 		rawSymbols = append(rawSymbols, stock.Ticker)
 	}
 	symbol := strings.Join(rawSymbols, ",")
-	tdfda.Logger.Info(fmt.Sprintf("going to fetch financial data for supported US stocks - %v", symbol))
+	logger.Info(fmt.Sprintf("going to fetch financial data for supported US stocks - %v", symbol))
 
 	// Try to fetch twelve data financial data api
-	rawResponse, fetchError := http.Get(fmt.Sprintf(
+	fetchStartedAt := time.Now()
+	rawResponse, fetchError := otelhttp.Get(ctx, fmt.Sprintf(
 		"https://api.twelvedata.com/time_series?symbol=%s&interval=1min&apikey=%s",
 		symbol,
 		"api-key",
 	))
+	metrics.ObserveExternalApiCall("twelve_data_financial_data_api", fetchStartedAt, rawResponse, fetchError)
 	if fetchError != nil {
-		tdfda.Logger.Error(fmt.Sprintf("failed to fetch twelve data financial data api. %s", fetchError.Error()))
-		return
+		logger.Error(fmt.Sprintf("failed to fetch twelve data financial data api. %s", fetchError.Error()))
+		return fetchError
 	}
 
 	// Convert api response into byte array
 	response, parseRawResponseError := io.ReadAll(rawResponse.Body)
 	if parseRawResponseError != nil {
-		tdfda.Logger.Error(fmt.Sprintf("failed to parse twelve data financial data api response. %s", parseRawResponseError.Error()))
-		return
+		logger.Error(fmt.Sprintf("failed to parse twelve data financial data api response. %s", parseRawResponseError.Error()))
+		return parseRawResponseError
 	}
 
 	// Convert api response byte array into consumable json
 	var data map[string]TickerDetails
 	convertJsonError := json.Unmarshal(response, &data)
 	if convertJsonError != nil {
-		tdfda.Logger.Error(fmt.Sprintf("failed to convert twelve data financial data api response into json. %s", convertJsonError.Error()))
-		return
+		logger.Error(fmt.Sprintf("failed to convert twelve data financial data api response into json. %s", convertJsonError.Error()))
+		return convertJsonError
 	}
 
 	// Update current price for stocks in database
+	failedUpdates := 0
 	for _, stock := range stocks {
 		stockDetails := data[stock.Ticker]
 		if len(stockDetails.Values) > 0 {
 			latestPrice, parseFloatError := strconv.ParseFloat(stockDetails.Values[0].Close, 64)
 			if parseFloatError != nil {
-				tdfda.Logger.Error(fmt.Sprintf("failed to parse latest price for %s. %s", stock.Ticker, parseFloatError.Error()))
+				logger.Error(fmt.Sprintf("failed to parse latest price for %s. %s", stock.Ticker, parseFloatError.Error()))
+				failedUpdates++
 			} else {
-				_, updateStockPriceError := database.UpdateStockPrice(tdfda.Db, database.UpdateStockPriceParams{
+				_, updateStockPriceError := database.UpdateStockPrice(ctx, tdfda.Db, database.UpdateStockPriceParams{
 					Ticker:       stock.Ticker,
 					CountryId:    country.Id,
 					CurrentPrice: fmt.Sprintf("%.2f", latestPrice),
 				})
 				if updateStockPriceError != nil {
-					tdfda.Logger.Error(fmt.Sprintf("failed to update current price for %s. %s", stock.Ticker, convertJsonError.Error()))
+					logger.Error(fmt.Sprintf("failed to update current price for %s. %s", stock.Ticker, convertJsonError.Error()))
+					failedUpdates++
 				}
-				tdfda.Logger.Info(fmt.Sprintf("updated new price for %s", stock.Ticker))
+				logger.Info(fmt.Sprintf("updated new price for %s", stock.Ticker))
 			}
 		} else {
-			tdfda.Logger.Info(fmt.Sprintf("no new price for %s to update", stock.Ticker))
+			logger.Info(fmt.Sprintf("no new price for %s to update", stock.Ticker))
 		}
 	}
+	if failedUpdates > 0 {
+		return fmt.Errorf("failed to update price of %d stocks", failedUpdates)
+	}
+	return nil
 }
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nighostchris/everytrack-backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Name of the tracer creating spans of our own code, libraries instrumented by otel contrib use their own names
+const tracerName = "github.com/nighostchris/everytrack-backend"
+
+// Tracer creating spans for requests, queries and cron runs
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Set up the global tracer provider and trace context propagation, spans are exported by the exporter in config.
+// Trace ids are generated even without exporter, so logs of the same request can still be correlated.
+// Returns the function flushing pending spans, which should be called on shutdown.
+func New(env *config.Config, logger *zap.Logger) (func(context.Context) error, error) {
+	logger.Info(fmt.Sprintf("initializing tracing with %s exporter", env.TracingExporter))
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(env.TracingSampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(env.TracingServiceName))),
+	}
+
+	switch env.TracingExporter {
+	case "otlp":
+		exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(env.TracingOtlpEndpoint)}
+		if env.TracingOtlpInsecure {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, initExporterError := otlptracehttp.New(context.Background(), exporterOptions...)
+		if initExporterError != nil {
+			return nil, initExporterError
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case "stdout":
+		exporter, initExporterError := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if initExporterError != nil {
+			return nil, initExporterError
+		}
+		options = append(options, sdktrace.WithSyncer(exporter))
+	case "none":
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %s, expected one of none / stdout / otlp", env.TracingExporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Log fields identifying the span in ctx, empty if ctx does not carry a valid span
+func LogFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return []zap.Field{}
+	}
+
+	return []zap.Field{
+		zap.String("traceId", spanContext.TraceID().String()),
+		zap.String("spanId", spanContext.SpanID().String()),
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Upper bound of schedule length to guard against repayments that never cover the monthly interest
+const maxAmortisationScheduleLength = 1200
+
+type AmortisationEntry struct {
+	DueAt     time.Time
+	Payment   decimal.Decimal
+	Interest  decimal.Decimal
+	Principal decimal.Decimal
+	Balance   decimal.Decimal
+}
+
+// Convert annual percentage rate into monthly rate in fraction, e.g. 6 -> 0.005
+func CalculateMonthlyRate(annualRate decimal.Decimal) decimal.Decimal {
+	return annualRate.Div(decimal.NewFromInt(100)).Div(decimal.NewFromInt(12))
+}
+
+// Calculate the fixed monthly repayment which fully amortises the principal within the given number of months
+func CalculateMonthlyRepayment(principal decimal.Decimal, annualRate decimal.Decimal, months int) decimal.Decimal {
+	if months <= 0 {
+		return principal
+	}
+
+	monthlyRate := CalculateMonthlyRate(annualRate)
+	if monthlyRate.IsZero() {
+		return principal.Div(decimal.NewFromInt(int64(months))).RoundUp(2)
+	}
+
+	// payment = P * r / (1 - (1 + r) ^ -n)
+	growth := decimal.NewFromInt(1).Add(monthlyRate).Pow(decimal.NewFromInt(int64(months)))
+	payment := principal.Mul(monthlyRate).Mul(growth).Div(growth.Sub(decimal.NewFromInt(1)))
+
+	return payment.RoundUp(2)
+}
+
+// Calculate the interest accrued on outstanding balance for one month
+func CalculateMonthlyInterest(balance decimal.Decimal, annualRate decimal.Decimal) decimal.Decimal {
+	return balance.Mul(CalculateMonthlyRate(annualRate)).Round(2)
+}
+
+// Count the repayment dates on or before the given time, repayments fall on the same day of month as the start date
+func CountElapsedRepayments(startDate time.Time, at time.Time) int {
+	elapsed := 0
+	for startDate.AddDate(0, elapsed+1, 0).Before(at) || startDate.AddDate(0, elapsed+1, 0).Equal(at) {
+		elapsed += 1
+	}
+
+	return elapsed
+}
+
+// Generate the amortisation schedule of the outstanding balance with a fixed monthly payment,
+// the final entry is trimmed so that the balance ends at exactly zero
+func GenerateAmortisationSchedule(balance decimal.Decimal, annualRate decimal.Decimal, payment decimal.Decimal, firstDueAt time.Time) []AmortisationEntry {
+	schedule := []AmortisationEntry{}
+	remaining := balance
+
+	for index := 0; remaining.IsPositive() && index < maxAmortisationScheduleLength; index++ {
+		interest := CalculateMonthlyInterest(remaining, annualRate)
+		principal := payment.Sub(interest)
+		if !principal.IsPositive() {
+			break
+		}
+		if principal.GreaterThan(remaining) {
+			principal = remaining
+		}
+		remaining = remaining.Sub(principal)
+
+		schedule = append(schedule, AmortisationEntry{
+			DueAt:     firstDueAt.AddDate(0, index, 0),
+			Payment:   principal.Add(interest),
+			Interest:  interest,
+			Principal: principal,
+			Balance:   remaining,
+		})
+	}
+
+	return schedule
+}
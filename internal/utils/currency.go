@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+// Convert amount from one currency to another with exchange rates where 1 unit of base currency equals rate units of target currency
+func ConvertCurrency(amount decimal.Decimal, fromCurrencyId string, toCurrencyId string, exchangeRates []database.ExchangeRate) (decimal.Decimal, error) {
+	if fromCurrencyId == toCurrencyId {
+		return amount, nil
+	}
+
+	for _, exchangeRate := range exchangeRates {
+		if exchangeRate.BaseCurrencyId == fromCurrencyId && exchangeRate.TargetCurrencyId == toCurrencyId {
+			rate, parseRateError := decimal.NewFromString(exchangeRate.Rate)
+			if parseRateError != nil {
+				return decimal.Zero, parseRateError
+			}
+			return amount.Mul(rate), nil
+		}
+	}
+
+	return decimal.Zero, fmt.Errorf("missing exchange rate from %s to %s", fromCurrencyId, toCurrencyId)
+}
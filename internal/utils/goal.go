@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+// Sum the balances of the accounts linked to a goal after converting them into the goal currency
+func CalculateGoalCurrentAmount(currencyId string, accounts []database.AccountSummary, exchangeRates []database.ExchangeRate) (decimal.Decimal, error) {
+	current := decimal.Zero
+	for _, account := range accounts {
+		balance, parseBalanceError := decimal.NewFromString(account.Balance)
+		if parseBalanceError != nil {
+			return current, parseBalanceError
+		}
+		convertedBalance, convertError := ConvertCurrency(balance, account.CurrencyId, currencyId, exchangeRates)
+		if convertError != nil {
+			return current, convertError
+		}
+		current = current.Add(convertedBalance)
+	}
+
+	return current, nil
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+var BalanceHistoryIntervals = []string{"day", "week", "month"}
+
+type BalancePoint struct {
+	Date    time.Time
+	Balance decimal.Decimal
+}
+
+// Reconstruct end-of-day balances for every UTC day between from and to (inclusive) by rewinding the current balance
+// with transactions executed after each day. Transactions must include everything executed after the start of from.
+func ReconstructDailyBalances(currentBalance decimal.Decimal, transactions []database.Transaction, from time.Time, to time.Time) ([]BalancePoint, error) {
+	sortedTransactions := make([]database.Transaction, len(transactions))
+	copy(sortedTransactions, transactions)
+	sort.Slice(sortedTransactions, func(i, j int) bool {
+		return sortedTransactions[i].ExecutedAt.After(sortedTransactions[j].ExecutedAt)
+	})
+
+	firstDay := from.UTC().Truncate(24 * time.Hour)
+	lastDay := to.UTC().Truncate(24 * time.Hour)
+	points := []BalancePoint{}
+	balance := currentBalance
+	transactionIndex := 0
+
+	for day := lastDay; !day.Before(firstDay); day = day.AddDate(0, 0, -1) {
+		endOfDay := day.AddDate(0, 0, 1)
+		// Revert every transaction executed after the end of the day
+		for transactionIndex < len(sortedTransactions) && !sortedTransactions[transactionIndex].ExecutedAt.Before(endOfDay) {
+			amount, calculateError := CalculateSignedTransactionAmount(sortedTransactions[transactionIndex])
+			if calculateError != nil {
+				return []BalancePoint{}, calculateError
+			}
+			balance = balance.Sub(amount)
+			transactionIndex += 1
+		}
+		points = append(points, BalancePoint{Date: day, Balance: balance})
+	}
+
+	// Points are collected from latest to earliest
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+
+	return points, nil
+}
+
+// Aggregate daily balances into week or month buckets, each bucket keeps the balance at the end of its last day
+func AggregateBalances(points []BalancePoint, interval string) []BalancePoint {
+	if interval != "week" && interval != "month" {
+		return points
+	}
+
+	aggregatedPoints := []BalancePoint{}
+	for _, point := range points {
+		var bucket time.Time
+		if interval == "week" {
+			// Weeks start on Monday
+			bucket = point.Date.AddDate(0, 0, -((int(point.Date.Weekday()) + 6) % 7))
+		} else {
+			bucket = time.Date(point.Date.Year(), point.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		if len(aggregatedPoints) > 0 && aggregatedPoints[len(aggregatedPoints)-1].Date.Equal(bucket) {
+			aggregatedPoints[len(aggregatedPoints)-1].Balance = point.Balance
+			continue
+		}
+		aggregatedPoints = append(aggregatedPoints, BalancePoint{Date: bucket, Balance: point.Balance})
+	}
+
+	return aggregatedPoints
+}
@@ -0,0 +1,308 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// JSON Web Key of a public verification key as published in the JWKS endpoint
+type Jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type verificationKey struct {
+	publicKey crypto.PublicKey
+	createdAt time.Time
+}
+
+// Key store holding the asymmetric keys for signing JWT, backed by a directory where the file name is used as key id.
+// Private keys are stored as <kid>.pem in PKCS #8 and verification only public keys as <kid>.pub in PKIX,
+// the newest private key is used for signing and every key is accepted for verification.
+type KeyStore struct {
+	Algorithm string
+	Directory string
+	Logger    *zap.Logger
+
+	mutex            sync.RWMutex
+	signingKid       string
+	signingKey       crypto.Signer
+	signingCreatedAt time.Time
+	verificationKeys map[string]verificationKey
+	reloadedAt       time.Time
+}
+
+const minimumReloadInterval = 10 * time.Second
+
+func NewKeyStore(algorithm string, directory string, logger *zap.Logger) (*KeyStore, error) {
+	keyStore := &KeyStore{Algorithm: algorithm, Directory: directory, Logger: logger}
+	if !keyStore.IsAsymmetric() {
+		return keyStore, nil
+	}
+	if algorithm != "RS256" && algorithm != "EdDSA" {
+		return nil, fmt.Errorf("unsupported jwt signing algorithm %s", algorithm)
+	}
+
+	if reloadError := keyStore.Reload(); reloadError != nil {
+		return nil, reloadError
+	}
+	// Bootstrap the first signing key so that a fresh deployment works out of the box
+	if keyStore.signingKey == nil {
+		if rotateError := keyStore.Rotate(); rotateError != nil {
+			return nil, rotateError
+		}
+	}
+
+	return keyStore, nil
+}
+
+func (ks *KeyStore) IsAsymmetric() bool {
+	return ks != nil && ks.Algorithm != "" && ks.Algorithm != "HS256"
+}
+
+func (ks *KeyStore) signingMethod() jwt.SigningMethod {
+	if ks.Algorithm == "EdDSA" {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// Reload every key from directory, picking up keys rotated by other instances sharing the same directory
+func (ks *KeyStore) Reload() error {
+	entries, readDirectoryError := os.ReadDir(ks.Directory)
+	if readDirectoryError != nil && !errors.Is(readDirectoryError, os.ErrNotExist) {
+		return readDirectoryError
+	}
+
+	var signingKid string
+	var signingKey crypto.Signer
+	var signingCreatedAt time.Time
+	verificationKeys := map[string]verificationKey{}
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if entry.IsDir() || (extension != ".pem" && extension != ".pub") {
+			continue
+		}
+		kid := strings.TrimSuffix(entry.Name(), extension)
+
+		info, infoError := entry.Info()
+		if infoError != nil {
+			return infoError
+		}
+		content, readError := os.ReadFile(filepath.Join(ks.Directory, entry.Name()))
+		if readError != nil {
+			return readError
+		}
+		block, _ := pem.Decode(content)
+		if block == nil {
+			return fmt.Errorf("invalid pem file %s", entry.Name())
+		}
+
+		if extension == ".pub" {
+			publicKey, parseError := x509.ParsePKIXPublicKey(block.Bytes)
+			if parseError != nil {
+				return fmt.Errorf("invalid public key %s. %s", entry.Name(), parseError.Error())
+			}
+			verificationKeys[kid] = verificationKey{publicKey: publicKey, createdAt: info.ModTime()}
+			continue
+		}
+
+		privateKey, parseError := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if parseError != nil {
+			return fmt.Errorf("invalid private key %s. %s", entry.Name(), parseError.Error())
+		}
+		signer, isSigner := privateKey.(crypto.Signer)
+		if !isSigner {
+			return fmt.Errorf("unsupported private key %s", entry.Name())
+		}
+		verificationKeys[kid] = verificationKey{publicKey: signer.Public(), createdAt: info.ModTime()}
+
+		// Only keys matching the configured algorithm can sign, others are kept for verification during migration
+		if ks.isKeyOfAlgorithm(signer.Public()) && info.ModTime().After(signingCreatedAt) {
+			signingKid = kid
+			signingKey = signer
+			signingCreatedAt = info.ModTime()
+		}
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	ks.signingKid = signingKid
+	ks.signingKey = signingKey
+	ks.signingCreatedAt = signingCreatedAt
+	ks.verificationKeys = verificationKeys
+	ks.reloadedAt = time.Now()
+
+	return nil
+}
+
+func (ks *KeyStore) isKeyOfAlgorithm(publicKey crypto.PublicKey) bool {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return ks.Algorithm == "RS256"
+	case ed25519.PublicKey:
+		return ks.Algorithm == "EdDSA"
+	default:
+		return false
+	}
+}
+
+// Generate a new signing key, persist it into directory and make it the active signing key
+func (ks *KeyStore) Rotate() error {
+	var privateKey crypto.Signer
+	if ks.Algorithm == "EdDSA" {
+		_, ed25519Key, generateError := ed25519.GenerateKey(rand.Reader)
+		if generateError != nil {
+			return generateError
+		}
+		privateKey = ed25519Key
+	} else {
+		rsaKey, generateError := rsa.GenerateKey(rand.Reader, 2048)
+		if generateError != nil {
+			return generateError
+		}
+		privateKey = rsaKey
+	}
+
+	encodedKey, marshalError := x509.MarshalPKCS8PrivateKey(privateKey)
+	if marshalError != nil {
+		return marshalError
+	}
+	if makeDirectoryError := os.MkdirAll(ks.Directory, 0o700); makeDirectoryError != nil {
+		return makeDirectoryError
+	}
+
+	kid := fmt.Sprintf("%s-%d", strings.ToLower(ks.Algorithm), time.Now().Unix())
+	fileName := filepath.Join(ks.Directory, fmt.Sprintf("%s.pem", kid))
+	if writeError := os.WriteFile(fileName, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encodedKey}), 0o600); writeError != nil {
+		return writeError
+	}
+	ks.Logger.Info(fmt.Sprintf("generated new jwt signing key %s", kid))
+
+	return ks.Reload()
+}
+
+// Delete keys which are older than the given age, as every token they signed has expired already
+func (ks *KeyStore) Prune(maxAge time.Duration) error {
+	ks.mutex.RLock()
+	expiredKids := []string{}
+	for kid, key := range ks.verificationKeys {
+		if kid != ks.signingKid && time.Since(key.createdAt) > maxAge {
+			expiredKids = append(expiredKids, kid)
+		}
+	}
+	ks.mutex.RUnlock()
+
+	for _, kid := range expiredKids {
+		for _, extension := range []string{".pem", ".pub"} {
+			removeError := os.Remove(filepath.Join(ks.Directory, kid+extension))
+			if removeError != nil && !errors.Is(removeError, os.ErrNotExist) {
+				return removeError
+			}
+		}
+		ks.Logger.Info(fmt.Sprintf("pruned expired jwt signing key %s", kid))
+	}
+
+	return ks.Reload()
+}
+
+func (ks *KeyStore) GetSigningKeyAge() time.Duration {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+	return time.Since(ks.signingCreatedAt)
+}
+
+func (ks *KeyStore) Sign(claims jwt.Claims) (string, error) {
+	ks.mutex.RLock()
+	kid, signingKey := ks.signingKid, ks.signingKey
+	ks.mutex.RUnlock()
+	if signingKey == nil {
+		return "", errors.New("no jwt signing key available")
+	}
+
+	token := jwt.NewWithClaims(ks.signingMethod(), claims)
+	token.Header["kid"] = kid
+	return token.SignedString(signingKey)
+}
+
+// Get the public key of key id, reloading directory when the key is unknown as another instance may have just rotated it
+func (ks *KeyStore) GetVerificationKey(kid string) (crypto.PublicKey, error) {
+	ks.mutex.RLock()
+	key, found := ks.verificationKeys[kid]
+	shouldReload := time.Since(ks.reloadedAt) > minimumReloadInterval
+	ks.mutex.RUnlock()
+	if found {
+		return key.publicKey, nil
+	}
+
+	// Reload is throttled so that tokens with made up key ids cannot keep the server busy reading directory
+	if shouldReload {
+		if reloadError := ks.Reload(); reloadError != nil {
+			return nil, reloadError
+		}
+		ks.mutex.RLock()
+		key, found = ks.verificationKeys[kid]
+		ks.mutex.RUnlock()
+		if found {
+			return key.publicKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key id %s", kid)
+}
+
+func (ks *KeyStore) GetJwks() []Jwk {
+	jwks := []Jwk{}
+	if !ks.IsAsymmetric() {
+		return jwks
+	}
+
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+	for kid, key := range ks.verificationKeys {
+		switch publicKey := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, Jwk{
+				Kid: kid,
+				Kty: "RSA",
+				Alg: "RS256",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, Jwk{
+				Kid: kid,
+				Kty: "OKP",
+				Alg: "EdDSA",
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+
+	return jwks
+}
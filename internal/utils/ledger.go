@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+// Calculate the signed amount of a transaction, income adds to and expense deducts from account balance
+func CalculateSignedTransactionAmount(transaction database.Transaction) (decimal.Decimal, error) {
+	amount, parseAmountError := decimal.NewFromString(transaction.Amount)
+	if parseAmountError != nil {
+		return decimal.Zero, parseAmountError
+	}
+	if !transaction.Income {
+		return amount.Neg(), nil
+	}
+
+	return amount, nil
+}
+
+// Calculate how much the transactions moved the account balance in total
+func CalculateNetTransactionAmount(transactions []database.Transaction) (decimal.Decimal, error) {
+	netAmount := decimal.Zero
+	for _, transaction := range transactions {
+		amount, calculateError := CalculateSignedTransactionAmount(transaction)
+		if calculateError != nil {
+			return decimal.Zero, calculateError
+		}
+		netAmount = netAmount.Add(amount)
+	}
+
+	return netAmount, nil
+}
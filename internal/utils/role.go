@@ -0,0 +1,14 @@
+package utils
+
+import "golang.org/x/exp/slices"
+
+// Roles of workspace members, owners manage the workspace and its members, editors change records and viewers only read
+var WorkspaceRoles = []string{"owner", "editor", "viewer"}
+
+func IsValidWorkspaceRole(role string) bool {
+	return slices.Contains(WorkspaceRoles, role)
+}
+
+func CanWriteWorkspace(role string) bool {
+	return role == "owner" || role == "editor"
+}
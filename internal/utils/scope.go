@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Resources which personal access tokens can be scoped to, named after the route groups under /v1
+var ApiTokenResources = []string{
+	"accounts",
+	"cash",
+	"countries",
+	"currencies",
+	"exrates",
+	"fpayments",
+	"goals",
+	"providers",
+	"settings",
+	"stocks",
+	"transactions",
+}
+
+// Scope is in the format of <resource>:<read|write> where resource can be * for every resource, write implies read
+func IsValidApiTokenScope(scope string) bool {
+	resource, access, found := strings.Cut(scope, ":")
+	if !found || (access != "read" && access != "write") {
+		return false
+	}
+
+	return resource == "*" || slices.Contains(ApiTokenResources, resource)
+}
+
+func HasApiTokenScope(scopes []string, resource string, write bool) bool {
+	if !slices.Contains(ApiTokenResources, resource) {
+		return false
+	}
+
+	for _, scope := range scopes {
+		scopeResource, access, _ := strings.Cut(scope, ":")
+		if scopeResource != "*" && scopeResource != resource {
+			continue
+		}
+		if access == "write" || !write {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/nighostchris/everytrack-backend/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+type StatementPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Statement day is limited to 1 - 28 so that every month has a valid statement date
+func statementDate(year int, month time.Month, statementDay int, location *time.Location) time.Time {
+	return time.Date(year, month, statementDay, 0, 0, 0, 0, location)
+}
+
+// Calculate the statement period [Start, End) that the given time falls into
+func CalculateStatementPeriod(statementDay int, at time.Time) StatementPeriod {
+	currentMonthStatementDate := statementDate(at.Year(), at.Month(), statementDay, at.Location())
+
+	if at.Before(currentMonthStatementDate) {
+		return StatementPeriod{Start: currentMonthStatementDate.AddDate(0, -1, 0), End: currentMonthStatementDate}
+	}
+
+	return StatementPeriod{Start: currentMonthStatementDate, End: currentMonthStatementDate.AddDate(0, 1, 0)}
+}
+
+// Calculate the most recent statement period that has already been closed at the given time
+func CalculateLastClosedStatementPeriod(statementDay int, at time.Time) StatementPeriod {
+	currentPeriod := CalculateStatementPeriod(statementDay, at)
+
+	return StatementPeriod{Start: currentPeriod.Start.AddDate(0, -1, 0), End: currentPeriod.Start}
+}
+
+// Calculate the first due date falling after the statement closing date
+func CalculateStatementDueDate(dueDay int, statementEnd time.Time) time.Time {
+	dueDate := time.Date(statementEnd.Year(), statementEnd.Month(), dueDay, 0, 0, 0, 0, statementEnd.Location())
+	if !dueDate.After(statementEnd) {
+		dueDate = dueDate.AddDate(0, 1, 0)
+	}
+
+	return dueDate
+}
+
+// Calculate the amount owed for a statement period - spending increases it while repayments and refunds reduce it
+func CalculateStatementBalance(transactions []database.Transaction) (decimal.Decimal, error) {
+	netAmount, calculateError := CalculateNetTransactionAmount(transactions)
+	if calculateError != nil {
+		return decimal.Zero, calculateError
+	}
+
+	return netAmount.Neg(), nil
+}
+
+// Calculate the amount owed at the closing date of a statement, by rewinding the current balance with transactions executed since then.
+// The balance carries over unpaid amounts of previous statements, which the transactions within the period alone would miss.
+func CalculateClosingStatementBalance(currentBalance decimal.Decimal, laterTransactions []database.Transaction) (decimal.Decimal, error) {
+	laterNetAmount, calculateError := CalculateNetTransactionAmount(laterTransactions)
+	if calculateError != nil {
+		return decimal.Zero, calculateError
+	}
+
+	return currentBalance.Sub(laterNetAmount).Neg(), nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+type LoginThrottlePolicy struct {
+	// Number of failures tolerated before backoff kicks in
+	BackoffThreshold int
+	BackoffBase      time.Duration
+	// Number of failures after which the subject is locked out for the full lockout duration
+	LockoutThreshold int
+	Lockout          time.Duration
+}
+
+// Calculate how long the next login attempt has to wait given the failures counted within the attempt window.
+// Waiting time doubles with every failure beyond the backoff threshold and is capped by the lockout duration.
+func CalculateLoginRetryAfter(failures int, lastFailureAt time.Time, now time.Time, policy LoginThrottlePolicy) time.Duration {
+	if failures < policy.BackoffThreshold {
+		return 0
+	}
+
+	wait := policy.Lockout
+	if failures < policy.LockoutThreshold {
+		exponent := float64(failures - policy.BackoffThreshold)
+		backoff := time.Duration(float64(policy.BackoffBase) * math.Pow(2, exponent))
+		if backoff < wait {
+			wait = backoff
+		}
+	}
+
+	remaining := lastFailureAt.Add(wait).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
@@ -1,5 +1,7 @@
 package utils
 
+import "time"
+
 type PaymentFrequency struct {
 	Days   int
 	Months int
@@ -26,3 +28,13 @@ func CalculateActualPaymentFrequency(seconds int) PaymentFrequency {
 	paymentFrequency.Months = months
 	return paymentFrequency
 }
+
+// Count the number of whole or partial months from one time until another, 0 if the other time has passed already
+func CalculateMonthsUntil(from time.Time, to time.Time) int {
+	months := 0
+	for from.AddDate(0, months, 0).Before(to) {
+		months += 1
+	}
+
+	return months
+}
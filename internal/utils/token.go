@@ -1,8 +1,13 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,14 +16,19 @@ import (
 )
 
 type TokenUtils struct {
-	Env    *config.Config
-	Logger *zap.Logger
+	Env      *config.Config
+	Logger   *zap.Logger
+	KeyStore *KeyStore
 }
 
 type CustomClaims struct {
 	jwt.RegisteredClaims
+	// Tells token types apart when they are signed by the same asymmetric key
+	TokenType string `json:"token_type,omitempty"`
 }
 
+var tokenTypeNames = []string{"access", "refresh", "challenge"}
+
 func (tu TokenUtils) GetAccessTokenExpiry() time.Time {
 	return time.Now().Add(time.Hour * time.Duration(tu.Env.AccessTokenExpiryInHour))
 }
@@ -27,16 +37,24 @@ func (tu TokenUtils) GetRefreshTokenExpiry() time.Time {
 	return time.Now().Add(time.Hour * time.Duration(tu.Env.RefreshTokenExpiryInHour))
 }
 
-func (tu TokenUtils) GenerateToken(sub string, tokenType int) (string, error) {
-	tu.Logger.Info(fmt.Sprintf("starts generating token of type %d for %s", tokenType, sub))
+func (tu TokenUtils) GetChallengeTokenExpiry() time.Time {
+	return time.Now().Add(time.Minute * time.Duration(tu.Env.TwoFactorChallengeExpiryInMinute))
+}
 
-	// Define which secret key to sign token
-	var secret []byte
+func (tu TokenUtils) getSecret(tokenType int) []byte {
 	if tokenType == 0 {
-		secret = []byte(tu.Env.AccessTokenSecret)
+		return []byte(tu.Env.AccessTokenSecret)
 	} else if tokenType == 1 {
-		secret = []byte(tu.Env.RefreshTokenSecret)
-	} else {
+		return []byte(tu.Env.RefreshTokenSecret)
+	}
+	return []byte(tu.Env.OneTimeTokenSecret)
+}
+
+// Token type 0 is access token, 1 is refresh token and 2 is the challenge token issued between the two login steps of 2FA
+func (tu TokenUtils) GenerateToken(sub string, tokenType int) (string, error) {
+	tu.Logger.Info(fmt.Sprintf("starts generating token of type %d for %s", tokenType, sub))
+
+	if tokenType < 0 || tokenType >= len(tokenTypeNames) {
 		return "", errors.New("invalid token type for generation")
 	}
 
@@ -46,17 +64,30 @@ func (tu TokenUtils) GenerateToken(sub string, tokenType int) (string, error) {
 	claims.Subject = sub
 	if tokenType == 0 {
 		claims.ExpiresAt = jwt.NewNumericDate(tu.GetAccessTokenExpiry())
-	} else {
+	} else if tokenType == 1 {
 		claims.ExpiresAt = jwt.NewNumericDate(tu.GetRefreshTokenExpiry())
+	} else {
+		claims.ExpiresAt = jwt.NewNumericDate(tu.GetChallengeTokenExpiry())
 	}
 	claims.NotBefore = jwt.NewNumericDate(time.Now())
 	claims.IssuedAt = jwt.NewNumericDate(time.Now())
-
-	// Construct JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign JWT
-	signedJwt, signError := token.SignedString(secret)
+	// Unique token id so that tokens issued to the same subject within the same second still differ
+	tokenId, generateTokenIdError := GenerateRandomToken(16)
+	if generateTokenIdError != nil {
+		return "", errors.New("token generation failed")
+	}
+	claims.ID = tokenId
+	claims.TokenType = tokenTypeNames[tokenType]
+
+	// Sign JWT with the active asymmetric key if configured, otherwise with the secret of token type
+	var signedJwt string
+	var signError error
+	if tu.KeyStore.IsAsymmetric() {
+		signedJwt, signError = tu.KeyStore.Sign(claims)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signedJwt, signError = token.SignedString(tu.getSecret(tokenType))
+	}
 
 	if signError != nil {
 		return "", errors.New("token generation failed")
@@ -66,26 +97,42 @@ func (tu TokenUtils) GenerateToken(sub string, tokenType int) (string, error) {
 }
 
 func (tu TokenUtils) VerifyToken(token string, tokenType int) (bool, string) {
-	tu.Logger.Info(fmt.Sprintf("starts verifying token %s", token))
+	tu.Logger.Debug(fmt.Sprintf("starts verifying token of type %d", tokenType))
 
 	t, parseTokenError := jwt.ParseWithClaims(token, &CustomClaims{}, func(tk *jwt.Token) (interface{}, error) {
-		if _, isValidSigningMethod := tk.Method.(*jwt.SigningMethodHMAC); !isValidSigningMethod {
+		switch tk.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			// Tokens signed with secrets are only accepted until the configured cutoff after switching to asymmetric keys,
+			// so that the switch does not log everyone out while leaked secrets stop working eventually
+			if tu.KeyStore.IsAsymmetric() && !time.Now().Before(tu.Env.JwtHmacAcceptedUntil) {
+				return nil, fmt.Errorf("unexpected signing method %s", tk.Header["alg"])
+			}
+			return tu.getSecret(tokenType), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			if !tu.KeyStore.IsAsymmetric() {
+				return nil, fmt.Errorf("unexpected signing method %s", tk.Header["alg"])
+			}
+			kid, _ := tk.Header["kid"].(string)
+			return tu.KeyStore.GetVerificationKey(kid)
+		default:
 			return nil, fmt.Errorf("unexpected signing method %s", tk.Header["alg"])
 		}
-		if tokenType == 0 {
-			return []byte(tu.Env.AccessTokenSecret), nil
-		} else {
-			return []byte(tu.Env.RefreshTokenSecret), nil
-		}
 	})
 
 	if parseTokenError != nil || !t.Valid {
-		tu.Logger.Error(fmt.Sprintf("invalid token %s", token))
+		tu.Logger.Error("invalid token")
 		return false, ""
 	}
 
 	claim := t.Claims.(*CustomClaims)
-	tu.Logger.Info(fmt.Sprintf("claim in token %s - %#v", token, claim))
+	tu.Logger.Debug(fmt.Sprintf("verified token of type %s for %s", claim.TokenType, claim.Subject))
+
+	// Asymmetric tokens of every type share the same keys, so the type claim is mandatory for them
+	_, isHmacToken := t.Method.(*jwt.SigningMethodHMAC)
+	if (!isHmacToken || claim.TokenType != "") && claim.TokenType != tokenTypeNames[tokenType] {
+		tu.Logger.Error(fmt.Sprintf("unexpected token type %s", claim.TokenType))
+		return false, ""
+	}
 
 	currentTime := time.Now().Unix()
 	if claim.ExpiresAt.Time.Unix() <= currentTime {
@@ -96,3 +143,56 @@ func (tu TokenUtils) VerifyToken(token string, tokenType int) (bool, string) {
 
 	return true, sub
 }
+
+// Generate a hex encoded random string from the given number of random bytes
+func GenerateRandomToken(size int) (string, error) {
+	randomBytes := make([]byte, size)
+	if _, readError := rand.Read(randomBytes); readError != nil {
+		return "", readError
+	}
+
+	return hex.EncodeToString(randomBytes), nil
+}
+
+// Hash token with SHA-256 before persisting so that a database leak does not expose usable tokens
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (tu TokenUtils) signOneTimeToken(value string) string {
+	mac := hmac.New(sha256.New, []byte(tu.Env.OneTimeTokenSecret))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Generate a random one-time token for email links, signed so that forged tokens are rejected before hitting database
+func (tu TokenUtils) GenerateOneTimeToken() (string, error) {
+	value, generateError := GenerateRandomToken(32)
+	if generateError != nil {
+		return "", generateError
+	}
+
+	return fmt.Sprintf("%s.%s", value, tu.signOneTimeToken(value)), nil
+}
+
+func (tu TokenUtils) VerifyOneTimeToken(token string) bool {
+	value, signature, found := strings.Cut(token, ".")
+	if !found {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(tu.signOneTimeToken(value)))
+}
+
+// Prefix of personal access tokens, which tells them apart from JWT in the authorization header and makes leaked tokens easy to scan for
+const ApiTokenPrefix = "et_pat_"
+
+func GenerateApiToken() (string, error) {
+	value, generateError := GenerateRandomToken(32)
+	if generateError != nil {
+		return "", generateError
+	}
+
+	return ApiTokenPrefix + value, nil
+}
@@ -1,36 +1,156 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nighostchris/everytrack-backend/internal/app/cron"
 	"github.com/nighostchris/everytrack-backend/internal/app/handlers"
 	"github.com/nighostchris/everytrack-backend/internal/config"
 	"github.com/nighostchris/everytrack-backend/internal/connections/postgres"
 	"github.com/nighostchris/everytrack-backend/internal/connections/server"
+	"github.com/nighostchris/everytrack-backend/internal/database"
 	"github.com/nighostchris/everytrack-backend/internal/logger"
+	"github.com/nighostchris/everytrack-backend/internal/metrics"
+	"github.com/nighostchris/everytrack-backend/internal/redact"
+	"github.com/nighostchris/everytrack-backend/internal/tracing"
+	"github.com/nighostchris/everytrack-backend/internal/utils"
+	"go.uber.org/zap"
 )
 
 func main() {
 	// Initialize environment variable configs
 	env := config.New()
 	// Initialize logger
-	logger := logger.New(env.LogLevel)
+	logger := logger.New(env.LogLevel, redact.New(env))
+	// Initialize tracing, spans are flushed on shutdown
+	shutdownTracing, initTracingError := tracing.New(env, logger)
+	if initTracingError != nil {
+		logger.Error(fmt.Sprintf("failed to initialize tracing. %s", initTracingError.Error()))
+		os.Exit(1)
+	}
 	// Establish database connection
-	db := postgres.New(env.Database)
+	db := postgres.New(env)
+	metrics.RegisterDatabaseCollector(db, logger)
+	// Migrations are not bound to the query timeout, a long running one should not be cut off halfway
+	ctx := context.Background()
+	// Run "migrate up | down [steps] | seed | version" subcommand and exit
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if migrateError := runMigrateCommand(ctx, db, logger, os.Args[2:]); migrateError != nil {
+			logger.Error(fmt.Sprintf("failed to run migrate command. %s", migrateError.Error()))
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	// Bring database schema and reference data up to date
+	if env.MigrateOnStartup {
+		if migrateError := runMigrateCommand(ctx, db, logger, []string{"up"}); migrateError != nil {
+			logger.Error(fmt.Sprintf("failed to migrate database. %s", migrateError.Error()))
+			os.Exit(1)
+		}
+		if seedError := runMigrateCommand(ctx, db, logger, []string{"seed"}); seedError != nil {
+			logger.Error(fmt.Sprintf("failed to seed database. %s", seedError.Error()))
+			os.Exit(1)
+		}
+	}
+	// Load keys for signing and verifying JWT
+	keyStore, loadKeyStoreError := utils.NewKeyStore(env.JwtSigningAlgorithm, env.JwtKeysDirectory, logger)
+	if loadKeyStoreError != nil {
+		logger.Error(fmt.Sprintf("failed to load jwt key store. %s", loadKeyStoreError.Error()))
+		os.Exit(1)
+	}
+	tokenUtils := &utils.TokenUtils{Env: env, Logger: logger, KeyStore: keyStore}
 	// Initialize web server
-	app := server.New(env.DomainWhitelist, logger, env)
+	app := server.New(env, db, logger, tokenUtils)
 
 	// Define routes for server
-	handlers := handlers.Init(db, env, logger)
+	handlers := handlers.Init(db, env, logger, tokenUtils)
 	handlers.BindRoutes(app)
 
 	// Initialize cron jobs
-	// cronJobs := cron.Init(db, env, logger)
+	cronJobs := cron.Init(db, env, logger)
+	cronJobs.RotateJwtSigningKeys(keyStore)
+	cronJobs.EraseDeletedClients()
+	cronJobs.MonitorCreditStatements()
 
 	// Start web server
-	if initWebServerError := app.Start(fmt.Sprintf("%s:%d", env.WebServerHost, env.WebServerPort)); initWebServerError != nil {
-		logger.Error(initWebServerError.Error())
-		os.Exit(1)
+	go func() {
+		if initWebServerError := app.Start(fmt.Sprintf("%s:%d", env.WebServerHost, env.WebServerPort)); initWebServerError != nil && !errors.Is(initWebServerError, http.ErrServerClosed) {
+			logger.Error(initWebServerError.Error())
+			os.Exit(1)
+		}
+	}()
+
+	// Wait for termination signal, then drain in-flight requests, stop cron jobs and close database connections
+	signalCtx, stopSignal := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stopSignal()
+	<-signalCtx.Done()
+	logger.Info("shutting down")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(env.ShutdownTimeoutInSecond)*time.Second)
+	defer cancelShutdown()
+	if shutdownError := app.Shutdown(shutdownCtx); shutdownError != nil {
+		logger.Error(fmt.Sprintf("failed to shut down web server gracefully. %s", shutdownError.Error()))
+	}
+	if stopError := cronJobs.Stop(shutdownCtx); stopError != nil {
+		logger.Error(fmt.Sprintf("failed to wait for cron jobs to finish. %s", stopError.Error()))
+	}
+	db.Close()
+	if shutdownTracingError := shutdownTracing(shutdownCtx); shutdownTracingError != nil {
+		logger.Error(fmt.Sprintf("failed to flush pending spans. %s", shutdownTracingError.Error()))
+	}
+	logger.Info("shut down")
+	logger.Sync()
+}
+
+func runMigrateCommand(ctx context.Context, db *pgxpool.Pool, logger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command, expected one of up / down / seed / version")
+	}
+
+	switch args[0] {
+	case "up":
+		migrations, migrateError := database.MigrateUp(ctx, db)
+		for _, migration := range migrations {
+			logger.Info(fmt.Sprintf("applied migration %d_%s", migration.Version, migration.Name))
+		}
+		return migrateError
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			parsedSteps, parseStepsError := strconv.Atoi(args[1])
+			if parseStepsError != nil || parsedSteps < 1 {
+				return fmt.Errorf("invalid number of steps %s", args[1])
+			}
+			steps = parsedSteps
+		}
+		migrations, migrateError := database.MigrateDown(ctx, db, steps)
+		for _, migration := range migrations {
+			logger.Info(fmt.Sprintf("reverted migration %d_%s", migration.Version, migration.Name))
+		}
+		return migrateError
+	case "seed":
+		if seedError := database.Seed(ctx, db); seedError != nil {
+			return seedError
+		}
+		logger.Info("seeded reference data")
+		return nil
+	case "version":
+		version, getVersionError := database.GetMigrationVersion(ctx, db)
+		if getVersionError != nil {
+			return getVersionError
+		}
+		logger.Info(fmt.Sprintf("database is at migration version %d", version))
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %s, expected one of up / down / seed / version", args[0])
 	}
 }